@@ -0,0 +1,1029 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/rrule"
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"github.com/goyalmunish/reminder/pkg/vault"
+)
+
+// Exit codes returned by the app.
+const (
+	ExitOK     = 0
+	ExitError  = 1
+	ExitUsage  = 2
+	ExitLocked = 3
+)
+
+// ErrorUsage is returned when a subcommand is invoked with invalid arguments.
+var ErrorUsage = errors.New("Invalid usage; run `reminder help` for details")
+
+const usageText = `Usage: reminder [<command> [<options>] [<args>]]
+
+Without any command, the interactive session is started.
+
+Commands:
+  add [--tag <slug>]... [--due <date>] [--repeat <rule>] [--main] [--no-sync] <text>
+        add a new note (the --tag option can be repeated, or be comma separated); with --no-sync, the
+        note is kept out of the calendar sync
+  list [--tag <slug>] [--status <status>] [--main] [--format <format>]
+        list notes (status can be pending, snoozed, suspended, done or all; default is pending, which
+        leaves out the snoozed notes)
+  done [--comment <text>] <id>
+        mark the note as done; for a recurring note, complete its current occurrence instead (with
+        optional comment) and move its due date to the next occurrence
+  history <id>
+        show the completed occurrences of the recurring note, along with its streaks
+  comment <id> <text>
+        add a comment to the note
+  due <id> <date>
+        update due date of the note, or clear it with nil
+  date <date>
+        show the date (and time) which the <date> resolves to, without changing anything
+  repeat <id> <rule>
+        update recurrence of the note (starting from its due date), or clear it with nil
+  snooze <id> <snooze>
+        hide the pending note from the interactive views until the <snooze> is over, or wake it up with nil
+  window (<id> | --tag <slug>) <window>
+        update due window of the note (or of all the notes with the tag), or clear it with nil
+  nosync <id> (on | off)
+        keep the note out of the calendar sync (on), or let it be synced again as per the sync filter (off)
+  search [--format <format>] <text>
+        search through text, summary and comments of all notes
+  tags [--format <format>]
+        list all tags along with number of their pending notes
+  stats [--format <format>]
+        show stats of the data file
+  merge [--base <file>] [--newer] <conflict file>
+        merge a _CONFLICT file back into the data file; the --base (such as a backup) is the common
+        ancestor of both the files, and the conflicts are asked for unless --newer is given (in which
+        case the side updated last wins)
+  migrate --to <store> <file>
+        copy the whole data to a new data file of given store (json or sqlite)
+  upgrade [--dry-run]
+        upgrade the data file to the current schema version (after creating its backup); with
+        --dry-run, just show what would be changed
+  backups
+        list the backups of the data file, along with number of notes in each of them
+  restore <backup>
+        replace the data file with the backup (its path or file name); the current data file is backed up first
+  rekey [--decrypt]
+        re-encrypt the data file along with its backups with a new passphrase (taken from the
+        REMINDER_NEW_PASSPHRASE environment variable, or else asked for); with --decrypt, write
+        them as plaintext JSON instead
+  export [--todo] [--output <file>]
+        export the pending notes with a due date as an iCalendar (.ics) file, as events (or, with
+        --todo, as to-dos), to be imported into any calendar app; it is written to stdout unless the
+        --output file is given
+  serve [--todo] [--addr <host:port>]
+        serve the export (as above) at http://<host:port>/reminder.ics (default is 127.0.0.1:8765),
+        for any calendar app to subscribe to; each request gets the latest notes, and so, the other
+        sessions can update the data file meanwhile
+  calendar auth (login [--device] | status | revoke)
+        authorize the app to access Google Calendar (by a browser, or with --device, by entering a
+        code on another device; the latter is the default on machines without a browser), show the
+        status of the authorization, or revoke it
+  help
+        show this help
+
+The <format> can be text (default), json, yaml or csv.
+
+The <date> is a date such as DD-MM-YYYY, DD-MM, YYYY-MM-DD, today, tomorrow, +3d, +2w, in 10 days,
+next friday, friday or end of month; optionally followed by time of day (HH:MM, or at HH:MM) and
+an IANA timezone (such as Asia/Kolkata), for example: "next friday at 18:00 Europe/London".
+
+The <rule> is a recurrence rule (subset of RFC 5545 RRULE) with FREQ (DAILY, WEEKLY, MONTHLY or
+YEARLY), and optionally INTERVAL, BYDAY, BYMONTHDAY, BYMONTH, and COUNT or UNTIL; for example,
+FREQ=WEEKLY;BYDAY=MO,TH or FREQ=MONTHLY;BYDAY=-1FR (last Friday) or FREQ=MONTHLY;BYMONTHDAY=-1.
+
+The <snooze> is a duration from now (such as 3h, 2 days or 1 week), or a <date> optionally preceded
+by until (such as until 15-11, or until tomorrow at 09:00).
+
+The <window> is the number of days before (lead) and after (grace) a due date during which the
+note shows up as approaching its due date, in the form <lead days>[,<grace days>], such as 60, 1,2
+or ,3; the values not given fall back to the largest ones of the note's tags, and then to the
+due_window settings.
+
+The <id> of a note is shown against it by the list and search commands; any unambiguous
+prefix of the id can be used as well.
+
+While another session holds the lock on the data file, the commands which only read the data
+(list, search, tags, stats, history, date, export and serve) still work, but the rest of the commands fail.
+
+Exit codes: 0 on success, 1 on failure, 2 on invalid usage, and 3 if the data file is locked.
+`
+
+// ExitCode returns the process exit code corresponding to the error returned by Run.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrorUsage):
+		return ExitUsage
+	case errors.Is(err, model.ErrorDataFileLocked), errors.Is(err, model.ErrorReadOnly):
+		return ExitLocked
+	}
+	return ExitError
+}
+
+// writeCommands are the subcommands which update the data file.
+var writeCommands = []string{"add", "done", "comment", "due", "repeat", "snooze", "window", "nosync", "merge", "restore", "rekey"}
+
+// unlockedCommands are the long-running subcommands which never write the data, and so, never take the
+// lock on the data file.
+var unlockedCommands = []string{"serve", "calendar"}
+
+// tagSlugs is a flag.Value collecting repeated (or comma separated) tag slugs.
+type tagSlugs []string
+
+func (t *tagSlugs) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *tagSlugs) Set(value string) error {
+	for _, slug := range strings.Split(value, ",") {
+		slug = strings.ToLower(strings.TrimSpace(slug))
+		if slug != "" {
+			*t = append(*t, slug)
+		}
+	}
+	return nil
+}
+
+// RunCommand runs the given subcommand non-interactively.
+// The args[0] is the name of the subcommand, and rest of args are its options and arguments.
+func RunCommand(reminderData *model.ReminderData, args []string) error {
+	name, args := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Print(usageText)
+		return nil
+	}
+	switch name {
+	case "add":
+		return commandAdd(reminderData, args)
+	case "list":
+		return commandList(reminderData, args)
+	case "done":
+		return commandDone(reminderData, args)
+	case "history":
+		return commandHistory(reminderData, args)
+	case "comment":
+		return commandComment(reminderData, args)
+	case "due":
+		return commandDue(reminderData, args)
+	case "date":
+		return commandDate(args)
+	case "repeat":
+		return commandRepeat(reminderData, args)
+	case "snooze":
+		return commandSnooze(reminderData, args)
+	case "window":
+		return commandWindow(reminderData, args)
+	case "nosync":
+		return commandNoSync(reminderData, args)
+	case "search":
+		return commandSearch(reminderData, args)
+	case "tags":
+		return commandTags(reminderData, args)
+	case "stats":
+		return commandStats(reminderData, args)
+	case "merge":
+		return commandMerge(reminderData, args)
+	case "migrate":
+		return commandMigrate(reminderData, args)
+	case "upgrade":
+		return commandUpgrade(reminderData, args)
+	case "backups":
+		return commandBackups(reminderData, args)
+	case "restore":
+		return commandRestore(reminderData, args)
+	case "rekey":
+		return commandRekey(reminderData, args)
+	case "export":
+		return commandExport(reminderData, args)
+	case "serve":
+		return commandServe(reminderData, args)
+	case "calendar":
+		return commandCalendar(args)
+	}
+	return fmt.Errorf("Unknown command %q: %w", name, ErrorUsage)
+}
+
+// persistMigrations persists the data upgraded from an older schema version (see
+// model.ReminderData.PersistMigrations).
+// The commands which write the data call it once they are validated, right before writing the data,
+// so that a rejected command leaves the data file as it is; the upgrade command persists it by itself.
+func persistMigrations(reminderData *model.ReminderData) error {
+	return reminderData.PersistMigrations()
+}
+
+// newFlagSet returns flag set for given subcommand, which reports errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses the flags and wraps any error as ErrorUsage.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %v: %w", fs.Name(), err, ErrorUsage)
+	}
+	return nil
+}
+
+// tagIdsFromSlugs returns ids of tags with given slugs.
+func tagIdsFromSlugs(reminderData *model.ReminderData, slugs []string) ([]int, error) {
+	tagIDs := make([]int, 0, len(slugs))
+	for _, slug := range slugs {
+		tag := reminderData.TagFromSlug(slug)
+		if tag == nil {
+			return nil, fmt.Errorf("Tag %q doesn't exist: %w", slug, ErrorUsage)
+		}
+		if !utils.IsMemberOfSlice(tag.Id, tagIDs) {
+			tagIDs = append(tagIDs, tag.Id)
+		}
+	}
+	return tagIDs, nil
+}
+
+// validateDueDate validates the due date the same way as the interactive prompt does.
+func validateDueDate(date string) error {
+	if err := utils.ValidateDateString()(date); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	return nil
+}
+
+// validateSnooze validates the snooze of the note (on its copy), and wraps any error as ErrorUsage.
+func validateSnooze(note *model.Note, snooze string) error {
+	noteCopy := *note
+	if err := noteCopy.Snooze(snooze); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	return nil
+}
+
+// validateRecurrence validates the recurrence rule, and wraps any error as ErrorUsage.
+func validateRecurrence(rule string) error {
+	if rule == "nil" {
+		return nil
+	}
+	if _, err := rrule.Parse(rule); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	return nil
+}
+
+// noteFromArg returns the note referred by given command-line argument (an id or its prefix).
+func noteFromArg(reminderData *model.ReminderData, arg string) (*model.Note, error) {
+	note, err := reminderData.NoteByIDPrefix(arg)
+	if err != nil {
+		return nil, fmt.Errorf("Note %q: %w", arg, err)
+	}
+	return note, nil
+}
+
+// formatFlag defines the --format flag on given flag set.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", string(model.OutputFormat_Text), "output format")
+}
+
+// parseFormat parses value of the --format flag, and wraps any error as ErrorUsage.
+func parseFormat(name string) (model.OutputFormat, error) {
+	format, err := model.ParseOutputFormat(name)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	return format, nil
+}
+
+// printRendered prints the rendered output.
+func printRendered(text string, err error) error {
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
+
+func commandAdd(reminderData *model.ReminderData, args []string) error {
+	var slugs tagSlugs
+	fs := newFlagSet("add")
+	fs.Var(&slugs, "tag", "tag slug")
+	due := fs.String("due", "", "due date")
+	repeat := fs.String("repeat", "", "recurrence rule")
+	isMain := fs.Bool("main", false, "flag the note as main")
+	noSync := fs.Bool("no-sync", false, "keep the note out of the calendar sync")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if text == "" {
+		return fmt.Errorf("add: note's text is empty: %w", ErrorUsage)
+	}
+	tagIDs, err := tagIdsFromSlugs(reminderData, slugs)
+	if err != nil {
+		return err
+	}
+	if *due != "" {
+		if err := validateDueDate(*due); err != nil {
+			return err
+		}
+	}
+	if *repeat != "" {
+		if *due == "" {
+			return fmt.Errorf("add: --repeat requires --due, as the recurrence starts from the due date: %w", ErrorUsage)
+		}
+		if err := validateRecurrence(*repeat); err != nil {
+			return err
+		}
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	// register the note, and then update rest of its attributes (all saved together)
+	var note *model.Note
+	err = reminderData.Transaction(func() error {
+		if note, err = reminderData.NewNoteRegistration(tagIDs, text); err != nil {
+			return err
+		}
+		if *due != "" {
+			if err := reminderData.UpdateNoteCompleteBy(note, *due); err != nil {
+				return err
+			}
+		}
+		if *repeat != "" {
+			if err := reminderData.UpdateNoteRecurrence(note, *repeat); err != nil {
+				return err
+			}
+		}
+		if *noSync {
+			if err := reminderData.UpdateNoteNoSync(note, true); err != nil {
+				return err
+			}
+		}
+		if *isMain {
+			return reminderData.ToggleNoteMainFlag(note)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if note.CompleteBy > 0 {
+		fmt.Printf("Added note %s, due on %s\n", note.ShortId(), dueDatePreview(note))
+		return nil
+	}
+	fmt.Printf("Added note %s\n", note.ShortId())
+	return nil
+}
+
+func commandList(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("list")
+	tagSlug := fs.String("tag", "", "tag slug")
+	status := fs.String("status", string(model.NoteStatus_Pending), "note status")
+	onlyMain := fs.Bool("main", false, "list only main notes")
+	formatName := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	notes := reminderData.Notes
+	switch model.NoteStatus(*status) {
+	case model.NoteStatus_Pending:
+		// note: like the interactive views, the snoozed notes are listed only by their own status
+		notes = notes.WithStatus(model.NoteStatus_Pending).NotSnoozed()
+	case model.NoteStatus_Suspended, model.NoteStatus_Done:
+		notes = notes.WithStatus(model.NoteStatus(*status))
+	case "snoozed":
+		notes = notes.WithStatus(model.NoteStatus_Pending).Snoozed()
+	case "all":
+	default:
+		return fmt.Errorf("list: unknown status %q: %w", *status, ErrorUsage)
+	}
+	if *tagSlug != "" {
+		tagIDs, err := tagIdsFromSlugs(reminderData, []string{*tagSlug})
+		if err != nil {
+			return err
+		}
+		var withTag model.Notes
+		for _, note := range notes {
+			if utils.IsMemberOfSlice(tagIDs[0], note.TagIds) {
+				withTag = append(withTag, note)
+			}
+		}
+		notes = withTag
+	}
+	if *onlyMain {
+		notes = notes.OnlyMain()
+	}
+	return printRendered(reminderData.RenderNotes(notes, format))
+}
+
+func commandDone(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("done")
+	comment := fs.String("comment", "", "completion comment (for a recurring note)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("done: expects exactly one note id: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	recurring := reminderData.IsRecurring(note)
+	if !recurring && *comment != "" {
+		return fmt.Errorf("done: --comment is accepted only for a recurring note: %w", ErrorUsage)
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if recurring {
+		completion, err := reminderData.CompleteNote(note, *comment)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Completed the occurrence of note %s due on %s\n", note.ShortId(), note.DueDateStr(completion.DueDate))
+		if note.Status == model.NoteStatus_Done {
+			fmt.Printf("Marked note %s as done, as it has no more occurrences\n", note.ShortId())
+		} else {
+			fmt.Printf("Next occurrence of note %s is due on %s\n", note.ShortId(), note.DueDateStr(note.CompleteBy))
+		}
+		return nil
+	}
+	if err := reminderData.UpdateNoteStatus(note, model.NoteStatus_Done); err != nil {
+		return err
+	}
+	fmt.Printf("Marked note %s as done\n", note.ShortId())
+	return nil
+}
+
+func commandHistory(reminderData *model.ReminderData, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("history: expects exactly one note id: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(reminderData.CompletionHistory(note))
+	return nil
+}
+
+func commandComment(reminderData *model.ReminderData, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("comment: expects note id and the comment text: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.AddNoteComment(note, strings.Join(args[1:], " ")); err != nil {
+		return err
+	}
+	fmt.Printf("Added comment to note %s\n", note.ShortId())
+	return nil
+}
+
+func commandDue(reminderData *model.ReminderData, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("due: expects note id and the due date: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	date := strings.Join(args[1:], " ")
+	if err := validateDueDate(date); err != nil {
+		return err
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.UpdateNoteCompleteBy(note, date); err != nil {
+		return err
+	}
+	if note.CompleteBy == 0 {
+		fmt.Printf("Cleared due date of note %s\n", note.ShortId())
+		return nil
+	}
+	fmt.Printf("Updated due date of note %s to %s\n", note.ShortId(), dueDatePreview(note))
+	return nil
+}
+
+// commandDate shows what the due date text resolves to, without changing anything.
+func commandDate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("date: expects the due date: %w", ErrorUsage)
+	}
+	dueDate, err := utils.ParseDueDate(strings.Join(args, " "), utils.CurrentLocation())
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	fmt.Println(utils.DueDateToPreviewStr(dueDate))
+	return nil
+}
+
+// dueDatePreview returns human-readable representation of the note's due date in its timezone.
+func dueDatePreview(note *model.Note) string {
+	return utils.DueDateToPreviewStr(time.Unix(note.CompleteBy, 0).In(note.Location()))
+}
+
+func commandRepeat(reminderData *model.ReminderData, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("repeat: expects note id and the recurrence rule: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	if err := validateRecurrence(args[1]); err != nil {
+		return err
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.UpdateNoteRecurrence(note, args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Updated recurrence of note %s\n", note.ShortId())
+	return nil
+}
+
+func commandNoSync(reminderData *model.ReminderData, args []string) error {
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		return fmt.Errorf("nosync: expects note id, and on or off: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.UpdateNoteNoSync(note, args[1] == "on"); err != nil {
+		return err
+	}
+	if note.NoSync {
+		fmt.Printf("Note %s is kept out of the calendar sync\n", note.ShortId())
+		return nil
+	}
+	fmt.Printf("Note %s is synced to the calendar (as per the sync filter)\n", note.ShortId())
+	return nil
+}
+
+func commandSnooze(reminderData *model.ReminderData, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("snooze: expects note id and the snooze duration or date: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	snooze := strings.Join(args[1:], " ")
+	if err := validateSnooze(note, snooze); err != nil {
+		return err
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.SnoozeNote(note, snooze); err != nil {
+		return err
+	}
+	if note.DeferUntil == 0 {
+		fmt.Printf("Woke up note %s\n", note.ShortId())
+		return nil
+	}
+	fmt.Printf("Snoozed note %s until %s\n", note.ShortId(), utils.DueDateToPreviewStr(time.Unix(note.DeferUntil, 0).In(note.Location())))
+	return nil
+}
+
+func commandWindow(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("window")
+	tagSlug := fs.String("tag", "", "slug of the tag")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if *tagSlug != "" {
+		if len(args) != 1 {
+			return fmt.Errorf("window: expects the due window of the tag: %w", ErrorUsage)
+		}
+		tag := reminderData.TagFromSlug(strings.ToLower(*tagSlug))
+		if tag == nil {
+			return fmt.Errorf("Tag %q doesn't exist: %w", *tagSlug, ErrorUsage)
+		}
+		if _, err := model.ParseDueWindow(args[0]); err != nil {
+			return fmt.Errorf("%v: %w", err, ErrorUsage)
+		}
+		if err := persistMigrations(reminderData); err != nil {
+			return err
+		}
+		if err := reminderData.UpdateTagDueWindow(tag, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Updated due window of tag %q\n", tag.Slug)
+		return nil
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("window: expects note id and the due window: %w", ErrorUsage)
+	}
+	note, err := noteFromArg(reminderData, args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := model.ParseDueWindow(args[1]); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := reminderData.UpdateNoteDueWindow(note, args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Updated due window of note %s\n", note.ShortId())
+	return nil
+}
+
+func commandSearch(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("search")
+	formatName := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	query := strings.ToLower(strings.TrimSpace(strings.Join(fs.Args(), " ")))
+	if query == "" {
+		return fmt.Errorf("search: search text is empty: %w", ErrorUsage)
+	}
+	var matched model.Notes
+	for _, note := range reminderData.Notes {
+		text, err := note.SearchableText()
+		if err != nil {
+			return err
+		}
+		if strings.Contains(strings.ToLower(text), query) {
+			matched = append(matched, note)
+		}
+	}
+	return printRendered(reminderData.RenderNotes(matched, format))
+}
+
+func commandTags(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("tags")
+	formatName := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	return printRendered(reminderData.RenderTags(format))
+}
+
+func commandStats(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("stats")
+	formatName := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	return printRendered(reminderData.RenderStats(format))
+}
+
+func commandMerge(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("merge")
+	baseFile := fs.String("base", "", "common ancestor of both the files")
+	newer := fs.Bool("newer", false, "resolve conflicts in favour of the side updated last")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("merge: expects exactly one conflict file: %w", ErrorUsage)
+	}
+	resolver := askMergeResolver
+	if *newer {
+		resolver = model.NewerWinsResolver
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	report, err := reminderData.MergeConflictFile(fs.Arg(0), *baseFile, resolver)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	fmt.Printf("Merged %q into the data file; it can be removed now\n", fs.Arg(0))
+	return nil
+}
+
+// askMergeResolver asks the user how to resolve the conflict.
+func askMergeResolver(conflict model.MergeConflict) (bool, error) {
+	fmt.Printf("Conflict in %q of note %q:\n", conflict.Field, conflict.NoteText)
+	// note: options can't have \n character
+	optionIndex, _, err := utils.AskOption([]string{
+		fmt.Sprintf("Keep data file's: %s", strings.ReplaceAll(conflict.Ours, "\n", " ")),
+		fmt.Sprintf("Take conflict file's: %s", strings.ReplaceAll(conflict.Theirs, "\n", " ")),
+	}, "Select Option")
+	if err != nil {
+		return false, err
+	}
+	return optionIndex == 1, nil
+}
+
+func commandMigrate(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("migrate")
+	kind := fs.String("to", "", "destination store")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *kind == "" || fs.NArg() != 1 {
+		return fmt.Errorf("migrate: expects the --to store and exactly one destination file: %w", ErrorUsage)
+	}
+	dst, err := model.OpenStore(model.StoreKind(*kind), fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, ErrorUsage)
+	}
+	defer func() {
+		utils.LogError(dst.Close())
+	}()
+	// note: an existing destination is rejected before the upgrade of the data file is persisted
+	exists, err := dst.Exists()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("The destination %q already exists", dst.Path())
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	if err := model.MigrateStore(reminderData, dst); err != nil {
+		return err
+	}
+	fmt.Printf("Migrated %d notes and %d tags to %q\n", len(reminderData.Notes), len(reminderData.Tags), dst.Path())
+	fmt.Printf("To use it, set `store: %s` and `data_file: %s` under `appinfo` in the config file\n", *kind, dst.Path())
+	return nil
+}
+
+func commandUpgrade(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("upgrade")
+	dryRun := fs.Bool("dry-run", false, "just show what would be changed")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	fmt.Print(reminderData.MigrationReport())
+	if *dryRun {
+		if len(reminderData.AppliedMigrations()) > 0 {
+			fmt.Println("Dry run; the data file is left unchanged")
+		}
+		return nil
+	}
+	if reminderData.ReadOnly() {
+		return fmt.Errorf("upgrade: %w", model.ErrorReadOnly)
+	}
+	return reminderData.PersistMigrations()
+}
+
+func commandBackups(reminderData *model.ReminderData, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("backups: doesn't expect any arguments: %w", ErrorUsage)
+	}
+	backups, err := reminderData.Backups()
+	if err != nil {
+		return err
+	}
+	reminderData.CountBackupNotes(backups)
+	for _, backup := range backups {
+		fmt.Println(backup)
+	}
+	return nil
+}
+
+func commandRestore(reminderData *model.ReminderData, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("restore: expects exactly one backup: %w", ErrorUsage)
+	}
+	backups, err := reminderData.Backups()
+	if err != nil {
+		return err
+	}
+	// the backup can be referred by its file name as well
+	backupFile := args[0]
+	for _, backup := range backups {
+		if filepath.Base(backup.Path) == args[0] {
+			backupFile = backup.Path
+		}
+	}
+	if err := reminderData.RestoreBackup(backupFile); err != nil {
+		return err
+	}
+	fmt.Printf("Restored the data file from %q\n", backupFile)
+	return nil
+}
+
+func commandRekey(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("rekey")
+	decrypt := fs.Bool("decrypt", false, "write the data file as plaintext JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("rekey: doesn't expect any arguments: %w", ErrorUsage)
+	}
+	var newVault *vault.Vault
+	if !*decrypt {
+		passphrase, err := readPassphrase(newPassphraseEnv, "", true)
+		if err != nil {
+			return err
+		}
+		if newVault, err = vault.New(passphrase); err != nil {
+			return err
+		}
+	}
+	if err := persistMigrations(reminderData); err != nil {
+		return err
+	}
+	files, err := reminderData.Rekey(newVault)
+	if err != nil {
+		return err
+	}
+	if *decrypt {
+		fmt.Printf("Decrypted the data file and its %d copies\n", len(files)-1)
+		if config != nil && config.AppInfo.Encrypt {
+			fmt.Println("Set `encrypt: false` under `appinfo` in the config file, otherwise the data file is encrypted again on next run")
+		}
+		return nil
+	}
+	fmt.Printf("Encrypted the data file and its %d copies with the new passphrase\n", len(files)-1)
+	return nil
+}
+
+// icsComponent returns the iCalendar component which the notes are exported as.
+func icsComponent(todo bool) string {
+	if todo {
+		return calendar.ICSComponent_Todo
+	}
+	return calendar.ICSComponent_Event
+}
+
+func commandExport(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("export")
+	todo := fs.Bool("todo", false, "export the notes as to-dos")
+	output := fs.String("output", "", "file to write the export to")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("export: doesn't expect any arguments: %w", ErrorUsage)
+	}
+	data, err := reminderData.ExportICS(icsComponent(*todo))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		fmt.Print(data)
+		return nil
+	}
+	if err := utils.WriteFileAtomic(utils.TryConvertTildaBasedPath(*output), []byte(data), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Exported the pending notes to %q\n", *output)
+	return nil
+}
+
+// defaultServeAddr is the address at which the export is served by default (only to the local machine).
+const defaultServeAddr = "127.0.0.1:8765"
+
+func commandServe(reminderData *model.ReminderData, args []string) error {
+	fs := newFlagSet("serve")
+	todo := fs.Bool("todo", false, "export the notes as to-dos")
+	addr := fs.String("addr", defaultServeAddr, "address to listen at")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("serve: doesn't expect any arguments: %w", ErrorUsage)
+	}
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return fmt.Errorf("serve: %v: %w", err, ErrorUsage)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		logger.Warn(fmt.Sprintf("The notes are served at %q, which may be reachable from other machines.", *addr))
+	}
+	component := icsComponent(*todo)
+	handler := calendar.NewFeedHandler(func() (string, error) {
+		// read the latest data, as it may have been updated by another session
+		latest, err := reminderData.Store().Load()
+		if err != nil {
+			return "", err
+		}
+		if err := applySettings(latest); err != nil {
+			return "", err
+		}
+		return latest.ExportICS(component)
+	})
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	// stop serving on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		utils.LogError(server.Shutdown(context.Background()))
+	}()
+	fmt.Printf("Serving the pending notes at http://%s%s (press Ctrl-C to stop)\n", listener.Addr(), calendar.FeedPath)
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func commandCalendar(args []string) error {
+	if len(args) < 2 || args[0] != "auth" {
+		return fmt.Errorf("calendar: expects auth followed by login, status or revoke: %w", ErrorUsage)
+	}
+	action, args := args[1], args[2:]
+	fs := newFlagSet("calendar auth " + action)
+	device := fs.Bool("device", calendar.IsHeadless(), "authorize by entering a code on another device")
+	switch action {
+	case "login":
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		args = fs.Args()
+	case "status", "revoke":
+	default:
+		return fmt.Errorf("calendar auth: unknown action %q: %w", action, ErrorUsage)
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("calendar auth %s: doesn't expect any arguments: %w", action, ErrorUsage)
+	}
+	calOptions := calendar.DefaultOptions()
+	if config != nil && config.Calendar != nil {
+		calOptions = config.Calendar
+	}
+	if calOptions.Provider != "" && calOptions.Provider != calendar.Provider_Google {
+		return fmt.Errorf("The authorization is needed only for Google Calendar, but the calendar provider is %q", calOptions.Provider)
+	}
+	auth, err := calendar.NewGoogleAuth(calOptions)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch action {
+	case "login":
+		if _, err := auth.Authorize(ctx, *device); err != nil {
+			return err
+		}
+		fmt.Printf("Authorized the app; the token is saved at %q\n", auth.TokenFile)
+	case "status":
+		status, err := auth.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Println(status)
+	case "revoke":
+		if err := auth.Revoke(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Revoked the authorization, and deleted the token")
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package reminder_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/cmd/reminder"
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// runCommand runs the subcommand, and returns its output (on stdout) along with its exit code.
+func runCommand(reminderData *model.ReminderData, args ...string) (string, int) {
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := reminder.RunCommand(reminderData, args)
+	w.Close()
+	os.Stdout = stdout
+	output, _ := io.ReadAll(r)
+	return string(output), reminder.ExitCode(err)
+}
+
+// newTestData returns the data of a new data file.
+func newTestData(dataFilePath string) *model.ReminderData {
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	return reminderData
+}
+
+func TestExitCode(t *testing.T) {
+	utils.AssertEqual(t, reminder.ExitCode(nil), reminder.ExitOK)
+	utils.AssertEqual(t, reminder.ExitCode(errors.New("some error")), reminder.ExitError)
+	utils.AssertEqual(t, reminder.ExitCode(reminder.ErrorUsage), reminder.ExitUsage)
+	utils.AssertEqual(t, reminder.ExitCode(model.ErrorDataFileLocked), reminder.ExitLocked)
+	utils.AssertEqual(t, reminder.ExitCode(model.ErrorReadOnly), reminder.ExitLocked)
+}
+
+func TestRunCommandUsage(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	reminderData := newTestData(dataFilePath)
+	for _, args := range [][]string{
+		{"unknown"},
+		{"add"},
+		{"add", "--unknown", "a note"},
+		{"add", "--tag", "missing", "a note"},
+		{"add", "--due", "someday", "a note"},
+		{"add", "--repeat", "FREQ=DAILY", "a note"},
+		{"list", "--status", "later"},
+		{"list", "--format", "xml"},
+		{"done"},
+		{"done", "one", "two"},
+		{"backups", "extra"},
+	} {
+		_, exitCode := runCommand(reminderData, args...)
+		utils.AssertEqual(t, exitCode, reminder.ExitUsage)
+	}
+	// the invalid commands don't change anything
+	utils.AssertEqual(t, len(reminderData.Notes), 0)
+	output, exitCode := runCommand(reminderData, "help")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	utils.AssertEqual(t, output[:len("Usage: reminder")], "Usage: reminder")
+}
+
+func TestRunCommandLocked(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	reminderData := newTestData(dataFilePath)
+	// another session holds the lock on the data file
+	lock, err := model.LockDataFile(dataFilePath)
+	utils.AssertEqual(t, err, nil)
+	defer lock.Release()
+	_, err = model.LockDataFile(dataFilePath)
+	utils.AssertEqual(t, reminder.ExitCode(err), reminder.ExitLocked)
+	// and so, the data is opened in read-only mode
+	reminderData.SetReadOnly(true)
+	_, exitCode := runCommand(reminderData, "add", "a note")
+	utils.AssertEqual(t, exitCode, reminder.ExitLocked)
+	reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 0)
+	// the data can still be read
+	_, exitCode = runCommand(reminderData, "list")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+}
+
+func TestRunCommandAddListDone(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	reminderData := newTestData(dataFilePath)
+	// add
+	output, exitCode := runCommand(reminderData, "add", "--due", "2026-11-01", "--main", "pay", "the", "rent")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	utils.AssertEqual(t, len(reminderData.Notes), 1)
+	note := reminderData.Notes[0]
+	utils.AssertEqual(t, output, "Added note "+note.ShortId()+", due on Sun, 01 Nov 2026\n")
+	_, exitCode = runCommand(reminderData, "add", "call the bank")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	// the notes are saved to the data file
+	reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 2)
+	// list
+	var records []model.NoteRecord
+	output, exitCode = runCommand(reminderData, "list", "--main", "--format", "json")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	utils.AssertEqual(t, json.Unmarshal([]byte(output), &records), nil)
+	utils.AssertEqual(t, len(records), 1)
+	utils.AssertEqual(t, records[0].Id, note.Id)
+	utils.AssertEqual(t, records[0].Text, "pay the rent")
+	utils.AssertEqual(t, records[0].Status, model.NoteStatus_Pending)
+	utils.AssertEqual(t, records[0].IsMain, true)
+	// done (by a prefix of the id)
+	output, exitCode = runCommand(reminderData, "done", note.Id[:6])
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	utils.AssertEqual(t, output, "Marked note "+note.ShortId()+" as done\n")
+	records = nil
+	output, _ = runCommand(reminderData, "list", "--format", "json")
+	utils.AssertEqual(t, json.Unmarshal([]byte(output), &records), nil)
+	utils.AssertEqual(t, len(records), 1)
+	utils.AssertEqual(t, records[0].Text, "call the bank")
+	// the snoozed notes are listed by their own status
+	_, exitCode = runCommand(reminderData, "snooze", reminderData.Notes[1].Id, "2 days")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	records = nil
+	output, _ = runCommand(reminderData, "list", "--format", "json")
+	utils.AssertEqual(t, json.Unmarshal([]byte(output), &records), nil)
+	utils.AssertEqual(t, len(records), 0)
+	output, _ = runCommand(reminderData, "list", "--status", "snoozed", "--format", "json")
+	utils.AssertEqual(t, json.Unmarshal([]byte(output), &records), nil)
+	utils.AssertEqual(t, len(records), 1)
+	records = nil
+	output, _ = runCommand(reminderData, "list", "--status", "done", "--format", "json")
+	utils.AssertEqual(t, json.Unmarshal([]byte(output), &records), nil)
+	utils.AssertEqual(t, len(records), 1)
+	utils.AssertEqual(t, records[0].Id, note.Id)
+	// an unknown (or ambiguous) id is an error, but not an invalid usage
+	_, exitCode = runCommand(reminderData, "done", "no-such-id")
+	utils.AssertEqual(t, exitCode, reminder.ExitError)
+}
+
+func TestRunCommandMigrations(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// a data file of an older schema version
+	_ = os.MkdirAll(path.Dir(dataFilePath), 0755)
+	oldData, _ := os.ReadFile(path.Join("..", "..", "test", "test_data_file.json"))
+	_ = os.WriteFile(dataFilePath, oldData, 0600)
+	reminderData, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()) > 0, true)
+	// note: the test data file doesn't record its own path
+	reminderData.DataFile = dataFilePath
+	// the commands which only read the data leave the data file as it is
+	for _, args := range [][]string{{"list"}, {"tags"}, {"stats"}, {"backups"}} {
+		_, exitCode := runCommand(reminderData, args...)
+		utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	}
+	data, _ := os.ReadFile(dataFilePath)
+	utils.AssertEqual(t, string(data), string(oldData))
+	backups, _ := reminderData.Backups()
+	utils.AssertEqual(t, len(backups), 0)
+	// and so do the commands which write the data, but are rejected
+	for _, args := range [][]string{{"add", "--unknown", "a note"}, {"done", "no-such-id"}, {"window", "no-such-tag", "7"}} {
+		_, exitCode := runCommand(reminderData, args...)
+		utils.AssertEqual(t, exitCode != reminder.ExitOK, true)
+	}
+	data, _ = os.ReadFile(dataFilePath)
+	utils.AssertEqual(t, string(data), string(oldData))
+	backups, _ = reminderData.Backups()
+	utils.AssertEqual(t, len(backups), 0)
+	// whereas the commands which write the data persist the upgrade first
+	_, exitCode := runCommand(reminderData, "add", "a note")
+	utils.AssertEqual(t, exitCode, reminder.ExitOK)
+	data, _ = os.ReadFile(dataFilePath)
+	utils.AssertEqual(t, strings.Contains(string(data), "schema_version"), true)
+	backups, _ = reminderData.Backups()
+	utils.AssertEqual(t, len(backups), 1)
+}
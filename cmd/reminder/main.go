@@ -2,15 +2,26 @@
 Tool `reminder` is a command-line (terminal) based interactive app for organizing tasks with minimal efforts.
 
 Just run it as `go run ./cmd/reminder`
+
+Run without any arguments, it starts the interactive session. Otherwise, the
+first argument is treated as a subcommand (such as `add`, `list`, `done`,
+`comment` or `search`) which runs without any prompts; run `reminder help` for
+the details.
 */
 package reminder
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/goyalmunish/reminder/internal/model"
 	"github.com/goyalmunish/reminder/internal/settings"
+	"github.com/goyalmunish/reminder/pkg/filelock"
 	"github.com/goyalmunish/reminder/pkg/logger"
 	"github.com/goyalmunish/reminder/pkg/utils"
 )
@@ -18,11 +29,20 @@ import (
 // flow is recursive function for overall flow of interactivity
 var config *settings.Settings
 
-func Run() error {
+// applySettings applies the settings (other than the app-wide ones) to the data.
+func applySettings(reminderData *model.ReminderData) error {
+	reminderData.SetDueWindowOptions(config.DueWindow)
+	reminderData.SetEventOptions(config.Calendar.Events)
+	reminderData.SetSyncFilter(config.Calendar.Filter)
+	return reminderData.SetRedactionOptions(config.Redaction)
+}
+
+// Run runs the app with given command-line arguments (excluding the program name).
+// With no arguments, it starts the interactive session.
+func Run(args []string) error {
 	// initialization
 	var err error
 	var runID = uuid.New()
-	var startInteractiveProcess bool = true
 	// note: setting are loaded before logger is being setup; it will assume only default logrus settings
 	config, err = settings.LoadConfig()
 	if err != nil {
@@ -34,53 +54,100 @@ func Run() error {
 		"run_id": runID,
 	})
 
-	// make sure DataFile exists
-	if err := model.MakeSureFileExists(config.AppInfo.DataFile, true); err != nil {
+	// set the timezone of the app
+	// note: it is set before reading the data, as the data migrations may depend on it
+	if utils.Location, err = utils.LoadLocation(config.AppInfo.TimeZone); err != nil {
 		return err
 	}
 
-	// read and parse the existing data
-	reminderData, err := model.ReadDataFile(config.AppInfo.DataFile, false)
+	// set up the encryption of the data file (if it is encrypted, or is to be encrypted)
+	if err := setupEncryption(config.AppInfo.DataFile); err != nil {
+		return err
+	}
+
+	// open the store, and make sure it exists
+	// note: user details are asked only for the interactive session
+	store, err := model.OpenStore(model.StoreKind(config.AppInfo.Store), config.AppInfo.DataFile)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		utils.LogError(store.Close())
+	}()
+	if err := model.MakeSureStoreExists(store, len(args) == 0); err != nil {
+		return err
+	}
 
-	// check if the data file is locked by another session
-	if reminderData.MutexLock {
-		fmt.Printf("WARNING! %s\n", model.ErrorMutexLockOn.Error())
-		reset, err := utils.AskBoolean("But, do you want to force reset the lock?")
-		if err != nil {
-			return err
+	// acquire the lock on the data file
+	// note: if another session holds the lock, the data is opened in read-only mode
+	// note: the long-running commands never take the lock (and so, they are always read-only), so that
+	// the other sessions can update the data file meanwhile
+	readOnly := len(args) > 0 && utils.IsMemberOfSlice(args[0], unlockedCommands)
+	var lock *filelock.Lock
+	if !readOnly {
+		lock, err = model.LockDataFile(config.AppInfo.DataFile)
+	}
+	if errors.Is(err, model.ErrorDataFileLocked) {
+		// never touch the data file while another session holds the lock on it
+		if len(args) > 0 && utils.IsMemberOfSlice(args[0], writeCommands) {
+			return fmt.Errorf("%s: %w", args[0], err)
 		}
-		if !reset {
-			// exit now without resetting the lock
-			return model.ErrorMutexLockOn
+		if len(args) == 0 {
+			fmt.Printf("WARNING! %v\n", err)
+			browse, err := utils.AskBoolean("But, do you want to browse the notes in read-only mode?")
+			if err != nil {
+				return err
+			}
+			if !browse {
+				return model.ErrorDataFileLocked
+			}
 		}
-		// proceed forward to reset the lock, but don't start the interactive process
-		startInteractiveProcess = false
+		readOnly = true
+	} else if err != nil {
+		return err
 	}
-
-	// make sure lock is released and any uncommitted data is persisted
 	defer func() {
-		if !reminderData.MutexLock {
-			// prevent multiple runs of cleanup
-			return
+		utils.LogError(lock.Release())
+	}()
+
+	// read and parse the existing data
+	reminderData, err := store.Load()
+	if errors.Is(err, model.ErrorCorruptDataFile) {
+		if len(args) > 0 || readOnly {
+			return fmt.Errorf("%w; run the app without any command to restore it from its last good copy", err)
 		}
-		// mutex lock is enable
-		reminderData.MutexLock = false
-		if err := reminderData.UpdateDataFile("Turning OFF the Mutex Lock, persisting the data, and closing the app!"); err != nil {
-			utils.LogError(err)
+		reminderData, err = recoverDataFile(store, err)
+	}
+	if err != nil {
+		return err
+	}
+	reminderData.SetReadOnly(readOnly)
+	if err := applySettings(reminderData); err != nil {
+		return err
+	}
+
+	// encrypt the existing plaintext data file (along with its copies), if the encryption is just enabled
+	if model.Encryption() != nil && !readOnly {
+		if encrypted, err := model.IsEncryptedFile(store.Path()); err == nil && !encrypted {
+			files, err := reminderData.Rekey(model.Encryption())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Encrypted the data file and its %d copies\n", len(files)-1)
 		}
-	}()
+	}
 
-	// early exit if conditions are not met
-	if !startInteractiveProcess {
-		return model.ErrorInteractiveProcessSkipped
+	// run the non-interactive subcommand, if asked for
+	if len(args) > 0 {
+		return RunCommand(reminderData, args)
 	}
 
-	reminderData.MutexLock = true
-	if err := reminderData.UpdateDataFile("Turning ON the Mutex Lock!"); err != nil {
-		return err
+	// persist the data upgraded from an older schema version
+	if len(reminderData.AppliedMigrations()) > 0 && !reminderData.ReadOnly() {
+		fmt.Print(reminderData.MigrationReport())
+		if err := reminderData.PersistMigrations(); err != nil {
+			return err
+		}
 	}
 
 	// start the repeating interactive process
@@ -90,15 +157,138 @@ func Run() error {
 	return nil
 }
 
+// Environment variables holding the passphrase of the data file (meant for scripts).
+const (
+	passphraseEnv    = "REMINDER_PASSPHRASE"
+	newPassphraseEnv = "REMINDER_NEW_PASSPHRASE"
+)
+
+// setupEncryption enables encryption of the data file if it is already encrypted, or if
+// the encryption is enabled in the config.
+func setupEncryption(dataFile string) error {
+	encrypted, err := model.IsEncryptedFile(dataFile)
+	if err != nil {
+		return err
+	}
+	if !encrypted && !config.AppInfo.Encrypt {
+		return nil
+	}
+	if model.StoreKind(config.AppInfo.Store) != model.StoreKind_JSON {
+		return fmt.Errorf("Encryption of the data file is supported only for the %q store", model.StoreKind_JSON)
+	}
+	// a new passphrase is asked twice
+	passphrase, err := readPassphrase(passphraseEnv, config.AppInfo.PassphraseFile, !encrypted)
+	if err != nil {
+		return err
+	}
+	v, err := model.NewDataFileVault(dataFile, passphrase)
+	if err != nil {
+		return err
+	}
+	model.SetEncryption(v)
+	return nil
+}
+
+// readPassphrase reads the passphrase from the environment variable, or else from the
+// passphrase file (if any), or else asks it from the user.
+func readPassphrase(envVar string, passphraseFile string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(envVar); passphrase != "" {
+		return passphrase, nil
+	}
+	if passphraseFile != "" {
+		passphraseFile = utils.TryConvertTildaBasedPath(passphraseFile)
+		if info, err := os.Stat(passphraseFile); err == nil && info.Mode().Perm()&0077 != 0 {
+			logger.Warn(fmt.Sprintf("The passphrase file %q is accessible by other users; consider `chmod 600` on it.", passphraseFile))
+		}
+		byteValue, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(byteValue), "\r\n"), nil
+	}
+	passphrase, err := utils.GeneratePrompt("passphrase", "")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	confirmed, err := utils.GeneratePrompt("passphrase_confirm", "")
+	if err != nil {
+		return "", err
+	}
+	if confirmed != passphrase {
+		return "", errors.New("The passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+// recoverDataFile offers to restore the corrupt data file from its last good copy.
+// It returns the restored data, or the original readErr if the data file is not restored.
+func recoverDataFile(store model.Store, readErr error) (*model.ReminderData, error) {
+	fmt.Printf("WARNING! %v\n", readErr)
+	dataFile := store.Path()
+	goodFile, err := model.LastGoodDataFile(dataFile)
+	if err != nil {
+		utils.LogError(err)
+		return nil, readErr
+	}
+	restore, err := utils.AskBoolean(fmt.Sprintf("Do you want to restore it from its last good copy %q?", goodFile))
+	if err != nil {
+		return nil, err
+	}
+	if !restore {
+		return nil, readErr
+	}
+	if err := model.RestoreDataFile(dataFile, goodFile); err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
+
+// listBackupsAndAskRestore lists the backups, and offers to restore the selected one.
+func listBackupsAndAskRestore(reminderData *model.ReminderData) error {
+	backups, err := reminderData.Backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Println("There are no backups yet")
+		return nil
+	}
+	reminderData.CountBackupNotes(backups)
+	options := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		options = append(options, backup.String())
+	}
+	optionIndex, _, err := utils.AskOption(options, "Select Backup to Restore")
+	if err != nil {
+		return err
+	}
+	restore, err := utils.AskBoolean(fmt.Sprintf("Do you want to replace the data file with %q?", backups[optionIndex].Path))
+	if err != nil || !restore {
+		return err
+	}
+	if err := reminderData.RestoreBackup(backups[optionIndex].Path); err != nil {
+		return err
+	}
+	fmt.Printf("Restored the data file from %q\n", backups[optionIndex].Path)
+	return nil
+}
+
 func RepeatInteractiveSession(reminderData *model.ReminderData) error {
 	var err error
 	// print data stats
 	stats, err := reminderData.Stats()
 	fmt.Println(stats)
 	utils.LogError(err)
+	if reminderData.ReadOnly() {
+		fmt.Println("Note: The data file is opened in read-only mode; any changes won't be saved.")
+	}
 	// try automatic backup
-	_, err = reminderData.AutoBackup(24 * 60 * 60)
+	backupFile, err := reminderData.AutoBackup(24 * 60 * 60)
 	utils.LogError(err)
+	if backupFile != "" {
+		_, err = reminderData.PruneBackups(config.Backup)
+		utils.LogError(err)
+	}
 	// ask the main menu
 	fmt.Println("| =========================== MAIN MENU =========================== |")
 	fmt.Println("|     Use 'Ctrl-c' to jump one level up (towards the Main Menu)     |")
@@ -118,9 +308,12 @@ func RepeatInteractiveSession(reminderData *model.ReminderData) error {
 		fmt.Sprintf("%s %s", utils.Symbols["hat"], "Main Notes"),
 		fmt.Sprintf("%s %s", utils.Symbols["search"], "Search Notes"),
 		fmt.Sprintf("%s %s", utils.Symbols["backup"], "Create Backup"),
+		fmt.Sprintf("%s %s", utils.Symbols["backup"], "List Backups"),
 		fmt.Sprintf("%s %s", utils.Symbols["zzz"], "Suspended Notes"),
+		fmt.Sprintf("%s %s", utils.Symbols["snooze"], "Snoozed Notes"),
 		fmt.Sprintf("%s %s", utils.Symbols["telescope"], "Look Ahead"),
-		fmt.Sprintf("%s %s", utils.Symbols["refresh"], "Google Cloud Sync"),
+		fmt.Sprintf("%s %s", utils.Symbols["refresh"], "Calendar Sync"),
+		fmt.Sprintf("%s %s", utils.Symbols["calendar"], "Import from Calendar"),
 		fmt.Sprintf("%s %s", utils.Symbols["pad"], "Display Data File")}, "Select Option")
 	// operate on main options
 	switch result {
@@ -133,13 +326,29 @@ func RepeatInteractiveSession(reminderData *model.ReminderData) error {
 	case fmt.Sprintf("%s %s", utils.Symbols["search"], "Search Notes"):
 		err = reminderData.SearchNotes()
 	case fmt.Sprintf("%s %s", utils.Symbols["backup"], "Create Backup"):
-		_, err = reminderData.CreateBackup()
+		// note: in read-only mode, the backups are left alone (as are the changes)
+		if reminderData.ReadOnly() {
+			err = model.ErrorReadOnly
+		} else if _, err = reminderData.CreateBackup(); err == nil {
+			_, err = reminderData.PruneBackups(config.Backup)
+		}
+	case fmt.Sprintf("%s %s", utils.Symbols["backup"], "List Backups"):
+		err = listBackupsAndAskRestore(reminderData)
 	case fmt.Sprintf("%s %s", utils.Symbols["zzz"], "Suspended Notes"):
 		err = reminderData.PrintNotesAndAskOptions(model.Notes{}, "suspended_notes", -1, "default")
+	case fmt.Sprintf("%s %s", utils.Symbols["snooze"], "Snoozed Notes"):
+		err = reminderData.PrintNotesAndAskOptions(model.Notes{}, "snoozed_notes", -1, "default")
 	case fmt.Sprintf("%s %s", utils.Symbols["telescope"], "Look Ahead"):
 		err = reminderData.PrintNotesAndAskOptions(model.Notes{}, "pending_long_view_notes", -1, "due-date")
-	case fmt.Sprintf("%s %s", utils.Symbols["refresh"], "Google Cloud Sync"):
-		err = reminderData.SyncCalendar(config.Calendar)
+	case fmt.Sprintf("%s %s", utils.Symbols["refresh"], "Calendar Sync"):
+		// an interrupt stops the sync (rather than the app)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err = reminderData.SyncCalendar(ctx, config.Calendar)
+		stop()
+	case fmt.Sprintf("%s %s", utils.Symbols["calendar"], "Import from Calendar"):
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err = reminderData.ImportFromCalendar(ctx, config.Calendar)
+		stop()
 	case fmt.Sprintf("%s %s", utils.Symbols["pad"], "Display Data File"):
 		err = reminderData.DisplayDataFile()
 	case fmt.Sprintf("%s %s %s", utils.Symbols["checkerdFlag"], "Exit", utils.Symbols["redFlag"]):
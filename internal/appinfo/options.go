@@ -2,11 +2,23 @@ package appinfo
 
 type Options struct {
 	DataFile string `json:"data_file" yaml:"data_file" mapstructure:"data_file"`
+	// Store is the storage backend of the data file: "json" (default) or "sqlite".
+	Store string `json:"store" yaml:"store" mapstructure:"store"`
+	// Encrypt enables passphrase-based encryption of the data file and its backups.
+	Encrypt bool `json:"encrypt" yaml:"encrypt" mapstructure:"encrypt"`
+	// PassphraseFile is an optional file holding the passphrase (meant for scripts);
+	// the REMINDER_PASSPHRASE environment variable takes precedence over it.
+	PassphraseFile string `json:"passphrase_file" yaml:"passphrase_file" mapstructure:"passphrase_file"`
+	// TimeZone is the IANA timezone (such as "Asia/Kolkata") of the app, in which the dates are
+	// displayed and the due dates are entered; it defaults to the local timezone of the system.
+	TimeZone string `json:"timezone" yaml:"timezone" mapstructure:"timezone"`
 }
 
 func DefaultOptions() *Options {
 	dataFilePath := "~/reminder/data.json"
 	return &Options{
 		DataFile: dataFilePath,
+		Store:    "json",
+		Encrypt:  false,
 	}
 }
@@ -0,0 +1,222 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+/*
+A BackupOptions represents the retention policy of the timestamped backups.
+
+Similar to tools like restic, for each of the last KeepDaily days (having any
+backup), the latest backup of that day is kept; and likewise for weeks and
+months. The latest backup is always kept. Setting all of them to 0 keeps all
+the backups.
+*/
+type BackupOptions struct {
+	KeepDaily   int `json:"keep_daily" yaml:"keep_daily" mapstructure:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly" yaml:"keep_weekly" mapstructure:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly" yaml:"keep_monthly" mapstructure:"keep_monthly"`
+}
+
+func DefaultBackupOptions() *BackupOptions {
+	return &BackupOptions{
+		KeepDaily:   7,
+		KeepWeekly:  4,
+		KeepMonthly: 12,
+	}
+}
+
+/*
+A Backup represents a timestamped backup of the data file.
+*/
+type Backup struct {
+	Path      string
+	CreatedAt int64
+	// Notes is the number of notes in the backup (as counted by CountBackupNotes), or -1 if
+	// the backup couldn't be read.
+	Notes int
+}
+
+// String provides basic string representation of a backup.
+func (b Backup) String() string {
+	notes := "unreadable"
+	if b.Notes >= 0 {
+		notes = fmt.Sprintf("%d notes", b.Notes)
+	}
+	return fmt.Sprintf("%s | %-12s | %s", utils.UnixTimestampToLongTimeStr(b.CreatedAt), notes, path.Base(b.Path))
+}
+
+// backupPath returns path of the backup of the data file created at given time.
+func backupPath(dataFilePath string, createdAt int64) string {
+	ext := path.Ext(dataFilePath)
+	return dataFilePath[:len(dataFilePath)-len(ext)] + "_backup_" + strconv.FormatInt(createdAt, 10) + ext
+}
+
+// storeKind returns kind of the store in which the data is persisted.
+func (rd *ReminderData) storeKind() StoreKind {
+	if _, ok := rd.Store().(*SQLiteStore); ok {
+		return StoreKind_SQLite
+	}
+	return StoreKind_JSON
+}
+
+// readBackup reads the backup the same way as the data file.
+func (rd *ReminderData) readBackup(backupFile string) (*ReminderData, error) {
+	store, err := OpenStore(rd.storeKind(), backupFile)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.Load()
+}
+
+// Backups returns all the timestamped backups of the data file (latest first).
+// Note: The backups aren't read; see CountBackupNotes for their note counts.
+func (rd *ReminderData) Backups() ([]Backup, error) {
+	dataFile := utils.TryConvertTildaBasedPath(rd.DataFile)
+	ext := path.Ext(dataFile)
+	base := dataFile[:len(dataFile)-len(ext)]
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(path.Base(base)) + `_backup_(\d+)` + regexp.QuoteMeta(ext) + "$")
+	matches, err := filepath.Glob(base + "_backup_*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	var backups []Backup
+	for _, match := range matches {
+		groups := pattern.FindStringSubmatch(path.Base(match))
+		if groups == nil {
+			// such as the "_backup_latest" symlink
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(groups[1], 10, 64)
+		backups = append(backups, Backup{Path: match, CreatedAt: createdAt})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt > backups[j].CreatedAt })
+	return backups, nil
+}
+
+// CountBackupNotes reads the given backups to count their notes, such as for listing them.
+func (rd *ReminderData) CountBackupNotes(backups []Backup) {
+	for i := range backups {
+		backups[i].Notes = -1
+		if data, err := rd.readBackup(backups[i].Path); err == nil {
+			backups[i].Notes = len(data.Notes)
+		} else {
+			logger.Warn(fmt.Sprintf("Unable to read the backup %q: %v", backups[i].Path, err))
+		}
+	}
+}
+
+// backupsToKeep returns paths of the backups (latest first) to be kept as per the retention policy.
+func backupsToKeep(backups []Backup, opts *BackupOptions) map[string]bool {
+	keep := make(map[string]bool)
+	if len(backups) == 0 {
+		return keep
+	}
+	keep[backups[0].Path] = true
+	keepLatestPerPeriod := func(count int, period func(createdAt int64) string) {
+		// note: a negative count (as well as 0) keeps none for the period
+		if count <= 0 {
+			return
+		}
+		periods := make(map[string]bool)
+		for _, backup := range backups {
+			p := period(backup.CreatedAt)
+			if periods[p] {
+				continue
+			}
+			if len(periods) == count {
+				break
+			}
+			periods[p] = true
+			keep[backup.Path] = true
+		}
+	}
+	keepLatestPerPeriod(opts.KeepDaily, func(createdAt int64) string {
+		return utils.UnixTimestampToTime(createdAt).Format("2006-01-02")
+	})
+	keepLatestPerPeriod(opts.KeepWeekly, func(createdAt int64) string {
+		year, week := utils.UnixTimestampToTime(createdAt).ISOWeek()
+		return fmt.Sprintf("%d-W%d", year, week)
+	})
+	keepLatestPerPeriod(opts.KeepMonthly, func(createdAt int64) string {
+		return utils.UnixTimestampToTime(createdAt).Format("2006-01")
+	})
+	return keep
+}
+
+// PruneBackups removes the timestamped backups not to be kept as per the retention policy.
+// It returns paths of the removed backups.
+func (rd *ReminderData) PruneBackups(opts *BackupOptions) ([]string, error) {
+	if rd.readOnly {
+		return nil, ErrorReadOnly
+	}
+	if opts == nil || (opts.KeepDaily <= 0 && opts.KeepWeekly <= 0 && opts.KeepMonthly <= 0) {
+		return nil, nil
+	}
+	backups, err := rd.Backups()
+	if err != nil {
+		return nil, err
+	}
+	keep := backupsToKeep(backups, opts)
+	var removed []string
+	for _, backup := range backups {
+		if keep[backup.Path] {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup.Path)
+	}
+	if len(removed) > 0 {
+		logger.Info(fmt.Sprintf("Pruned %d backups: %s", len(removed), strings.Join(removed, ", ")))
+	}
+	return removed, nil
+}
+
+// RestoreBackup swaps the given backup into place of the data file, and reloads the data from it.
+// The current data file is backed up first, so that the restore itself can be undone.
+// Note: It is meant to be called while holding the lock on the data file (see LockDataFile).
+func (rd *ReminderData) RestoreBackup(backupFile string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	// make sure the backup is a valid one
+	backupData, err := rd.readBackup(backupFile)
+	if err != nil {
+		return fmt.Errorf("Unable to read the backup %q: %w", backupFile, err)
+	}
+	currentBackup, err := rd.CreateBackup()
+	if err != nil {
+		return fmt.Errorf("Unable to back up the current data file: %w", err)
+	}
+	logger.Info(fmt.Sprintf("Backed up the current data file to %q.", currentBackup))
+	store := rd.Store()
+	if err := store.Restore(backupFile); err != nil {
+		return err
+	}
+	restored, err := store.Load()
+	if err != nil {
+		return err
+	}
+	// note: the data is persisted only at the next change; till then the data file is
+	// same as the backup (except for the upgrade by any of the migrations)
+	// note: just the persisted data is replaced, so that the options set on the data (such as the
+	// sync filter and the redaction) and its store stay in effect
+	rd.User, rd.Notes, rd.Tags = restored.User, restored.Notes, restored.Tags
+	rd.LastBackupAt, rd.SchemaVersion, rd.BaseStruct = restored.LastBackupAt, restored.SchemaVersion, restored.BaseStruct
+	rd.migrations = restored.migrations
+	logger.Info(fmt.Sprintf("Restored the data file from %q with %d notes.", backupFile, len(backupData.Notes)))
+	return nil
+}
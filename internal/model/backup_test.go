@@ -0,0 +1,144 @@
+package model_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// writeTestBackup writes a backup (of the data file) with given number of notes, created at given time.
+func writeTestBackup(dataFilePath string, createdAt int64, notes int) string {
+	backupFile := fmt.Sprintf("%s_backup_%d.json", dataFilePath[:len(dataFilePath)-len(".json")], createdAt)
+	reminderData := &model.ReminderData{DataFile: backupFile, User: &model.User{}, Tags: model.Tags{}, Notes: model.Notes{}}
+	for i := 0; i < notes; i++ {
+		reminderData.Notes = append(reminderData.Notes, &model.Note{Id: fmt.Sprint(i), Text: fmt.Sprint(i)})
+	}
+	_ = reminderData.CreateDataFile("")
+	return backupFile
+}
+
+func TestCreateBackupAndBackups(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "a note")
+	oldBackup := writeTestBackup(dataFilePath, 1600000000, 3)
+	backupFile, err := reminderData.CreateBackup()
+	utils.AssertEqual(t, err, nil)
+	// the latest backup is symlinked
+	target, _ := os.Readlink("temp_test_dir/mydata_backup_latest.json")
+	utils.AssertEqual(t, target, filepath.Base(backupFile))
+	// backups are listed (latest first) along with their note counts
+	backups, err := reminderData.Backups()
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(backups), 2)
+	utils.AssertEqual(t, backups[0].Path, backupFile)
+	reminderData.CountBackupNotes(backups)
+	utils.AssertEqual(t, backups[0].Notes, 1)
+	utils.AssertEqual(t, backups[1], model.Backup{Path: oldBackup, CreatedAt: 1600000000, Notes: 3})
+}
+
+func TestPruneBackups(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	day := int64(24 * 60 * 60)
+	// Mon, 2021-01-04 00:00:00 UTC
+	monday := int64(1609718400)
+	var backupFiles []string
+	for _, createdAt := range []int64{
+		monday + 3*day + 10, // Thu (latest)
+		monday + 2*day + 20, // Wed (later of the day)
+		monday + 2*day + 10, // Wed
+		monday + day,        // Tue
+		monday - day,        // Sun of previous week
+		monday - 8*day,      // Sun of the week before (previous month)
+		monday - 40*day,     // month before the previous one
+	} {
+		backupFiles = append(backupFiles, writeTestBackup(dataFilePath, createdAt, 1))
+	}
+	// case 1 (nothing to prune)
+	removed, err := reminderData.PruneBackups(&model.BackupOptions{})
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(removed), 0)
+	// case 2 (keep latest of 2 days, of 2 weeks, and of 2 months)
+	removed, err = reminderData.PruneBackups(&model.BackupOptions{KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 2})
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, removed, []string{backupFiles[2], backupFiles[3], backupFiles[6]})
+	backups, _ := reminderData.Backups()
+	utils.AssertEqual(t, len(backups), 4)
+	// case 3 (read-only mode)
+	reminderData.SetReadOnly(true)
+	_, err = reminderData.PruneBackups(&model.BackupOptions{KeepDaily: 1})
+	utils.AssertEqual(t, err, model.ErrorReadOnly)
+	backups, _ = reminderData.Backups()
+	utils.AssertEqual(t, len(backups), 4)
+	reminderData.SetReadOnly(false)
+	// case 4 (a negative count keeps none for its period)
+	removed, err = reminderData.PruneBackups(&model.BackupOptions{KeepDaily: -1, KeepWeekly: 1})
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, removed, []string{backupFiles[1], backupFiles[4], backupFiles[5]})
+}
+
+func TestRestoreBackup(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "a note")
+	backupFile := writeTestBackup(dataFilePath, 1600000000, 3)
+	// case 1 (invalid backup)
+	utils.AssertEqual(t, reminderData.RestoreBackup("temp_test_dir/missing.json") != nil, true)
+	utils.AssertEqual(t, len(reminderData.Notes), 1)
+	// case 2 (the backup is swapped into place, and the data is reloaded)
+	utils.AssertEqual(t, reminderData.RestoreBackup(backupFile), nil)
+	utils.AssertEqual(t, len(reminderData.Notes), 3)
+	reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 3)
+	// the replaced data file is backed up first
+	backups, _ := reminderData.Backups()
+	reminderData.CountBackupNotes(backups)
+	utils.AssertEqual(t, backups[0].Notes, 1)
+	// further changes are saved to the data file (without any conflict)
+	_, err := reminderData.NewNoteRegistration([]int{}, "another note")
+	utils.AssertEqual(t, err, nil)
+	reminderDataRe, _ = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 4)
+}
+
+func TestRestoreBackupKeepsOptions(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.SetSyncFilter(&calendar.SyncFilter{MainOnly: true})
+	utils.AssertEqual(t, reminderData.SetRedactionOptions(&model.RedactionOptions{GenericTitles: true, GenericTitle: "Busy"}), nil)
+	backupFile := writeTestBackup(dataFilePath, 1600000000, 2)
+	utils.AssertEqual(t, reminderData.RestoreBackup(backupFile), nil)
+	utils.AssertEqual(t, len(reminderData.Notes), 2)
+	// the sync filter and the redaction still apply to the restored notes
+	for _, note := range reminderData.Notes {
+		note.Status = model.NoteStatus_Pending
+		note.CompleteBy = 1800000000
+	}
+	reminderData.Notes[1].IsMain = true
+	events, err := reminderData.CalendarEvents("UTC")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 1)
+	utils.AssertEqual(t, events[0].Summary, calendar.TitlePrefix+"Busy")
+}
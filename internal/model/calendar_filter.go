@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// SetSyncFilter sets the filter which selects the notes to be synced to the calendar.
+func (rd *ReminderData) SetSyncFilter(filter *calendar.SyncFilter) {
+	rd.syncFilter = filter
+}
+
+// NotesToSync returns the notes to be synced to the calendar, which are the notes with a due date
+// selected by the sync filter (see calendar.SyncFilter), except the ones marked as not to be synced,
+// and the ones imported from the calendar (as their events are already there).
+func (rd *ReminderData) NotesToSync() (Notes, error) {
+	filter := rd.syncFilter
+	if filter == nil {
+		filter = calendar.DefaultSyncFilter()
+	}
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = calendar.DefaultSyncFilter().Statuses
+	}
+	var noteStatuses []NoteStatus
+	for _, status := range statuses {
+		noteStatus := NoteStatus(status)
+		if noteStatus != NoteStatus_Pending && noteStatus != NoteStatus_Suspended && noteStatus != NoteStatus_Done {
+			return nil, fmt.Errorf("Unknown status %q in the calendar sync filter; expected %q, %q or %q", status, NoteStatus_Pending, NoteStatus_Suspended, NoteStatus_Done)
+		}
+		noteStatuses = append(noteStatuses, noteStatus)
+	}
+	// the tags of the filter which don't exist are most likely typos
+	for _, slug := range append(append([]string{}, filter.IncludeTags...), filter.ExcludeTags...) {
+		if rd.TagFromSlug(slug) == nil {
+			logger.Warn(fmt.Sprintf("The tag %q of the calendar sync filter doesn't exist.", slug))
+		}
+	}
+	var dueBefore int64
+	if filter.DueWithinDays > 0 {
+		dueBefore = utils.CurrentTime().Add(time.Duration(filter.DueWithinDays) * 24 * time.Hour).Unix()
+	}
+	var selected Notes
+	for _, note := range rd.Notes.WithCompleteBy() {
+		if !utils.IsMemberOfSlice(note.Status, noteStatuses) || note.NoSync || note.CalendarEventId != "" {
+			continue
+		}
+		if filter.MainOnly && !note.IsMain {
+			continue
+		}
+		if dueBefore > 0 && note.CompleteBy > dueBefore {
+			continue
+		}
+		if (len(filter.IncludeTags) > 0 || len(filter.IncludeGroups) > 0) && !rd.hasTagOf(note, filter.IncludeTags, filter.IncludeGroups) {
+			continue
+		}
+		if rd.hasTagOf(note, filter.ExcludeTags, filter.ExcludeGroups) {
+			continue
+		}
+		selected = append(selected, note)
+	}
+	return selected, nil
+}
+
+// hasTagOf tells if the note has any of the tags (by their slugs), or any tag of the groups.
+func (rd *ReminderData) hasTagOf(note *Note, slugs []string, groups []string) bool {
+	for _, tag := range rd.Tags.FromIds(note.TagIds) {
+		if utils.IsMemberOfSlice(tag.Slug, slugs) || (tag.Group != "" && utils.IsMemberOfSlice(tag.Group, groups)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestNotesToSync(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	day := int64(24 * 3600)
+	now := utils.CurrentTime().Unix()
+	reminderData := &model.ReminderData{
+		Tags: model.Tags{
+			&model.Tag{Id: 1, Slug: "work", Group: "life"},
+			&model.Tag{Id: 2, Slug: "personal", Group: "life"},
+			&model.Tag{Id: 3, Slug: "priority-urgent", Group: "priority"},
+			&model.Tag{Id: 4, Slug: "confidential"},
+		},
+		Notes: model.Notes{
+			{Id: "report", Status: model.NoteStatus_Pending, CompleteBy: now + day, TagIds: []int{1, 3}, IsMain: true},
+			{Id: "review", Status: model.NoteStatus_Pending, CompleteBy: now + 30*day, TagIds: []int{1, 4}},
+			{Id: "dentist", Status: model.NoteStatus_Pending, CompleteBy: now - day, TagIds: []int{2}},
+			{Id: "diary", Status: model.NoteStatus_Pending, CompleteBy: now + day, TagIds: []int{2}, NoSync: true},
+			{Id: "imported", Status: model.NoteStatus_Pending, CompleteBy: now + day, CalendarEventId: "e1"},
+			{Id: "paused", Status: model.NoteStatus_Suspended, CompleteBy: now + day, TagIds: []int{1}},
+			{Id: "no-due-date", Status: model.NoteStatus_Pending, TagIds: []int{1}},
+		},
+	}
+	ids := func(filter *calendar.SyncFilter) []string {
+		reminderData.SetSyncFilter(filter)
+		notes, err := reminderData.NotesToSync()
+		utils.AssertEqual(t, err, nil)
+		var ids []string
+		for _, note := range notes {
+			ids = append(ids, note.Id)
+		}
+		return ids
+	}
+	// the notes marked as not to be synced (along with the imported ones) are always left out
+	utils.AssertEqual(t, ids(nil), []string{"report", "review", "dentist"})
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{Statuses: []string{"pending", "suspended"}}), []string{"report", "review", "dentist", "paused"})
+	// tags and groups
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{IncludeTags: []string{"work"}}), []string{"report", "review"})
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{IncludeGroups: []string{"life"}, ExcludeTags: []string{"confidential"}}), []string{"report", "dentist"})
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{ExcludeGroups: []string{"priority"}}), []string{"review", "dentist"})
+	// main flag and due date
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{MainOnly: true}), []string{"report"})
+	utils.AssertEqual(t, ids(&calendar.SyncFilter{DueWithinDays: 7}), []string{"report", "dentist"})
+	// invalid filter
+	reminderData.SetSyncFilter(&calendar.SyncFilter{Statuses: []string{"snoozed"}})
+	_, err := reminderData.NotesToSync()
+	utils.AssertEqual(t, err.Error(), `Unknown status "snoozed" in the calendar sync filter; expected "pending", "suspended" or "done"`)
+	// the events are of the selected notes only
+	reminderData.SetSyncFilter(&calendar.SyncFilter{IncludeTags: []string{"personal"}})
+	events, err := reminderData.CalendarEvents("UTC")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 1)
+	utils.AssertEqual(t, calendar.EventNoteId(events[0]), "dentist")
+	// the changes made in the calendar aren't pulled into the notes which are no longer synced
+	events[0].Status = "cancelled"
+	changes, _ := reminderData.CalendarChanges(events, "UTC")
+	utils.AssertEqual(t, len(changes), 1)
+	reminderData.Notes[2].NoSync = true
+	changes, _ = reminderData.CalendarChanges(events, "UTC")
+	utils.AssertEqual(t, len(changes), 0)
+}
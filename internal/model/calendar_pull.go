@@ -0,0 +1,343 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/rrule"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// Kinds of the changes pulled from the calendar.
+const (
+	CalendarChange_Reschedule = "reschedule"
+	CalendarChange_Done       = "done"
+	CalendarChange_Complete   = "complete"
+	CalendarChange_Suspend    = "suspend"
+)
+
+/*
+A CalendarChange is a change made to the event of a note in the calendar (such as moving it to another
+day, deleting it, or marking it as done by titling it with calendar.DoneTitlePrefix), which is to be
+pulled into the note.
+*/
+type CalendarChange struct {
+	Note *Note
+	// Kind is the change to be made to the note: "reschedule", "done", "complete" (the current occurrence
+	// of a recurring note marked as done; see ReminderData.CompleteNote) or "suspend" (a recurring note
+	// whose event is deleted).
+	Kind string
+	// DueDate is the new due date of the note (for "reschedule"), as accepted by UpdateNoteCompleteBy.
+	DueDate string
+}
+
+// String provides basic string representation of the change.
+func (change CalendarChange) String() string {
+	switch change.Kind {
+	case CalendarChange_Reschedule:
+		return fmt.Sprintf("reschedule %q from %s to %s", change.Note.Text, change.Note.DueDateStr(change.Note.CompleteBy), change.DueDate)
+	case CalendarChange_Done:
+		return fmt.Sprintf("mark %q as done", change.Note.Text)
+	case CalendarChange_Complete:
+		return fmt.Sprintf("complete the occurrence of %q due on %s", change.Note.Text, change.Note.DueDateStr(change.Note.CompleteBy))
+	}
+	return fmt.Sprintf("mark %q as suspended", change.Note.Text)
+}
+
+// CalendarChanges compares the events registered by the app (including the deleted ones) with
+// the notes, and returns the changes made to the events in the calendar since the last sync.
+// The events whose notes have also changed since the last sync are skipped, as such notes
+// take precedence over their events.
+func (rd *ReminderData) CalendarChanges(events []*calendar.Event, timezoneIANA string) ([]CalendarChange, error) {
+	// pick the event of each note, preferring the events which aren't deleted
+	eventsByNote := make(map[string]*calendar.Event)
+	var noteIds []string
+	for _, event := range events {
+		noteId := calendar.EventNoteId(event)
+		if noteId == "" || event.RecurringEventId != "" {
+			continue
+		}
+		current, ok := eventsByNote[noteId]
+		if !ok {
+			noteIds = append(noteIds, noteId)
+		}
+		if !ok || (calendar.IsCancelled(current) && !calendar.IsCancelled(event)) {
+			eventsByNote[noteId] = event
+		}
+	}
+	// note: the changes are pulled only into the notes which are still synced
+	notesToSync, err := rd.NotesToSync()
+	if err != nil {
+		return nil, err
+	}
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	var changes []CalendarChange
+	for _, noteId := range noteIds {
+		event := eventsByNote[noteId]
+		note := notesToSync.WithId(noteId)
+		if note == nil || note.Status != NoteStatus_Pending {
+			continue
+		}
+		desired, err := note.CalendarEvent(repeatAnnuallyTagId, repeatMonthlyTagId, timezoneIANA, rd, rd.eventOptions, rd.redaction)
+		if err != nil {
+			return nil, err
+		}
+		if calendar.EventHash(event) != calendar.EventHash(desired) {
+			logger.Info(fmt.Sprintf("Skipped pulling the event %q, as its note is changed since the last sync.", calendar.EventString(event)))
+			continue
+		}
+		rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+		if calendar.IsCancelled(event) || calendar.IsMarkedDone(event) {
+			kind := CalendarChange_Done
+			switch {
+			case rule != nil && calendar.IsCancelled(event):
+				// the whole series is deleted
+				kind = CalendarChange_Suspend
+			case rule != nil:
+				kind = CalendarChange_Complete
+			}
+			changes = append(changes, CalendarChange{Note: note, Kind: kind})
+			continue
+		}
+		start, allDay, err := calendar.EventStart(event, note.Location())
+		if err != nil {
+			return nil, err
+		}
+		desiredStart, _, err := calendar.EventStart(desired, note.Location())
+		if err != nil {
+			return nil, err
+		}
+		if start.Equal(desiredStart) {
+			continue
+		}
+		changes = append(changes, CalendarChange{Note: note, Kind: CalendarChange_Reschedule, DueDate: note.dueDateText(start, allDay)})
+	}
+	return changes, nil
+}
+
+// dueDateText returns the start of an event of the note as a due date accepted by UpdateCompleteBy.
+// An event of a note without time of day starts at 10 AM, and so, such start is taken as just the date;
+// and an all-day event keeps the time of day of its note (if any).
+func (note *Note) dueDateText(start time.Time, allDay bool) string {
+	start = start.In(note.Location())
+	text := start.Format("2006-01-02")
+	switch {
+	case allDay && note.hasTimeOfDay():
+		text += " " + time.Unix(note.CompleteBy, 0).In(note.Location()).Format("15:04")
+	case !allDay && !(!note.hasTimeOfDay() && start.Hour() == 10 && start.Minute() == 0):
+		text += " " + start.Format("15:04")
+	}
+	if note.TimeZone != "" {
+		text += " " + note.TimeZone
+	}
+	return text
+}
+
+// ApplyCalendarChanges applies the changes pulled from the calendar to their notes.
+func (rd *ReminderData) ApplyCalendarChanges(changes []CalendarChange) error {
+	for _, change := range changes {
+		var err error
+		switch change.Kind {
+		case CalendarChange_Reschedule:
+			err = rd.UpdateNoteCompleteBy(change.Note, change.DueDate)
+		case CalendarChange_Done:
+			err = rd.UpdateNoteStatus(change.Note, NoteStatus_Done)
+		case CalendarChange_Complete:
+			_, err = rd.CompleteNote(change.Note, "marked as done in the calendar")
+		case CalendarChange_Suspend:
+			err = rd.UpdateNoteStatus(change.Note, NoteStatus_Suspended)
+		}
+		if err != nil {
+			return fmt.Errorf("Unable to %s: %w", change, err)
+		}
+	}
+	return nil
+}
+
+// PullCalendarChanges asks which of the changes made to the events in the calendar (see CalendarChanges)
+// are to be pulled into the notes, and applies them.
+func (rd *ReminderData) PullCalendarChanges(events []*calendar.Event, timezoneIANA string) error {
+	changes, err := rd.CalendarChanges(events, timezoneIANA)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("No changes to pull from the calendar.")
+		return nil
+	}
+	fmt.Printf("Found %d changes made in the calendar since the last sync (the changes which aren't selected are reverted in the calendar):\n", len(changes))
+	var options []string
+	for _, change := range changes {
+		options = append(options, change.String())
+	}
+	selected, err := utils.AskOptions(options, "Select the changes to pull into the notes: ")
+	if err != nil {
+		return err
+	}
+	var selectedChanges []CalendarChange
+	for _, index := range selected {
+		selectedChanges = append(selectedChanges, changes[index])
+	}
+	return rd.ApplyCalendarChanges(selectedChanges)
+}
+
+// NotesFromCalendarEvents returns new notes (with the tag) for the events which are neither registered
+// by the app nor already imported.
+// A note's due date is the start of its event (in the event's timezone), and its recurrence is taken
+// from the event (if supported).
+func (rd *ReminderData) NotesFromCalendarEvents(events []*calendar.Event, tagID int) (Notes, error) {
+	imported := make(map[string]bool)
+	for _, note := range rd.Notes {
+		if note.CalendarEventId != "" {
+			imported[note.CalendarEventId] = true
+		}
+	}
+	var notes Notes
+	for _, event := range events {
+		if calendar.EventNoteId(event) != "" || strings.HasPrefix(event.Summary, calendar.TitlePrefix) ||
+			calendar.IsCancelled(event) || event.RecurringEventId != "" || imported[event.Id] {
+			continue
+		}
+		note, err := noteFromCalendarEvent(event, tagID)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// noteFromCalendarEvent returns a new note (with the tag) for the event.
+func noteFromCalendarEvent(event *calendar.Event, tagID int) (*Note, error) {
+	text := strings.TrimSpace(event.Summary)
+	if text == "" {
+		return nil, fmt.Errorf("Event %q has no title", event.Id)
+	}
+	location := utils.CurrentLocation()
+	if event.Start != nil && event.Start.TimeZone != "" {
+		if loc, err := time.LoadLocation(event.Start.TimeZone); err == nil {
+			location = loc
+		}
+	}
+	start, _, err := calendar.EventStart(event, location)
+	if err != nil {
+		return nil, err
+	}
+	currentTime := utils.CurrentUnixTimestamp()
+	note := &Note{
+		Id:              NewNoteId(),
+		Text:            text,
+		Comments:        Comments{},
+		Summary:         strings.TrimSpace(event.Description),
+		Status:          NoteStatus_Pending,
+		TagIds:          []int{tagID},
+		CompleteBy:      start.Unix(),
+		TimeZone:        timeZoneName(location),
+		CalendarEventId: event.Id,
+		BaseStruct:      BaseStruct{CreatedAt: currentTime, UpdatedAt: currentTime},
+	}
+	for _, line := range event.Recurrence {
+		if !strings.HasPrefix(line, "RRULE:") {
+			continue
+		}
+		rule, err := rrule.Parse(strings.TrimPrefix(line, "RRULE:"))
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Skipped the unsupported recurrence %q of the event %q: %v", line, text, err))
+			continue
+		}
+		note.Recurrence = rule.String()
+	}
+	return note, nil
+}
+
+// ImportCalendarEvents adds the notes of the calendar events (see NotesFromCalendarEvents).
+func (rd *ReminderData) ImportCalendarEvents(notes Notes) error {
+	if len(notes) == 0 {
+		return errors.New("No events to import")
+	}
+	return rd.Transaction(func() error {
+		for _, note := range notes {
+			if err := rd.newNoteAppend(note); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ImportFromCalendar asks for a period and a tag, and imports the events of the calendar in the period
+// (which are neither registered by the app nor already imported) as notes with the tag, after
+// asking which of them are to be imported.
+func (rd *ReminderData) ImportFromCalendar(ctx context.Context, calOptions *calendar.Options) error {
+	if !EnableCalendar {
+		logger.Warn("Calendar sync is disabled.")
+		return nil
+	}
+	from, err := askDate("calendar_import_from", "today")
+	if err != nil {
+		return err
+	}
+	to, err := askDate("calendar_import_to", "+30d")
+	if err != nil {
+		return err
+	}
+	if !to.After(from) {
+		return errors.New("End of the period must be after its start")
+	}
+	tagSlugs := rd.SortedTagSlugs()
+	tagIndex, _, err := utils.AskOption(tagSlugs, "Select the tag for the imported notes: ")
+	if err != nil {
+		return err
+	}
+	cal, err := calendar.New(ctx, calOptions)
+	if err != nil {
+		return err
+	}
+	events, err := cal.Events(ctx, calendar.EventsQuery{Start: from, Stop: to})
+	if err != nil {
+		return err
+	}
+	notes, err := rd.NotesFromCalendarEvents(events, rd.Tags[tagIndex].Id)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		fmt.Println("No events to import.")
+		return nil
+	}
+	var options []string
+	for _, note := range notes {
+		options = append(options, fmt.Sprintf("%s (due on %s)", note.Text, note.DueDateStr(note.CompleteBy)))
+	}
+	selected, err := utils.AskOptions(options, "Select the events to import as notes: ")
+	if err != nil {
+		return err
+	}
+	var selectedNotes Notes
+	for _, index := range selected {
+		selectedNotes = append(selectedNotes, notes[index])
+	}
+	if len(selectedNotes) == 0 {
+		fmt.Println("No events imported.")
+		return nil
+	}
+	if err := rd.ImportCalendarEvents(selectedNotes); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d events as notes.\n", len(selectedNotes))
+	return nil
+}
+
+// askDate asks for a date (see utils.ParseDueDate) with the prompt.
+func askDate(promptName string, defaultText string) (time.Time, error) {
+	text, err := utils.GeneratePrompt(promptName, defaultText)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return utils.ParseDueDate(text, utils.CurrentLocation())
+}
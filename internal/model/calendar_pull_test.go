@@ -0,0 +1,119 @@
+package model_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestCalendarChanges(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	// Sun Nov 01 2026 00:00:00 GMT+0000
+	dueDate := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix()
+	reminderData.Notes = model.Notes{
+		{Id: "moved", Text: "pay the rent", Status: model.NoteStatus_Pending, CompleteBy: dueDate},
+		{Id: "moved-with-time", Text: "call the bank", Status: model.NoteStatus_Pending, CompleteBy: dueDate + 9*3600 + 30*60},
+		{Id: "deleted", Text: "feed the cat", Status: model.NoteStatus_Pending, CompleteBy: dueDate},
+		{Id: "marked-done", Text: "water the plants", Status: model.NoteStatus_Pending, CompleteBy: dueDate, Recurrence: "FREQ=WEEKLY"},
+		{Id: "changed-both", Text: "book the tickets", Status: model.NoteStatus_Pending, CompleteBy: dueDate},
+		{Id: "unchanged", Text: "renew the passport", Status: model.NoteStatus_Pending, CompleteBy: dueDate},
+		{Id: "deleted-series", Text: "pay the dues", Status: model.NoteStatus_Pending, CompleteBy: dueDate, Recurrence: "FREQ=MONTHLY"},
+	}
+	events, err := reminderData.CalendarEvents("UTC")
+	utils.AssertEqual(t, err, nil)
+	// the changes made in the calendar since the last sync
+	events[0].Start = &calendar.EventTime{DateTime: "2026-11-03T10:00:00Z"}
+	events[1].Start = &calendar.EventTime{DateTime: "2026-11-02T11:00:00Z"}
+	events[2].Status = "cancelled"
+	events[3].Summary = calendar.DoneTitlePrefix + "water the plants"
+	events[4].Start = &calendar.EventTime{DateTime: "2026-11-03T10:00:00Z"}
+	events[6].Status = "cancelled"
+	reminderData.Notes[4].Text = "book the train tickets"
+	// a deleted duplicate doesn't take precedence over the live event
+	duplicate := *events[5]
+	duplicate.Status = "cancelled"
+	events = append(events, &duplicate)
+	changes, err := reminderData.CalendarChanges(events, "UTC")
+	utils.AssertEqual(t, err, nil)
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	utils.AssertEqual(t, got, []string{
+		`reschedule "pay the rent" from 01-Nov-26 to 2026-11-03`,
+		`reschedule "call the bank" from 01-Nov-26 09:30 to 2026-11-02 11:00`,
+		`mark "feed the cat" as done`,
+		`complete the occurrence of "water the plants" due on 01-Nov-26`,
+		`mark "pay the dues" as suspended`,
+	})
+	// apply the changes
+	utils.AssertEqual(t, reminderData.ApplyCalendarChanges(changes), nil)
+	utils.AssertEqual(t, reminderData.Notes[0].CompleteBy, time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC).Unix())
+	utils.AssertEqual(t, reminderData.Notes[1].CompleteBy, time.Date(2026, 11, 2, 11, 0, 0, 0, time.UTC).Unix())
+	utils.AssertEqual(t, reminderData.Notes[2].Status, model.NoteStatus_Done)
+	// the recurring note marked as done rolls forward to its next occurrence
+	utils.AssertEqual(t, reminderData.Notes[3].Status, model.NoteStatus_Pending)
+	utils.AssertEqual(t, len(reminderData.Notes[3].Completions), 1)
+	utils.AssertEqual(t, reminderData.Notes[3].CompleteBy, time.Date(2026, 11, 8, 0, 0, 0, 0, time.UTC).Unix())
+	utils.AssertEqual(t, reminderData.Notes[6].Status, model.NoteStatus_Suspended)
+	// the pulled changes are in sync now
+	changes, _ = reminderData.CalendarChanges(events, "UTC")
+	utils.AssertEqual(t, len(changes), 0)
+}
+
+func TestImportCalendarEvents(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.Tags = model.Tags{&model.Tag{Id: 1, Slug: "imported"}}
+	reminderData.Notes = model.Notes{
+		{Id: "registered", Text: "pay the rent", Status: model.NoteStatus_Pending, CompleteBy: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix()},
+	}
+	registered, _ := reminderData.CalendarEvents("UTC")
+	events := []*calendar.Event{
+		registered[0],
+		{Id: "e1", Summary: "dentist appointment", Description: "bring the reports", Start: &calendar.EventTime{DateTime: "2026-10-20T15:30:00+05:30", TimeZone: "Asia/Kolkata"}},
+		{Id: "e2", Summary: "team offsite", Start: &calendar.EventTime{Date: "2026-11-05"}, Recurrence: []string{"RRULE:FREQ=YEARLY"}},
+		{Id: "e3", Summary: "cancelled meeting", Status: "cancelled", Start: &calendar.EventTime{Date: "2026-11-05"}},
+		{Id: "e4", Summary: "an instance", RecurringEventId: "e2", Start: &calendar.EventTime{Date: "2026-11-05"}},
+	}
+	notes, err := reminderData.NotesFromCalendarEvents(events, 1)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(notes), 2)
+	utils.AssertEqual(t, notes[0].Text, "dentist appointment")
+	utils.AssertEqual(t, notes[0].Summary, "bring the reports")
+	utils.AssertEqual(t, notes[0].TimeZone, "Asia/Kolkata")
+	utils.AssertEqual(t, notes[0].CompleteBy, time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC).Unix())
+	utils.AssertEqual(t, notes[0].TagIds, []int{1})
+	utils.AssertEqual(t, notes[1].CompleteBy, time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC).Unix())
+	utils.AssertEqual(t, notes[1].Recurrence, "FREQ=YEARLY")
+	utils.AssertEqual(t, notes[1].CalendarEventId, "e2")
+	utils.AssertEqual(t, reminderData.ImportCalendarEvents(notes), nil)
+	utils.AssertEqual(t, len(reminderData.Notes), 3)
+	// the imported events are neither imported again, nor synced back to the calendar
+	notes, _ = reminderData.NotesFromCalendarEvents(events, 1)
+	utils.AssertEqual(t, len(notes), 0)
+	utils.AssertEqual(t, reminderData.ImportCalendarEvents(notes).Error(), "No events to import")
+	synced, _ := reminderData.CalendarEvents("UTC")
+	utils.AssertEqual(t, len(synced), 1)
+}
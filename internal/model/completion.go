@@ -0,0 +1,62 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+/*
+A Completion records that an occurrence of a recurring note was done.
+
+Its CreatedAt is the time at which the occurrence was marked as done.
+A completion belongs to a particular note, whereas a recurring note can have multiple completions.
+*/
+type Completion struct {
+	// DueDate is the due date of the completed occurrence.
+	DueDate int64  `json:"due_date"`
+	Comment string `json:"comment"`
+	BaseStruct
+}
+
+// String provides basic string representation of a completion.
+func (completion *Completion) String() string {
+	parts := []string{"due " + utils.UnixTimestampToShortTimeStr(completion.DueDate), "done " + utils.UnixTimestampToMediumTimeStr(completion.CreatedAt)}
+	if completion.Comment != "" {
+		parts = append(parts, completion.Comment)
+	}
+	return strings.Join(parts, " | ")
+}
+
+/*
+A Completions is a slice of Completion objects.
+
+By default it is sorted by due date of the completed occurrences.
+*/
+type Completions []*Completion
+
+func (c Completions) Len() int      { return len(c) }
+func (c Completions) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c Completions) Less(i, j int) bool {
+	if c[i].DueDate == c[j].DueDate {
+		return c[i].CreatedAt < c[j].CreatedAt
+	}
+	return c[i].DueDate < c[j].DueDate
+}
+
+// Strings provides representation of Completions in terms of slice of strings.
+func (completions Completions) Strings() []string {
+	strs := make([]string, 0, len(completions))
+	for _, completion := range completions {
+		strs = append(strs, completion.String())
+	}
+	return strs
+}
+
+// sorted returns a sorted copy of the completions.
+func (completions Completions) sorted() Completions {
+	sortedCompletions := append(Completions{}, completions...)
+	sort.Stable(sortedCompletions)
+	return sortedCompletions
+}
@@ -0,0 +1,287 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/rrule"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// maxWindowDays is the largest number of days a due window can span on either side of a due date.
+const maxWindowDays = 3650
+
+/*
+A DueWindow is the number of days before (lead) and after (grace) the due date of a pending note
+(or, for a recurring note, each of its occurrences), during which the note shows up under the
+"Approaching Due Date" option. A one-off note stays there after its due date until it is marked
+as done, and so its grace is not used.
+
+A DueWindow can be set for a note as well as for a tag. A value which isn't set (nil) falls back to
+the largest of the values set for the note's tags, and then to the DueWindowOptions in the settings.
+*/
+type DueWindow struct {
+	LeadDays  *int `json:"lead_days,omitempty"`
+	GraceDays *int `json:"grace_days,omitempty"`
+}
+
+// ParseDueWindow parses due window of the form "<lead days>[,<grace days>]", such as "60", "1,2" or ",3".
+// If input is "nil", both the values are cleared.
+func ParseDueWindow(text string) (DueWindow, error) {
+	var window DueWindow
+	text = strings.TrimSpace(text)
+	if text == "nil" {
+		return window, nil
+	}
+	parts := strings.Split(text, ",")
+	if text == "" || len(parts) > 2 {
+		return window, fmt.Errorf("Invalid due window %q; expected <lead days>[,<grace days>]", text)
+	}
+	values := make([]*int, 2)
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		days, err := strconv.Atoi(part)
+		if err != nil || days < 0 || days > maxWindowDays {
+			return window, fmt.Errorf("Invalid due window %q; the days must be between 0 and %d", text, maxWindowDays)
+		}
+		values[i] = &days
+	}
+	window.LeadDays, window.GraceDays = values[0], values[1]
+	return window, nil
+}
+
+// formatDueWindow returns representation of the due window in the format accepted by ParseDueWindow.
+func formatDueWindow(window DueWindow) string {
+	if window.LeadDays == nil && window.GraceDays == nil {
+		return "nil"
+	}
+	var lead, grace string
+	if window.LeadDays != nil {
+		lead = strconv.Itoa(*window.LeadDays)
+	}
+	if window.GraceDays == nil {
+		return lead
+	}
+	grace = strconv.Itoa(*window.GraceDays)
+	return lead + "," + grace
+}
+
+// WindowDays are the lead and grace days of a due window.
+type WindowDays struct {
+	LeadDays  int `json:"lead_days" yaml:"lead_days" mapstructure:"lead_days"`
+	GraceDays int `json:"grace_days" yaml:"grace_days" mapstructure:"grace_days"`
+}
+
+// LongViewLeadDays are the lead days of the notes in the "long" view, for the one-off notes and
+// for the recurring notes of each frequency. A due window with a longer lead is kept as it is.
+type LongViewLeadDays struct {
+	OneOff  int `json:"one_off" yaml:"one_off" mapstructure:"one_off"`
+	Daily   int `json:"daily" yaml:"daily" mapstructure:"daily"`
+	Weekly  int `json:"weekly" yaml:"weekly" mapstructure:"weekly"`
+	Monthly int `json:"monthly" yaml:"monthly" mapstructure:"monthly"`
+	Yearly  int `json:"yearly" yaml:"yearly" mapstructure:"yearly"`
+}
+
+/*
+A DueWindowOptions represents the default due windows of the notes (see DueWindow), for the one-off
+notes and for the recurring notes of each frequency.
+
+The LongView are the lead days in the "long" view, in which the notes show up a whole period (or,
+for the one-off notes, a year) in advance by default.
+*/
+type DueWindowOptions struct {
+	OneOff   WindowDays       `json:"one_off" yaml:"one_off" mapstructure:"one_off"`
+	Daily    WindowDays       `json:"daily" yaml:"daily" mapstructure:"daily"`
+	Weekly   WindowDays       `json:"weekly" yaml:"weekly" mapstructure:"weekly"`
+	Monthly  WindowDays       `json:"monthly" yaml:"monthly" mapstructure:"monthly"`
+	Yearly   WindowDays       `json:"yearly" yaml:"yearly" mapstructure:"yearly"`
+	LongView LongViewLeadDays `json:"long_view" yaml:"long_view" mapstructure:"long_view"`
+}
+
+func DefaultDueWindowOptions() *DueWindowOptions {
+	return &DueWindowOptions{
+		OneOff:   WindowDays{LeadDays: 7},
+		Daily:    WindowDays{LeadDays: 0, GraceDays: 1},
+		Weekly:   WindowDays{LeadDays: 1, GraceDays: 2},
+		Monthly:  WindowDays{LeadDays: 1, GraceDays: 3},
+		Yearly:   WindowDays{LeadDays: 3, GraceDays: 7},
+		LongView: LongViewLeadDays{OneOff: 365, Daily: 1, Weekly: 7, Monthly: 31, Yearly: 365},
+	}
+}
+
+// forRule returns the default window of the notes with given recurrence rule (nil for the one-off notes).
+func (opts *DueWindowOptions) forRule(rule *rrule.Rule) WindowDays {
+	if rule == nil {
+		return opts.OneOff
+	}
+	switch rule.Freq {
+	case rrule.Daily:
+		return opts.Daily
+	case rrule.Weekly:
+		return opts.Weekly
+	case rrule.Monthly:
+		return opts.Monthly
+	}
+	return opts.Yearly
+}
+
+// longViewLeadDays returns the lead days of the notes with given recurrence rule (nil for the one-off
+// notes) in the "long" view.
+func (opts *DueWindowOptions) longViewLeadDays(rule *rrule.Rule) int {
+	if rule == nil {
+		return opts.LongView.OneOff
+	}
+	switch rule.Freq {
+	case rrule.Daily:
+		return opts.LongView.Daily
+	case rrule.Weekly:
+		return opts.LongView.Weekly
+	case rrule.Monthly:
+		return opts.LongView.Monthly
+	}
+	return opts.LongView.Yearly
+}
+
+// An EffectiveWindow is the due window in effect for a note, along with where its values come from
+// (such as "note", "tag passport" or "settings").
+type EffectiveWindow struct {
+	WindowDays
+	LeadSource  string
+	GraceSource string
+}
+
+// SetDueWindowOptions sets the default due windows of the notes.
+func (rd *ReminderData) SetDueWindowOptions(opts *DueWindowOptions) {
+	rd.dueWindowOptions = opts
+}
+
+// dueWindows returns the default due windows of the notes (as set, or else the default ones).
+func (rd *ReminderData) dueWindows() *DueWindowOptions {
+	if rd.dueWindowOptions == nil {
+		return DefaultDueWindowOptions()
+	}
+	return rd.dueWindowOptions
+}
+
+// EffectiveWindow returns the due window in effect for the note with given recurrence rule (nil for a one-off note).
+func (rd *ReminderData) EffectiveWindow(note *Note, rule *rrule.Rule) EffectiveWindow {
+	opts := rd.dueWindows()
+	window := EffectiveWindow{WindowDays: opts.forRule(rule), LeadSource: "settings", GraceSource: "settings"}
+	// the largest values set for the note's tags override the settings
+	var tagLead, tagGrace *int
+	for _, tag := range rd.Tags.FromIds(note.TagIds) {
+		if tag.LeadDays != nil && (tagLead == nil || *tag.LeadDays > *tagLead) {
+			tagLead, window.LeadSource = tag.LeadDays, "tag "+tag.Slug
+		}
+		if tag.GraceDays != nil && (tagGrace == nil || *tag.GraceDays > *tagGrace) {
+			tagGrace, window.GraceSource = tag.GraceDays, "tag "+tag.Slug
+		}
+	}
+	if tagLead != nil {
+		window.LeadDays = *tagLead
+	}
+	if tagGrace != nil {
+		window.GraceDays = *tagGrace
+	}
+	// the values set for the note override everything else
+	if note.LeadDays != nil {
+		window.LeadDays, window.LeadSource = *note.LeadDays, "note"
+	}
+	if note.GraceDays != nil {
+		window.GraceDays, window.GraceSource = *note.GraceDays, "note"
+	}
+	return window
+}
+
+// approachingDueDate tells if the pending note is approaching its due date (as per its due window),
+// in the given view ("default" or "long"), at the current time. It returns the matched due date
+// (the occurrence for a recurring note), along with the reason of its visibility.
+func (rd *ReminderData) approachingDueDate(note *Note, rule *rrule.Rule, view string, currentTimestamp int64) (int64, string, bool) {
+	if note.Status != NoteStatus_Pending || note.CompleteBy == 0 {
+		return 0, "", false
+	}
+	window := rd.EffectiveWindow(note, rule)
+	leadDays := window.LeadDays
+	if longViewLead := rd.dueWindows().longViewLeadDays(rule); view == "long" && longViewLead > leadDays {
+		leadDays, window.LeadSource = longViewLead, "long view"
+	}
+	lead := fmt.Sprintf("lead of %s (from %s)", daysStr(leadDays), window.LeadSource)
+	// first process notes WITHOUT recurrence
+	// they show up within their lead, and stay until they are marked done
+	// note: the days are reckoned in the note's timezone
+	if rule == nil {
+		dueTime := time.Unix(note.CompleteBy, 0).In(note.Location())
+		if currentTimestamp < dueTime.AddDate(0, 0, -leadDays).Unix() {
+			return 0, "", false
+		}
+		if currentTimestamp > note.CompleteBy {
+			return note.CompleteBy, fmt.Sprintf("overdue since %s (until it is marked as done)", note.DueDateStr(note.CompleteBy)), true
+		}
+		return note.CompleteBy, fmt.Sprintf("due on %s, within the %s", note.DueDateStr(note.CompleteBy), lead), true
+	}
+	// check notes with recurrence
+	// show them around their previous or next occurrence
+	occurrence, ok := occurrenceAround(rule, note.CompleteBy, currentTimestamp, int64(leadDays), int64(window.GraceDays), note.Location())
+	if !ok {
+		return 0, "", false
+	}
+	if occurrence > currentTimestamp {
+		return occurrence, fmt.Sprintf("occurrence due on %s, within the %s", note.DueDateStr(occurrence), lead), true
+	}
+	grace := fmt.Sprintf("grace of %s (from %s)", daysStr(window.GraceDays), window.GraceSource)
+	return occurrence, fmt.Sprintf("occurrence due on %s, within the %s", note.DueDateStr(occurrence), grace), true
+}
+
+// VisibilityReason tells why the note currently shows up under the "Approaching Due Date" option.
+// It returns blank string if the note doesn't show up there.
+func (rd *ReminderData) VisibilityReason(note *Note) string {
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+	_, reason, _ := rd.approachingDueDate(note, rule, "default", utils.CurrentUnixTimestamp())
+	return reason
+}
+
+// UpdateNoteDueWindow updates the note's due window (see ParseDueWindow).
+func (rd *ReminderData) UpdateNoteDueWindow(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	window, err := ParseDueWindow(text)
+	if err != nil {
+		return err
+	}
+	note.DueWindow = window
+	note.UpdatedAt = utils.CurrentUnixTimestamp()
+	return rd.saveNote(note)
+}
+
+// UpdateTagDueWindow updates the tag's due window (see ParseDueWindow).
+func (rd *ReminderData) UpdateTagDueWindow(tag *Tag, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	if tag == nil {
+		return errors.New("Tag doesn't exist")
+	}
+	window, err := ParseDueWindow(text)
+	if err != nil {
+		return err
+	}
+	tag.DueWindow = window
+	tag.UpdatedAt = utils.CurrentUnixTimestamp()
+	return rd.saveTag(tag)
+}
+
+// daysStr returns the number of days in words, such as "1 day" or "3 days".
+func daysStr(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
@@ -0,0 +1,92 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/rrule"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestParseDueWindow(t *testing.T) {
+	days := func(n int) *int { return &n }
+	var tests = []struct {
+		text string
+		want model.DueWindow
+	}{
+		{"60", model.DueWindow{LeadDays: days(60)}},
+		{" 1, 2 ", model.DueWindow{LeadDays: days(1), GraceDays: days(2)}},
+		{",3", model.DueWindow{GraceDays: days(3)}},
+		{"nil", model.DueWindow{}},
+	}
+	for _, test := range tests {
+		got, err := model.ParseDueWindow(test.text)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, got, test.want)
+	}
+	for _, text := range []string{"", "a", "-1", "1,2,3", "99999"} {
+		_, err := model.ParseDueWindow(text)
+		utils.AssertEqual(t, err != nil, true)
+	}
+}
+
+func TestEffectiveWindow(t *testing.T) {
+	reminderData := &model.ReminderData{Tags: model.Tags{
+		&model.Tag{Id: 1, Slug: "travel"},
+		&model.Tag{Id: 2, Slug: "passport"},
+		&model.Tag{Id: 3, Slug: "bills"},
+	}}
+	reminderData.Tags[0].DueWindow, _ = model.ParseDueWindow("14,1")
+	reminderData.Tags[1].DueWindow, _ = model.ParseDueWindow("60")
+	weekly, _ := rrule.Parse("FREQ=WEEKLY")
+	// case 1 (defaults from the settings)
+	note := &model.Note{Text: "pay rent", TagIds: []int{3}}
+	window := reminderData.EffectiveWindow(note, weekly)
+	utils.AssertEqual(t, window, model.EffectiveWindow{WindowDays: model.WindowDays{LeadDays: 1, GraceDays: 2}, LeadSource: "settings", GraceSource: "settings"})
+	reminderData.SetDueWindowOptions(&model.DueWindowOptions{OneOff: model.WindowDays{LeadDays: 3}})
+	window = reminderData.EffectiveWindow(note, nil)
+	utils.AssertEqual(t, window.WindowDays, model.WindowDays{LeadDays: 3})
+	reminderData.SetDueWindowOptions(model.DefaultDueWindowOptions())
+	// case 2 (the largest values of the tags)
+	note = &model.Note{Text: "renew passport", TagIds: []int{1, 2, 3}}
+	window = reminderData.EffectiveWindow(note, nil)
+	utils.AssertEqual(t, window, model.EffectiveWindow{WindowDays: model.WindowDays{LeadDays: 60, GraceDays: 1}, LeadSource: "tag passport", GraceSource: "tag travel"})
+	// case 3 (the values of the note)
+	note.DueWindow, _ = model.ParseDueWindow(",5")
+	window = reminderData.EffectiveWindow(note, nil)
+	utils.AssertEqual(t, window, model.EffectiveWindow{WindowDays: model.WindowDays{LeadDays: 60, GraceDays: 5}, LeadSource: "tag passport", GraceSource: "note"})
+}
+
+func TestNotesApproachingDueDateWithDueWindow(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	currentTime := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	utils.CurrentTime = func() time.Time { return currentTime }
+	day := int64(24 * 3600)
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC).Unix()
+	reminderData := &model.ReminderData{Tags: model.Tags{&model.Tag{Id: 1, Slug: "passport"}}}
+	reminderData.Tags[0].DueWindow, _ = model.ParseDueWindow("60")
+	reminderData.Notes = model.Notes{
+		{Text: "in 30 days", Status: model.NoteStatus_Pending, CompleteBy: today + 30*day},
+		{Text: "in 30 days, tagged", Status: model.NoteStatus_Pending, CompleteBy: today + 30*day, TagIds: []int{1}},
+		{Text: "in 5 days, with lead of 3 days", Status: model.NoteStatus_Pending, CompleteBy: today + 5*day},
+		{Text: "weekly, 3 days ago, with grace of 4 days", Status: model.NoteStatus_Pending, CompleteBy: today - 10*day, Recurrence: "FREQ=WEEKLY"},
+		{Text: "weekly, 3 days ago", Status: model.NoteStatus_Pending, CompleteBy: today - 10*day, Recurrence: "FREQ=WEEKLY"},
+	}
+	reminderData.Notes[2].DueWindow, _ = model.ParseDueWindow("3")
+	reminderData.Notes[3].DueWindow, _ = model.ParseDueWindow(",4")
+	notesText := func(notes model.Notes) []string {
+		var texts []string
+		for _, note := range notes {
+			texts = append(texts, note.Text)
+		}
+		return texts
+	}
+	utils.AssertEqual(t, notesText(reminderData.NotesApprachingDueDate("default")), []string{"in 30 days, tagged", "weekly, 3 days ago, with grace of 4 days"})
+	// visibility reasons
+	utils.AssertEqual(t, reminderData.VisibilityReason(reminderData.Notes[0]), "")
+	utils.AssertEqual(t, reminderData.VisibilityReason(reminderData.Notes[1]), "due on 15-Nov-26, within the lead of 60 days (from tag passport)")
+	utils.AssertEqual(t, reminderData.VisibilityReason(reminderData.Notes[3]), "occurrence due on 13-Oct-26, within the grace of 4 days (from note)")
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"github.com/goyalmunish/reminder/pkg/vault"
+)
+
+// dataFileVault encrypts the data file (and its copies) if the encryption is enabled.
+var dataFileVault *vault.Vault
+
+// SetEncryption enables encryption of the data file (and its copies) with the given vault.
+// The data file is written as plaintext JSON if the vault is nil.
+func SetEncryption(v *vault.Vault) {
+	dataFileVault = v
+}
+
+// Encryption returns the vault used for encrypting the data file, or nil if the encryption is disabled.
+func Encryption() *vault.Vault {
+	return dataFileVault
+}
+
+// IsEncryptedFile tells if the (existing) data file is encrypted.
+func IsEncryptedFile(dataFilePath string) (bool, error) {
+	byteValue, err := os.ReadFile(utils.TryConvertTildaBasedPath(dataFilePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return vault.IsSealed(byteValue), nil
+}
+
+// NewDataFileVault returns the vault for the data file with the given passphrase.
+// If the data file is already encrypted, the passphrase is verified against it.
+func NewDataFileVault(dataFilePath string, passphrase string) (*vault.Vault, error) {
+	byteValue, err := os.ReadFile(utils.TryConvertTildaBasedPath(dataFilePath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil && vault.IsSealed(byteValue) {
+		v, err := vault.NewForSealed(passphrase, byteValue)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", dataFilePath, err)
+		}
+		return v, nil
+	}
+	return vault.New(passphrase)
+}
+
+// decodeDataFile returns the plaintext JSON of the (possibly encrypted) content of a data file.
+func decodeDataFile(dataFilePath string, byteValue []byte, v *vault.Vault) ([]byte, error) {
+	if !vault.IsSealed(byteValue) {
+		return byteValue, nil
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: %q", ErrorPassphraseRequired, dataFilePath)
+	}
+	plaintext, err := v.Open(byteValue)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", dataFilePath, err)
+	}
+	return plaintext, nil
+}
+
+// encodeDataFile returns the content to be persisted for the plaintext JSON of a data file.
+func encodeDataFile(byteValue []byte, v *vault.Vault) ([]byte, error) {
+	if v == nil {
+		return byteValue, nil
+	}
+	return v.Seal(byteValue)
+}
+
+// copiesOfDataFile returns paths of all the (existing) copies of the data file, that is its
+// rolling backup, timestamped backups and _CONFLICT files.
+func (rd *ReminderData) copiesOfDataFile() ([]string, error) {
+	var copies []string
+	if _, err := os.Stat(RollingBackupPath(rd.DataFile)); err == nil {
+		copies = append(copies, RollingBackupPath(rd.DataFile))
+	}
+	backups, err := rd.Backups()
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		copies = append(copies, backup.Path)
+	}
+	conflictFiles, err := filepath.Glob(rd.DataFile + "_CONFLICT_*")
+	if err != nil {
+		return nil, err
+	}
+	return append(copies, conflictFiles...), nil
+}
+
+/*
+Rekey re-encrypts the data file, along with all of its copies (see copiesOfDataFile),
+with the new vault. If the new vault is nil, they are instead written as plaintext JSON.
+
+All the files are decrypted first, so that none of them is re-written unless each of
+them can be read with the current passphrase. It returns paths of the re-written files.
+Note: It is meant to be called while holding the lock on the data file (see LockDataFile).
+*/
+func (rd *ReminderData) Rekey(newVault *vault.Vault) ([]string, error) {
+	if rd.readOnly {
+		return nil, ErrorReadOnly
+	}
+	if _, ok := rd.Store().(*JSONStore); !ok {
+		return nil, fmt.Errorf("Encryption of the data file %q is supported only for the json store", rd.DataFile)
+	}
+	copies, err := rd.copiesOfDataFile()
+	if err != nil {
+		return nil, err
+	}
+	// note: the data file is re-written last
+	files := append(copies, rd.DataFile)
+	plaintexts := make([][]byte, len(files))
+	for i, file := range files {
+		byteValue, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if plaintexts[i], err = decodeDataFile(file, byteValue, dataFileVault); err != nil {
+			return nil, err
+		}
+	}
+	var rewritten []string
+	for i, file := range files {
+		byteValue, err := encodeDataFile(plaintexts[i], newVault)
+		if err != nil {
+			return rewritten, err
+		}
+		if err := utils.WriteFileAtomic(file, byteValue, dataFileMode); err != nil {
+			return rewritten, err
+		}
+		rewritten = append(rewritten, file)
+	}
+	SetEncryption(newVault)
+	logger.Info(fmt.Sprintf("Re-wrote %d files with encryption enabled: %v", len(rewritten), newVault != nil))
+	return rewritten, nil
+}
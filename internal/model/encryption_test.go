@@ -0,0 +1,101 @@
+package model_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"github.com/goyalmunish/reminder/pkg/vault"
+)
+
+// newTestVault returns a vault with cheap KDF parameters, just to keep the tests fast.
+func newTestVault(passphrase string) *vault.Vault {
+	v, _ := vault.NewWithParams(passphrase, vault.KDFParams{N: 1 << 10, R: 8, P: 1})
+	return v
+}
+
+// isSealedFile tells if the file is encrypted.
+func isSealedFile(filePath string) bool {
+	byteValue, _ := os.ReadFile(filePath)
+	return vault.IsSealed(byteValue)
+}
+
+func TestEncryptedDataFile(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	defer model.SetEncryption(nil)
+	model.SetEncryption(newTestVault("secret"))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	_, err = reminderData.NewNoteRegistration([]int{}, "1:1 with manager")
+	utils.AssertEqual(t, err, nil)
+	// the data file (and its copies) are encrypted, and private
+	byteValue, _ := os.ReadFile(dataFilePath)
+	utils.AssertEqual(t, bytes.Contains(byteValue, []byte("manager")), false)
+	utils.AssertEqual(t, isSealedFile(model.RollingBackupPath(dataFilePath)), true)
+	info, _ := os.Stat(dataFilePath)
+	utils.AssertEqual(t, info.Mode().Perm(), os.FileMode(0600))
+	encrypted, _ := model.IsEncryptedFile(dataFilePath)
+	utils.AssertEqual(t, encrypted, true)
+	reminderData, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, reminderData.Notes[0].Text, "1:1 with manager")
+	// the passphrase is verified against the data file
+	_, err = model.NewDataFileVault(dataFilePath, "guess")
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorWrongPassphrase), true)
+	v, err := model.NewDataFileVault(dataFilePath, "secret")
+	utils.AssertEqual(t, err, nil)
+	// the data file can't be read with wrong (or without) passphrase
+	model.SetEncryption(newTestVault("guess"))
+	_, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorWrongPassphrase), true)
+	model.SetEncryption(nil)
+	_, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, errors.Is(err, model.ErrorPassphraseRequired), true)
+	model.SetEncryption(v)
+	_, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+}
+
+func TestRekey(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	defer model.SetEncryption(nil)
+	// create plaintext data file, along with its backup
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "a note")
+	backupFile, _ := reminderData.CreateBackup()
+	// case 1 (encrypt the data file and all its copies)
+	files, err := reminderData.Rekey(newTestVault("secret"))
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, files, []string{model.RollingBackupPath(dataFilePath), backupFile, dataFilePath})
+	for _, file := range files {
+		utils.AssertEqual(t, isSealedFile(file), true)
+	}
+	utils.AssertEqual(t, model.Encryption() != nil, true)
+	backups, _ := reminderData.Backups()
+	reminderData.CountBackupNotes(backups)
+	utils.AssertEqual(t, backups[0].Notes, 1)
+	// case 2 (change the passphrase)
+	_, err = reminderData.Rekey(newTestVault("new secret"))
+	utils.AssertEqual(t, err, nil)
+	_, err = model.NewDataFileVault(dataFilePath, "secret")
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorWrongPassphrase), true)
+	// case 3 (decrypt back to plaintext)
+	_, err = reminderData.Rekey(nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, isSealedFile(dataFilePath), false)
+	utils.AssertEqual(t, isSealedFile(backupFile), false)
+	reminderData, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, reminderData.Notes[0].Text, "a note")
+}
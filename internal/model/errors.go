@@ -3,7 +3,12 @@ package model
 import "errors"
 
 var (
-	ErrorConflictFile              = errors.New("Created _CONFLICT file")
-	ErrorMutexLockOn               = errors.New("Mutex Lock is ON; there is already a session running!")
-	ErrorInteractiveProcessSkipped = errors.New("Skipped running the interactive process. Try again!")
+	ErrorConflictFile       = errors.New("Created _CONFLICT file")
+	ErrorDataFileLocked     = errors.New("Data file is locked; there is already a session running!")
+	ErrorReadOnly           = errors.New("Data file is opened in read-only mode")
+	ErrorCorruptDataFile    = errors.New("Data file is corrupt")
+	ErrorNoteNotFound       = errors.New("No note found with given id")
+	ErrorAmbiguousNoteId    = errors.New("More than one note found with given id prefix")
+	ErrorUnsupportedSchema  = errors.New("Unsupported schema version")
+	ErrorPassphraseRequired = errors.New("Data file is encrypted; its passphrase is required")
 )
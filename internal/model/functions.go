@@ -11,6 +11,7 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/goyalmunish/reminder/pkg/filelock"
 	"github.com/goyalmunish/reminder/pkg/logger"
 	"github.com/goyalmunish/reminder/pkg/utils"
 	"github.com/rivo/tview"
@@ -71,6 +72,7 @@ func NewNote(tagIDs []int, useText string) (*Note, error) {
 	var noteText string
 	var err error
 	note := &Note{
+		Id:         NewNoteId(),
 		Comments:   Comments{},
 		Status:     NoteStatus_Pending,
 		CompleteBy: 0,
@@ -177,34 +179,7 @@ func NewTag(tagID int, useSlug string, useGroup string) (*Tag, error) {
 
 // MakeSureFileExists function makes sure that the dataFilePath exists.
 func MakeSureFileExists(dataFilePath string, askUserInput bool) error {
-	dataFilePath = utils.TryConvertTildaBasedPath(dataFilePath)
-	_, err := os.Stat(dataFilePath)
-	if err != nil {
-		logger.Warn(fmt.Sprintf("Error finding existing data file: %v\n", err))
-		if errors.Is(err, fs.ErrNotExist) {
-			logger.Info(fmt.Sprintf("Generating new data file %q.\n", dataFilePath))
-			err := os.MkdirAll(path.Dir(dataFilePath), 0751)
-			if err != nil {
-				return err
-			}
-			reminderData, err := BlankReminder(askUserInput, dataFilePath)
-			if err != nil {
-				return err
-			}
-			reminderData.DataFile = dataFilePath // save absolute path
-			err = reminderData.CreateDataFile("Persisting the newly created data file")
-			if err != nil {
-				return err
-			}
-			err = reminderData.RegisterBasicTags()
-			if err != nil {
-				return err
-			}
-			return reminderData.UpdateDataFile("")
-		}
-		return err
-	}
-	return nil
+	return MakeSureStoreExists(NewJSONStore(dataFilePath), askUserInput)
 }
 
 // BlankReminder function creates blank ReminderData object.
@@ -214,10 +189,11 @@ func BlankReminder(askUserInput bool, dataFilePath string) (*ReminderData, error
 	fmt.Println("Initializing the data file. Please provide following data:")
 	app := tview.NewApplication()
 	reminderData := &ReminderData{
-		User:     &User{Name: name, EmailId: emailID},
-		Notes:    Notes{},
-		Tags:     Tags{},
-		DataFile: dataFilePath,
+		User:          &User{Name: name, EmailId: emailID},
+		Notes:         Notes{},
+		Tags:          Tags{},
+		DataFile:      dataFilePath,
+		SchemaVersion: CurrentSchemaVersion(),
 	}
 
 	if !askUserInput {
@@ -273,14 +249,116 @@ func ReadDataFile(dataFilePath string, silentMode bool) (*ReminderData, error) {
 	if err != nil {
 		return nil, err
 	}
+	// decrypt the data, if it is encrypted
+	byteValue, err = decodeDataFile(dataFilePath, byteValue, dataFileVault)
+	if err != nil {
+		return nil, err
+	}
 	// parse json data
 	err = json.Unmarshal(byteValue, &reminderData)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w %q: %v", ErrorCorruptDataFile, dataFilePath, err)
 	}
 	if !silentMode {
 		logger.Info(fmt.Sprintf("Read contents of %q into ReminderData.", dataFilePath))
 	}
-	// close the file
+	// upgrade the data persisted by older versions of the app
+	if err := reminderData.migrate(); err != nil {
+		return nil, err
+	}
 	return &reminderData, nil
 }
+
+// LockFilePath returns path of the lock file of the data file.
+func LockFilePath(dataFilePath string) string {
+	return utils.TryConvertTildaBasedPath(dataFilePath) + ".lock"
+}
+
+// LockDataFile acquires the (OS-level) lock on the data file.
+// If another session holds the lock, the returned error wraps both ErrorDataFileLocked
+// and *filelock.LockedError (with details of the holding process).
+func LockDataFile(dataFilePath string) (*filelock.Lock, error) {
+	lock, err := filelock.Acquire(LockFilePath(dataFilePath))
+	var lockedErr *filelock.LockedError
+	if errors.As(err, &lockedErr) {
+		return nil, fmt.Errorf("%w %w", ErrorDataFileLocked, lockedErr)
+	}
+	return lock, err
+}
+
+// RollingBackupPath returns path of the rolling backup of the data file, which
+// is the previous (valid) version of the data file kept on each update.
+func RollingBackupPath(dataFilePath string) string {
+	return dataFilePath + ".bak"
+}
+
+// latestBackupPath returns path of the symlink pointing to the latest timestamped backup.
+func latestBackupPath(dataFilePath string) string {
+	ext := path.Ext(dataFilePath)
+	return dataFilePath[:len(dataFilePath)-len(ext)] + "_backup_latest" + ext
+}
+
+// dataFileMode is the permission of the data file and its copies, as the notes are private.
+const dataFileMode os.FileMode = 0600
+
+// writeDataFile atomically replaces contents of the data file with byteValue (the plaintext JSON),
+// which is encrypted first if the encryption is enabled.
+// The current content of the data file (if it is valid) is kept as its rolling backup.
+func writeDataFile(dataFilePath string, byteValue []byte) error {
+	byteValue, err := encodeDataFile(byteValue, dataFileVault)
+	if err != nil {
+		return err
+	}
+	existingValue, err := os.ReadFile(dataFilePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	// never replace the last good copy with a corrupt one
+	// note: an encrypted data file is a JSON document as well
+	if err == nil && json.Valid(existingValue) {
+		if err := utils.WriteFileAtomic(RollingBackupPath(dataFilePath), existingValue, dataFileMode); err != nil {
+			return err
+		}
+	}
+	return utils.WriteFileAtomic(dataFilePath, byteValue, dataFileMode)
+}
+
+// LastGoodDataFile returns path of the most recent valid copy of the data file,
+// looking at its rolling backup first and then at its latest timestamped backup.
+func LastGoodDataFile(dataFilePath string) (string, error) {
+	dataFilePath = utils.TryConvertTildaBasedPath(dataFilePath)
+	for _, candidate := range []string{RollingBackupPath(dataFilePath), latestBackupPath(dataFilePath)} {
+		if _, err := ReadDataFile(candidate, true); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("No valid copy of the data file %q found", dataFilePath)
+}
+
+// RestoreDataFile replaces the data file with the contents of given copy of it.
+// The replaced data file is kept aside as `<data file>_CORRUPT_<timestamp>`.
+func RestoreDataFile(dataFilePath string, fromFilePath string) error {
+	dataFilePath = utils.TryConvertTildaBasedPath(dataFilePath)
+	byteValue, err := os.ReadFile(fromFilePath)
+	if err != nil {
+		return err
+	}
+	// note: the copy is re-encrypted (or decrypted) as per the current encryption setting
+	if byteValue, err = decodeDataFile(fromFilePath, byteValue, dataFileVault); err != nil {
+		return err
+	}
+	if byteValue, err = encodeDataFile(byteValue, dataFileVault); err != nil {
+		return err
+	}
+	corruptFilePath := fmt.Sprintf("%s_CORRUPT_%d", dataFilePath, utils.CurrentUnixTimestamp())
+	if err := os.Rename(dataFilePath, corruptFilePath); err == nil {
+		logger.Warn(fmt.Sprintf("Moved the corrupt data file to %q.", corruptFilePath))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := utils.WriteFileAtomic(dataFilePath, byteValue, dataFileMode); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Restored the data file %q from %q.", dataFilePath, fromFilePath))
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -16,7 +17,9 @@ func TestNewNote(t *testing.T) {
 	tagIDs := []int{1, 3, 5}
 	dummyText := "a random note text"
 	note, _ := model.NewNote(tagIDs, dummyText)
+	utils.AssertEqual(t, len(note.Id), 36)
 	want := &model.Note{
+		Id:         note.Id,
 		Text:       dummyText,
 		TagIds:     tagIDs,
 		Status:     note.Status,
@@ -82,3 +85,67 @@ func TestReadDataFile(t *testing.T) {
 	reminderData, _ := model.ReadDataFile(dataFilePath, false)
 	utils.AssertEqual(t, reminderData.UpdatedAt > 0, true)
 }
+
+func TestReadDataFileCorrupt(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = os.MkdirAll(path.Dir(dataFilePath), 0751)
+	// a truncated data file
+	_ = os.WriteFile(dataFilePath, []byte(`{"user": {"name": "Test`), 0600)
+	_, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, errors.Is(err, model.ErrorCorruptDataFile), true)
+}
+
+func TestLockDataFile(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	lock, err := model.LockDataFile(dataFilePath)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, lock.Path, model.LockFilePath(dataFilePath))
+	// another session can't acquire the lock
+	_, err = model.LockDataFile(dataFilePath)
+	utils.AssertEqual(t, errors.Is(err, model.ErrorDataFileLocked), true)
+	utils.AssertEqual(t, lock.Release(), nil)
+}
+
+func TestLastGoodDataFileAndRestoreDataFile(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.User = &model.User{Name: "Test User", EmailId: "user@test.com"}
+	_ = reminderData.UpdateDataFile("")
+	// each update keeps the previous version as rolling backup
+	backupData, err := model.ReadDataFile(model.RollingBackupPath(dataFilePath), false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, backupData.User.EmailId, "")
+	// corrupt the data file
+	_ = os.WriteFile(dataFilePath, []byte(`{"user": {"na`), 0600)
+	goodFile, err := model.LastGoodDataFile(dataFilePath)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, goodFile, model.RollingBackupPath(dataFilePath))
+	// restore the data file
+	err = model.RestoreDataFile(dataFilePath, goodFile)
+	utils.AssertEqual(t, err, nil)
+	_, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	corruptFiles, _ := filepath.Glob(dataFilePath + "_CORRUPT_*")
+	utils.AssertEqual(t, len(corruptFiles), 1)
+	// a corrupt data file never replaces the rolling backup
+	_ = os.WriteFile(dataFilePath, []byte(`{"user": {"na`), 0600)
+	reminderData.DataFile = dataFilePath
+	reminderData.UpdatedAt = 0
+	_ = reminderData.CreateDataFile("")
+	_, err = model.ReadDataFile(model.RollingBackupPath(dataFilePath), false)
+	utils.AssertEqual(t, err, nil)
+	// no valid copy is available
+	os.Remove(model.RollingBackupPath(dataFilePath))
+	_, err = model.LastGoodDataFile(dataFilePath)
+	utils.AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// icsUIDSuffix makes the UIDs of the exported notes globally unique, as required by RFC 5545.
+const icsUIDSuffix = "@reminder"
+
+// ExportICS returns the iCalendar feed of the pending notes with a due date, as events, or as to-dos
+// (see calendar.ExportICS). The recurring notes are exported along with their recurrence rules.
+// Each note keeps the same UID across the exports, so that the calendar apps update (rather than
+// duplicate) its event; and as with the calendar sync, the notes are redacted (see RedactionOptions).
+func (rd *ReminderData) ExportICS(component string) (string, error) {
+	notes := rd.Notes.WithStatus(NoteStatus_Pending).WithCompleteBy()
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	// note: the notes without timezone are exported in the timezone of the app
+	timeZone := timeZoneName(utils.CurrentLocation())
+	var events []*calendar.Event
+	for _, note := range notes {
+		event, err := note.CalendarEvent(repeatAnnuallyTagId, repeatMonthlyTagId, timeZone, rd, rd.eventOptions, rd.redaction)
+		if err != nil {
+			return "", err
+		}
+		event.Id = note.Id + icsUIDSuffix
+		events = append(events, event)
+	}
+	return calendar.ExportICS(events, component, "reminder")
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestExportICS(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	// Sun Nov 01 2026 00:00:00 GMT+0000
+	dueDate := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix()
+	reminderData.Notes = model.Notes{
+		{Id: "rent", Text: "pay the rent", Status: model.NoteStatus_Pending, CompleteBy: dueDate, Recurrence: "FREQ=MONTHLY",
+			Comments: model.Comments{{Text: "account number 1234"}}},
+		{Id: "bank", Text: "call the bank", Status: model.NoteStatus_Pending, CompleteBy: dueDate + 9*3600 + 30*60},
+		{Id: "done", Text: "feed the cat", Status: model.NoteStatus_Done, CompleteBy: dueDate},
+		{Id: "no-due-date", Text: "read a book", Status: model.NoteStatus_Pending},
+	}
+	data, err := reminderData.ExportICS(calendar.ICSComponent_Event)
+	utils.AssertEqual(t, err, nil)
+	events, err := calendar.DecodeICS(data)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 2)
+	utils.AssertEqual(t, events[0].Id, "rent@reminder")
+	utils.AssertEqual(t, events[0].Recurrence, []string{"RRULE:FREQ=MONTHLY"})
+	utils.AssertEqual(t, events[1].Id, "bank@reminder")
+	utils.AssertEqual(t, events[1].Start.DateTime, "2026-11-01T09:30:00Z")
+	utils.AssertEqual(t, strings.Contains(data, "X-WR-CALNAME:reminder\r\n"), true)
+	// the comments are left out
+	utils.AssertEqual(t, strings.Contains(data, "1234"), false)
+	// the notes keep their UIDs across the exports
+	again, _ := reminderData.ExportICS(calendar.ICSComponent_Event)
+	utils.AssertEqual(t, strings.Count(again, "UID:rent@reminder\r\n"), 1)
+	// as to-dos
+	data, err = reminderData.ExportICS(calendar.ICSComponent_Todo)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, strings.Count(data, "BEGIN:VTODO\r\n"), 2)
+	utils.AssertEqual(t, strings.Contains(data, "DUE:20261101T093000Z\r\n"), true)
+	utils.AssertEqual(t, strings.Count(data, "STATUS:NEEDS-ACTION\r\n"), 2)
+	utils.AssertEqual(t, strings.Contains(data, "DTEND"), false)
+}
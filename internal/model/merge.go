@@ -0,0 +1,368 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+/*
+A MergeConflict represents a field of a note (or a tag) which is changed
+differently in both the data files being merged.
+
+The "ours" side is the persisted data file, and the "theirs" side is the
+_CONFLICT file being merged into it.
+*/
+type MergeConflict struct {
+	NoteId          string
+	NoteText        string
+	Field           string
+	Ours            string
+	Theirs          string
+	OursUpdatedAt   int64
+	TheirsUpdatedAt int64
+	// TookTheirs tells how the conflict was resolved.
+	TookTheirs bool
+}
+
+// String provides basic string representation of the conflict.
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%q of %q: %q vs %q", c.Field, c.NoteText, c.Ours, c.Theirs)
+}
+
+// A MergeResolver resolves a conflict, by telling if "theirs" side is to be taken.
+type MergeResolver func(conflict MergeConflict) (bool, error)
+
+// NewerWinsResolver resolves a conflict in favour of the side whose note was updated last.
+// In case of a tie, "ours" side is kept.
+func NewerWinsResolver(conflict MergeConflict) (bool, error) {
+	return conflict.TheirsUpdatedAt > conflict.OursUpdatedAt, nil
+}
+
+/*
+A MergeReport summarizes the outcome of a merge.
+*/
+type MergeReport struct {
+	NotesAdded    int
+	NotesMerged   int
+	CommentsAdded int
+	TagsAdded     int
+	Conflicts     []MergeConflict
+}
+
+// String provides basic string representation of the report.
+func (r MergeReport) String() string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Merge summary: %d notes added, %d notes merged, %d comments added, %d tags added, %d conflicts resolved",
+		r.NotesAdded, r.NotesMerged, r.CommentsAdded, r.TagsAdded, len(r.Conflicts)))
+	for _, c := range r.Conflicts {
+		side := "ours"
+		if c.TookTheirs {
+			side = "theirs"
+		}
+		lines = append(lines, fmt.Sprintf("  - took %s for %v", side, c))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// merger holds state of an ongoing merge.
+type merger struct {
+	resolver MergeResolver
+	report   MergeReport
+	merged   *ReminderData
+}
+
+// identity displays a string value as it is.
+func identity(s string) string {
+	return s
+}
+
+// displayTimestamp displays a timestamp value, such as due date of a note.
+func displayTimestamp(unixTimestamp int64) string {
+	if unixTimestamp == 0 {
+		return "nil"
+	}
+	return utils.UnixTimestampToTimeStr(unixTimestamp, "02-Jan-06 15:04")
+}
+
+// mergeValue does three-way merge of a single value.
+// The base is nil if the value isn't present in the common ancestor.
+// A true conflict (both sides changed the value differently) is passed to the resolver.
+func mergeValue[V comparable](m *merger, conflict MergeConflict, base *V, ours V, theirs V, display func(V) string) (V, error) {
+	if ours == theirs {
+		return ours, nil
+	}
+	if base != nil {
+		if *base == ours {
+			return theirs, nil
+		}
+		if *base == theirs {
+			return ours, nil
+		}
+	}
+	conflict.Ours, conflict.Theirs = display(ours), display(theirs)
+	tookTheirs, err := m.resolver(conflict)
+	if err != nil {
+		return ours, err
+	}
+	conflict.TookTheirs = tookTheirs
+	m.report.Conflicts = append(m.report.Conflicts, conflict)
+	if tookTheirs {
+		return theirs, nil
+	}
+	return ours, nil
+}
+
+// noteMergeKey returns key identifying the note across data files.
+// Notes persisted before ids were introduced are identified by their creation time and text.
+func noteMergeKey(note *Note) string {
+	if note.Id != "" {
+		return note.Id
+	}
+	return fmt.Sprintf("%d|%s", note.CreatedAt, note.Text)
+}
+
+/*
+MergeData does three-way merge of the persisted data (ours) and the data of
+a _CONFLICT file (theirs), with base being their common ancestor.
+
+Notes are matched by their ids, tags are matched by their slugs, and comments
+are unioned. Each field of a note changed only on one side (as compared to the
+base) is taken from that side, whereas a field changed on both sides is a true
+conflict which is passed to the resolver. The base can be nil, in which case every
+differing field is treated as a conflict.
+
+It returns the merged data (which is based on ours, and is meant to be persisted
+at ours' data file), along with the merge report.
+*/
+func MergeData(base *ReminderData, ours *ReminderData, theirs *ReminderData, resolver MergeResolver) (*ReminderData, MergeReport, error) {
+	merged := *ours
+	m := &merger{resolver: resolver, merged: &merged}
+	merged.Tags = make(Tags, 0, len(ours.Tags))
+	merged.Notes = make(Notes, 0, len(ours.Notes))
+
+	// merge tags (by their slugs)
+	for _, tag := range ours.Tags {
+		tagCopy := *tag
+		merged.Tags = append(merged.Tags, &tagCopy)
+	}
+	for _, tag := range theirs.Tags {
+		mergedTag := merged.Tags.FromSlug(tag.Slug)
+		if mergedTag == nil {
+			tagCopy := *tag
+			tagCopy.Id = merged.nextPossibleTagId()
+			merged.Tags = append(merged.Tags, &tagCopy)
+			m.report.TagsAdded++
+			continue
+		}
+		var baseGroup *string
+		if base != nil {
+			if baseTag := base.Tags.FromSlug(tag.Slug); baseTag != nil {
+				baseGroup = &baseTag.Group
+			}
+		}
+		conflict := MergeConflict{NoteText: "tag " + tag.Slug, Field: "group", OursUpdatedAt: mergedTag.UpdatedAt, TheirsUpdatedAt: tag.UpdatedAt}
+		group, err := mergeValue(m, conflict, baseGroup, mergedTag.Group, tag.Group, identity)
+		if err != nil {
+			return nil, m.report, err
+		}
+		mergedTag.Group = group
+	}
+
+	// merge notes (by their ids)
+	theirNotes := make(map[string]*Note, len(theirs.Notes))
+	for _, note := range theirs.Notes {
+		theirNotes[noteMergeKey(note)] = note
+	}
+	baseNotes := make(map[string]*Note)
+	if base != nil {
+		for _, note := range base.Notes {
+			baseNotes[noteMergeKey(note)] = note
+		}
+	}
+	seen := make(map[string]bool, len(ours.Notes))
+	for _, note := range ours.Notes {
+		key := noteMergeKey(note)
+		seen[key] = true
+		theirNote, ok := theirNotes[key]
+		if !ok {
+			merged.Notes = append(merged.Notes, note)
+			continue
+		}
+		mergedNote, err := m.mergeNote(baseNotes[key], base, note, ours, theirNote, theirs)
+		if err != nil {
+			return nil, m.report, err
+		}
+		merged.Notes = append(merged.Notes, mergedNote)
+	}
+	for _, note := range theirs.Notes {
+		if seen[noteMergeKey(note)] {
+			continue
+		}
+		noteCopy := *note
+		noteCopy.TagIds = remapTagIds(note.TagIds, theirs, &merged)
+		merged.Notes = append(merged.Notes, &noteCopy)
+		m.report.NotesAdded++
+	}
+	logger.Info(m.report.String())
+	return &merged, m.report, nil
+}
+
+// mergeNote merges a note present on both sides.
+func (m *merger) mergeNote(baseNote *Note, base *ReminderData, ours *Note, oursData *ReminderData, theirs *Note, theirsData *ReminderData) (*Note, error) {
+	merged := *ours
+	conflict := MergeConflict{NoteId: ours.Id, NoteText: ours.Text, OursUpdatedAt: ours.UpdatedAt, TheirsUpdatedAt: theirs.UpdatedAt}
+	var err error
+	field := func(name string) MergeConflict {
+		c := conflict
+		c.Field = name
+		return c
+	}
+	// pick base value of the given field, if the note is present in base
+	var baseText, baseSummary, baseTimeZone, baseRecurrence, baseTags, baseDueWindow, baseCalendarEventId *string
+	var baseStatus *NoteStatus
+	var baseIsMain, baseNoSync *bool
+	var baseCompleteBy, baseDeferUntil *int64
+	if baseNote != nil {
+		baseTagSlugs := tagSlugsKey(base, baseNote.TagIds)
+		baseText, baseSummary, baseTimeZone, baseRecurrence, baseTags = &baseNote.Text, &baseNote.Summary, &baseNote.TimeZone, &baseNote.Recurrence, &baseTagSlugs
+		baseStatus, baseIsMain, baseCompleteBy, baseDeferUntil = &baseNote.Status, &baseNote.IsMain, &baseNote.CompleteBy, &baseNote.DeferUntil
+		baseCalendarEventId, baseNoSync = &baseNote.CalendarEventId, &baseNote.NoSync
+		baseDueWindowStr := formatDueWindow(baseNote.DueWindow)
+		baseDueWindow = &baseDueWindowStr
+	}
+	if merged.Text, err = mergeValue(m, field("text"), baseText, ours.Text, theirs.Text, identity); err != nil {
+		return nil, err
+	}
+	if merged.Summary, err = mergeValue(m, field("summary"), baseSummary, ours.Summary, theirs.Summary, identity); err != nil {
+		return nil, err
+	}
+	if merged.Status, err = mergeValue(m, field("status"), baseStatus, ours.Status, theirs.Status, func(s NoteStatus) string { return string(s) }); err != nil {
+		return nil, err
+	}
+	if merged.IsMain, err = mergeValue(m, field("is_main"), baseIsMain, ours.IsMain, theirs.IsMain, func(b bool) string { return fmt.Sprint(b) }); err != nil {
+		return nil, err
+	}
+	if merged.CompleteBy, err = mergeValue(m, field("complete_by"), baseCompleteBy, ours.CompleteBy, theirs.CompleteBy, displayTimestamp); err != nil {
+		return nil, err
+	}
+	if merged.DeferUntil, err = mergeValue(m, field("defer_until"), baseDeferUntil, ours.DeferUntil, theirs.DeferUntil, displayTimestamp); err != nil {
+		return nil, err
+	}
+	if merged.TimeZone, err = mergeValue(m, field("timezone"), baseTimeZone, ours.TimeZone, theirs.TimeZone, identity); err != nil {
+		return nil, err
+	}
+	if merged.Recurrence, err = mergeValue(m, field("recurrence"), baseRecurrence, ours.Recurrence, theirs.Recurrence, identity); err != nil {
+		return nil, err
+	}
+	if merged.CalendarEventId, err = mergeValue(m, field("calendar_event_id"), baseCalendarEventId, ours.CalendarEventId, theirs.CalendarEventId, identity); err != nil {
+		return nil, err
+	}
+	if merged.NoSync, err = mergeValue(m, field("no_sync"), baseNoSync, ours.NoSync, theirs.NoSync, func(b bool) string { return fmt.Sprint(b) }); err != nil {
+		return nil, err
+	}
+	// due window is compared by its representation, as its values are pointers
+	mergedDueWindow, err := mergeValue(m, field("due_window"), baseDueWindow, formatDueWindow(ours.DueWindow), formatDueWindow(theirs.DueWindow), identity)
+	if err != nil {
+		return nil, err
+	}
+	if merged.DueWindow, err = ParseDueWindow(mergedDueWindow); err != nil {
+		return nil, err
+	}
+	// tags are compared by their slugs, as tag ids may differ across the data files
+	mergedTags, err := mergeValue(m, field("tags"), baseTags, tagSlugsKey(oursData, ours.TagIds), tagSlugsKey(theirsData, theirs.TagIds), identity)
+	if err != nil {
+		return nil, err
+	}
+	if mergedTags != tagSlugsKey(oursData, ours.TagIds) {
+		merged.TagIds = remapTagIds(theirs.TagIds, theirsData, m.merged)
+	}
+	// comments are unioned
+	merged.Comments = append(Comments{}, ours.Comments...)
+	for _, comment := range theirs.Comments {
+		if !hasComment(merged.Comments, comment) {
+			merged.Comments = append(merged.Comments, comment)
+			m.report.CommentsAdded++
+		}
+	}
+	sort.SliceStable(merged.Comments, func(i, j int) bool { return merged.Comments[i].CreatedAt < merged.Comments[j].CreatedAt })
+	// completions are unioned as well
+	merged.Completions = append(Completions{}, ours.Completions...)
+	for _, completion := range theirs.Completions {
+		if !hasCompletion(merged.Completions, completion) {
+			merged.Completions = append(merged.Completions, completion)
+		}
+	}
+	sort.Stable(merged.Completions)
+	if len(merged.Completions) == 0 {
+		merged.Completions = nil
+	}
+	if theirs.UpdatedAt > merged.UpdatedAt {
+		merged.UpdatedAt = theirs.UpdatedAt
+	}
+	m.report.NotesMerged++
+	return &merged, nil
+}
+
+// tagSlugsKey returns sorted (comma separated) slugs of given tag ids, for comparison across data files.
+func tagSlugsKey(rd *ReminderData, tagIDs []int) string {
+	slugs := rd.TagsFromIds(tagIDs)
+	sort.Strings(slugs)
+	return strings.Join(slugs, ",")
+}
+
+// remapTagIds maps tag ids of `from` data to the ids of tags with same slugs in `to` data.
+func remapTagIds(tagIDs []int, from *ReminderData, to *ReminderData) []int {
+	remapped := make([]int, 0, len(tagIDs))
+	for _, slug := range from.TagsFromIds(tagIDs) {
+		if tag := to.TagFromSlug(slug); tag != nil {
+			remapped = append(remapped, tag.Id)
+		}
+	}
+	return remapped
+}
+
+// hasComment tells if an identical comment (same text and creation time) is present.
+func hasComment(comments Comments, comment *Comment) bool {
+	for _, c := range comments {
+		if c.Text == comment.Text && c.CreatedAt == comment.CreatedAt {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeConflictFile merges the given _CONFLICT file into the data file.
+// The baseFile is an optional copy (such as a backup) of the common ancestor of both the files.
+// The merged data is written back to the data file.
+func (rd *ReminderData) MergeConflictFile(conflictFile string, baseFile string, resolver MergeResolver) (MergeReport, error) {
+	theirs, err := ReadDataFile(conflictFile, true)
+	if err != nil {
+		return MergeReport{}, err
+	}
+	var base *ReminderData
+	if baseFile != "" {
+		if base, err = ReadDataFile(baseFile, true); err != nil {
+			return MergeReport{}, err
+		}
+	}
+	merged, report, err := MergeData(base, rd, theirs, resolver)
+	if err != nil {
+		return report, err
+	}
+	rd.Notes, rd.Tags = merged.Notes, merged.Tags
+	return report, rd.UpdateDataFile(fmt.Sprintf("Merged the conflict file %q.", conflictFile))
+}
+
+// hasCompletion tells if an identical completion (same occurrence and completion time) is present.
+func hasCompletion(completions Completions, completion *Completion) bool {
+	for _, c := range completions {
+		if c.DueDate == completion.DueDate && c.CreatedAt == completion.CreatedAt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package model_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func mergeTestReminderData(notes model.Notes, tags model.Tags) *model.ReminderData {
+	return &model.ReminderData{Notes: notes, Tags: tags}
+}
+
+func TestMergeData(t *testing.T) {
+	baseTags := model.Tags{&model.Tag{Id: 0, Slug: "home", Group: "place"}}
+	comment1 := &model.Comment{Text: "c1", BaseStruct: model.BaseStruct{CreatedAt: 1600000001}}
+	comment2 := &model.Comment{Text: "c2", BaseStruct: model.BaseStruct{CreatedAt: 1600000002}}
+	comment3 := &model.Comment{Text: "c3", BaseStruct: model.BaseStruct{CreatedAt: 1600000003}}
+	base := mergeTestReminderData(model.Notes{
+		&model.Note{Id: "n1", Text: "one", Status: model.NoteStatus_Pending, TagIds: []int{0}, Comments: model.Comments{comment1}},
+		&model.Note{Id: "n2", Text: "two", Status: model.NoteStatus_Pending},
+	}, baseTags)
+	// ours: text of n1 updated, comment added to n1, status of n2 updated
+	ours := mergeTestReminderData(model.Notes{
+		&model.Note{Id: "n1", Text: "one updated", Status: model.NoteStatus_Pending, TagIds: []int{0}, Comments: model.Comments{comment1, comment3}, BaseStruct: model.BaseStruct{UpdatedAt: 1600000010}},
+		&model.Note{Id: "n2", Text: "two", Status: model.NoteStatus_Done, BaseStruct: model.BaseStruct{UpdatedAt: 1600000010}},
+	}, baseTags)
+	// theirs: summary and tag of n1 updated (with a new tag), comment added to n1, status of n2 updated differently, and a new note
+	theirsTags := model.Tags{&model.Tag{Id: 0, Slug: "work", Group: "place"}, &model.Tag{Id: 1, Slug: "home", Group: "place"}}
+	theirs := mergeTestReminderData(model.Notes{
+		&model.Note{Id: "n1", Text: "one", Summary: "s", Status: model.NoteStatus_Pending, TagIds: []int{0}, Comments: model.Comments{comment1, comment2}, BaseStruct: model.BaseStruct{UpdatedAt: 1600000020}},
+		&model.Note{Id: "n2", Text: "two", Status: model.NoteStatus_Suspended, BaseStruct: model.BaseStruct{UpdatedAt: 1600000020}},
+		&model.Note{Id: "n3", Text: "three", Status: model.NoteStatus_Pending, TagIds: []int{0, 1}},
+	}, theirsTags)
+	// case 1 (three-way merge)
+	merged, report, err := model.MergeData(base, ours, theirs, model.NewerWinsResolver)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, merged.TagsFromIds([]int{0, 1}), []string{"home", "work"})
+	n1 := merged.NoteByID("n1")
+	utils.AssertEqual(t, n1.Text, "one updated")
+	utils.AssertEqual(t, n1.Summary, "s")
+	utils.AssertEqual(t, merged.TagsFromIds(n1.TagIds), []string{"work"})
+	utils.AssertEqual(t, n1.Comments, model.Comments{comment1, comment2, comment3})
+	utils.AssertEqual(t, n1.UpdatedAt, int64(1600000020))
+	utils.AssertEqual(t, merged.NoteByID("n2").Status, model.NoteStatus_Suspended)
+	utils.AssertEqual(t, merged.TagsFromIds(merged.NoteByID("n3").TagIds), []string{"work", "home"})
+	utils.AssertEqual(t, report.NotesAdded, 1)
+	utils.AssertEqual(t, report.NotesMerged, 2)
+	utils.AssertEqual(t, report.CommentsAdded, 1)
+	utils.AssertEqual(t, report.TagsAdded, 1)
+	utils.AssertEqual(t, len(report.Conflicts), 1)
+	utils.AssertEqual(t, report.Conflicts[0].Field, "status")
+	utils.AssertEqual(t, report.Conflicts[0].TookTheirs, true)
+	// ours is left as it is
+	utils.AssertEqual(t, ours.Notes[0].Summary, "")
+	utils.AssertEqual(t, len(ours.Tags), 1)
+	// case 2 (without base, every difference is a conflict)
+	keepOurs := func(conflict model.MergeConflict) (bool, error) { return false, nil }
+	merged, report, _ = model.MergeData(nil, ours, theirs, keepOurs)
+	utils.AssertEqual(t, merged.NoteByID("n1").Summary, "")
+	utils.AssertEqual(t, merged.NoteByID("n2").Status, model.NoteStatus_Done)
+	utils.AssertEqual(t, len(report.Conflicts), 4)
+}
+
+func TestMergeConflictFile(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	var conflictFilePath = "temp_test_dir/mydata.json_CONFLICT_1600000000"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	_ = model.MakeSureFileExists(conflictFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.Notes = model.Notes{&model.Note{Id: "n1", Text: "one"}}
+	_ = reminderData.UpdateDataFile("")
+	conflictData, _ := model.ReadDataFile(conflictFilePath, false)
+	conflictData.Notes = model.Notes{&model.Note{Id: "n2", Text: "two"}}
+	_ = conflictData.UpdateDataFile("")
+	// the merged data is written back to the data file
+	report, err := reminderData.MergeConflictFile(conflictFilePath, "", model.NewerWinsResolver)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, report.NotesAdded, 1)
+	remiderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(remiderDataRe.Notes), 2)
+	utils.AssertEqual(t, remiderDataRe.DataFile, reminderData.DataFile)
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+/*
+A Migration upgrades the data to a schema version from its previous version.
+
+Each change to the format of the data file (such as a new field which has
+to be populated for the existing data) is registered as a migration, so
+that the data files persisted by older versions of the app are upgraded
+automatically when they are read.
+*/
+type Migration struct {
+	// Version is the schema version the data is upgraded to.
+	Version     int
+	Description string
+	// Migrate upgrades the data in place, and returns the list of changes done.
+	Migrate func(rd *ReminderData) ([]string, error)
+}
+
+// A MigrationResult represents the changes done by a migration.
+type MigrationResult struct {
+	Version     int
+	Description string
+	Changes     []string
+}
+
+// migrations is the ordered registry of all the migrations.
+// Note: A registered migration must never be changed or removed; append a new one instead.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Assign ids to the notes",
+		Migrate: func(rd *ReminderData) ([]string, error) {
+			var backfilled Notes
+			for _, note := range rd.Notes {
+				if note.Id == "" {
+					backfilled = append(backfilled, note)
+				}
+			}
+			rd.Notes.BackfillIds()
+			changes := make([]string, 0, len(backfilled))
+			for _, note := range backfilled {
+				changes = append(changes, fmt.Sprintf("assigned id %s to note %q", note.ShortId(), note.Text))
+			}
+			return changes, nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Set recurrence of the notes from their repeat tags",
+		Migrate: func(rd *ReminderData) ([]string, error) {
+			repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+			var changes []string
+			for _, note := range rd.Notes {
+				// note: the recurrence starts from the due date, without which the repeat tags have no effect
+				if note.Recurrence != "" || note.CompleteBy == 0 {
+					continue
+				}
+				if rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId); rule != nil {
+					note.Recurrence = rule.String()
+					changes = append(changes, fmt.Sprintf("set recurrence %s of note %s %q", note.Recurrence, note.ShortId(), note.Text))
+				}
+			}
+			return changes, nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "Move the date-only due dates to the timezone of the app",
+		Migrate: func(rd *ReminderData) ([]string, error) {
+			// note: earlier, a due date was stored as the start of the day in UTC
+			location := utils.CurrentLocation()
+			toLocation := func(unixTimestamp int64) int64 {
+				if unixTimestamp <= 0 || unixTimestamp%(24*60*60) != 0 {
+					return unixTimestamp
+				}
+				year, month, day := time.Unix(unixTimestamp, 0).UTC().Date()
+				return time.Date(year, month, day, 0, 0, 0, 0, location).Unix()
+			}
+			var changes []string
+			for _, note := range rd.Notes {
+				if note.CompleteBy == 0 || note.TimeZone != "" {
+					continue
+				}
+				note.CompleteBy = toLocation(note.CompleteBy)
+				note.TimeZone = timeZoneName(location)
+				for _, completion := range note.Completions {
+					completion.DueDate = toLocation(completion.DueDate)
+				}
+				changes = append(changes, fmt.Sprintf("moved due date %s of note %s %q to timezone %s", note.DueDateStr(note.CompleteBy), note.ShortId(), note.Text, location))
+			}
+			return changes, nil
+		},
+	},
+}
+
+// CurrentSchemaVersion returns the schema version of the data persisted by this version of the app.
+func CurrentSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// migrate runs all the pending migrations on the data (in memory).
+// The applied migrations are remembered, to be persisted later with PersistMigrations.
+func (rd *ReminderData) migrate() error {
+	if rd.SchemaVersion > CurrentSchemaVersion() {
+		return fmt.Errorf("%w: the data file has schema version %d, whereas this version of the app supports up to %d; upgrade the app",
+			ErrorUnsupportedSchema, rd.SchemaVersion, CurrentSchemaVersion())
+	}
+	for _, migration := range migrations {
+		if migration.Version <= rd.SchemaVersion {
+			continue
+		}
+		changes, err := migration.Migrate(rd)
+		if err != nil {
+			return fmt.Errorf("Unable to migrate the data to schema version %d: %w", migration.Version, err)
+		}
+		rd.SchemaVersion = migration.Version
+		rd.migrations = append(rd.migrations, MigrationResult{Version: migration.Version, Description: migration.Description, Changes: changes})
+	}
+	return nil
+}
+
+// AppliedMigrations returns the migrations which were applied (in memory) when the data was read,
+// and which are yet to be persisted.
+func (rd *ReminderData) AppliedMigrations() []MigrationResult {
+	return rd.migrations
+}
+
+// MigrationReport describes the applied migrations along with the changes done by them.
+func (rd *ReminderData) MigrationReport() string {
+	if len(rd.migrations) == 0 {
+		return fmt.Sprintf("The data file %q is already at the current schema version %d.\n", rd.DataFile, CurrentSchemaVersion())
+	}
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Migrations of the data file %q from schema version %d to %d:",
+		rd.DataFile, rd.migrations[0].Version-1, rd.SchemaVersion))
+	for _, result := range rd.migrations {
+		lines = append(lines, fmt.Sprintf("  Version %d: %s (%d changes)", result.Version, result.Description, len(result.Changes)))
+		for _, change := range result.Changes {
+			lines = append(lines, fmt.Sprintf("    - %s", change))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// PersistMigrations persists the data upgraded by the applied migrations, if any.
+// A backup (see CreateBackup) of the data file is created before it is upgraded.
+func (rd *ReminderData) PersistMigrations() error {
+	if len(rd.migrations) == 0 || rd.readOnly {
+		return nil
+	}
+	backupFile, err := rd.CreateBackup()
+	if err != nil {
+		return fmt.Errorf("Unable to create backup before upgrading the data file: %w", err)
+	}
+	logger.Info(fmt.Sprintf("Created backup %q before upgrading the data file.", backupFile))
+	if err := rd.UpdateDataFile(fmt.Sprintf("Upgraded the data file to schema version %d.", rd.SchemaVersion)); err != nil {
+		return err
+	}
+	rd.migrations = nil
+	return nil
+}
@@ -0,0 +1,113 @@
+package model_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestMigrations(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = os.MkdirAll(path.Dir(dataFilePath), 0751)
+	// data file persisted before schema versions were introduced
+	oldData := `{"user": {}, "notes": [{"text": "1", "id": "existing-id"}, {"text": "2", "created_at": 1600000000}], "tags": [], "data_file": "` + dataFilePath + `", "updated_at": 1600000001}`
+	_ = os.WriteFile(dataFilePath, []byte(oldData), 0644)
+	// case 1 (the data is migrated in memory, and the data file is left as it is)
+	reminderData, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, reminderData.SchemaVersion, model.CurrentSchemaVersion())
+	utils.AssertEqual(t, reminderData.Notes[0].Id, "existing-id")
+	utils.AssertEqual(t, len(reminderData.Notes[1].Id), 36)
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()), 3)
+	utils.AssertEqual(t, reminderData.AppliedMigrations()[0].Changes, []string{`assigned id ` + reminderData.Notes[1].ShortId() + ` to note "2"`})
+	byteValue, _ := os.ReadFile(dataFilePath)
+	utils.AssertEqual(t, string(byteValue), oldData)
+	// the assigned ids are same across the reads
+	reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, reminderDataRe.Notes[1].Id, reminderData.Notes[1].Id)
+	// case 2 (the migrated data is persisted, after creating a backup)
+	utils.AssertEqual(t, reminderData.PersistMigrations(), nil)
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()), 0)
+	reminderDataRe, _ = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, reminderDataRe.SchemaVersion, model.CurrentSchemaVersion())
+	utils.AssertEqual(t, len(reminderDataRe.AppliedMigrations()), 0)
+	utils.AssertEqual(t, reminderDataRe.Notes[1].Id, reminderData.Notes[1].Id)
+	backups, _ := filepath.Glob("temp_test_dir/mydata_backup_*.json")
+	utils.AssertEqual(t, len(backups) > 0, true)
+	backupData, _ := os.ReadFile(backups[0])
+	utils.AssertEqual(t, string(backupData), oldData)
+	// case 3 (data file persisted by a newer version of the app)
+	_ = os.WriteFile(dataFilePath, []byte(`{"schema_version": 999}`), 0644)
+	_, err = model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, errors.Is(err, model.ErrorUnsupportedSchema), true)
+}
+
+func TestRecurrenceMigration(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = os.MkdirAll(path.Dir(dataFilePath), 0751)
+	// data file at schema version 1, with notes tagged with repeat tags
+	oldData := `{"schema_version": 1, "user": {}, "tags": [{"id": 0, "slug": "repeat-annually", "group": "repeat"}, {"id": 1, "slug": "repeat-monthly", "group": "repeat"}],
+		"notes": [{"id": "id-1", "text": "birthday", "tag_ids": [0], "complete_by": 1639526400}, {"id": "id-2", "text": "rent", "tag_ids": [1], "complete_by": 1639526400},
+		{"id": "id-3", "text": "no due date", "tag_ids": [1]}, {"id": "id-4", "text": "non-repeat", "tag_ids": [], "complete_by": 1639526400}],
+		"data_file": "` + dataFilePath + `", "updated_at": 1600000001}`
+	_ = os.WriteFile(dataFilePath, []byte(oldData), 0644)
+	reminderData, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()), 2)
+	utils.AssertEqual(t, reminderData.AppliedMigrations()[0].Changes, []string{`set recurrence FREQ=YEARLY of note id-1 "birthday"`, `set recurrence FREQ=MONTHLY of note id-2 "rent"`})
+	utils.AssertEqual(t, reminderData.Notes[0].Recurrence, "FREQ=YEARLY")
+	utils.AssertEqual(t, reminderData.Notes[1].Recurrence, "FREQ=MONTHLY")
+	utils.AssertEqual(t, reminderData.Notes[2].Recurrence, "")
+	utils.AssertEqual(t, reminderData.Notes[3].Recurrence, "")
+	// the repeat tags are left as they are
+	utils.AssertEqual(t, reminderData.Notes[1].TagIds, []int{1})
+}
+
+func TestDueDateTimezoneMigration(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = os.MkdirAll(path.Dir(dataFilePath), 0751)
+	defer func() { utils.Location = utils.UTCLocation() }()
+	utils.Location, _ = utils.LoadLocation("Asia/Kolkata")
+	// data file at schema version 2, with due dates at the start of the day in UTC
+	oldData := `{"schema_version": 2, "user": {}, "tags": [],
+		"notes": [{"id": "id-1", "text": "date only", "complete_by": 1639526400, "recurrence": "FREQ=DAILY", "completions": [{"due_date": 1639440000, "created_at": 1639450000}]},
+		{"id": "id-2", "text": "with time", "complete_by": 1639560600}, {"id": "id-3", "text": "no due date"},
+		{"id": "id-4", "text": "with timezone", "complete_by": 1639526400, "timezone": "UTC"}],
+		"data_file": "` + dataFilePath + `", "updated_at": 1600000001}`
+	_ = os.WriteFile(dataFilePath, []byte(oldData), 0644)
+	reminderData, err := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()), 1)
+	utils.AssertEqual(t, reminderData.AppliedMigrations()[0].Changes, []string{
+		`moved due date 15-Dec-21 of note id-1 "date only" to timezone Asia/Kolkata`,
+		`moved due date 15-Dec-21 15:00 of note id-2 "with time" to timezone Asia/Kolkata`,
+	})
+	// Wed Dec 15 2021 00:00:00 GMT+0530
+	utils.AssertEqual(t, reminderData.Notes[0].CompleteBy, 1639506600)
+	utils.AssertEqual(t, reminderData.Notes[0].TimeZone, "Asia/Kolkata")
+	utils.AssertEqual(t, reminderData.Notes[0].Completions[0].DueDate, 1639420200)
+	utils.AssertEqual(t, reminderData.Notes[1].CompleteBy, 1639560600)
+	utils.AssertEqual(t, reminderData.Notes[2].TimeZone, "")
+	utils.AssertEqual(t, reminderData.Notes[3].CompleteBy, 1639526400)
+}
+
+func TestNewDataFileSchemaVersion(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, reminderData.SchemaVersion, model.CurrentSchemaVersion())
+	utils.AssertEqual(t, len(reminderData.AppliedMigrations()), 0)
+}
@@ -3,13 +3,16 @@ package model
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/goyalmunish/reminder/pkg/calendar"
 	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/rrule"
 	"github.com/goyalmunish/reminder/pkg/utils"
-	gc "google.golang.org/api/calendar/v3"
 )
 
 /*
@@ -17,18 +20,39 @@ A Note represents a task.
 
 A note can be main or incidental.
 A note can be multiple tags, and a tag can be assocaited with mutiple notes.
+A note can recur as per its Recurrence, which is a recurrence rule (a subset of RFC 5545 RRULE,
+such as "FREQ=MONTHLY;BYDAY=-1FR") with its due date (CompleteBy) as the start of the recurrences.
+The due date is meant in the note's TimeZone, and a due date without time of day is at the start
+of the day in that timezone.
+A pending note with due date shows up under "Approaching Due Date" as per its due window (see DueWindow).
+A pending note can be snoozed until a certain time, until which it is hidden (see Snooze).
 */
 type Note struct {
+	// Id is persistent and collision-free (UUID) identifier of the note.
+	Id       string   `json:"id"`
 	Text     string   `json:"text"`
 	Comments Comments `json:"comments"`
 	Summary  string   `json:"summary"`
 	// Status can be "pending", "done", or "suspended".
 	// The "pending" status is special, and notes marked with it show up everywhere, whereas
 	// the nodes marked with other status show up only under "Search" or their dedicated menu.
-	Status      NoteStatus `json:"status"`
-	TagIds      []int      `json:"tag_ids"`
-	IsMain      bool       `json:"is_main"`
-	CompleteBy  int64      `json:"complete_by"`
+	Status     NoteStatus `json:"status"`
+	TagIds     []int      `json:"tag_ids"`
+	IsMain     bool       `json:"is_main"`
+	CompleteBy int64      `json:"complete_by"`
+	// TimeZone is the IANA timezone (such as "Asia/Kolkata") of the due date; if it is blank, the
+	// due date is meant in the timezone of the app (see utils.CurrentLocation).
+	TimeZone   string `json:"timezone"`
+	Recurrence string `json:"recurrence"`
+	// Completions records the completed occurrences of a recurring note.
+	Completions Completions `json:"completions,omitempty"`
+	// DeferUntil is the time until which the note is snoozed.
+	DeferUntil int64 `json:"defer_until,omitempty"`
+	// CalendarEventId is the id of the calendar event which the note is imported from.
+	CalendarEventId string `json:"calendar_event_id,omitempty"`
+	// NoSync keeps the note out of the calendar sync (see ReminderData.NotesToSync).
+	NoSync bool `json:"no_sync,omitempty"`
+	DueWindow
 	tempDueDate int64
 	BaseStruct
 }
@@ -45,6 +69,35 @@ const (
 	NoteStatus_Done NoteStatus = "done"
 )
 
+// ShortIdLength is the number of leading characters of note's Id which are
+// displayed to the user, and which is generally sufficient to identify a note.
+const ShortIdLength = 8
+
+// NewNoteId returns a new unique identifier for a note.
+func NewNoteId() string {
+	return uuid.New().String()
+}
+
+// legacyNoteId returns the Id derived from note's creation time and text, for the
+// notes persisted before ids were introduced.
+// The duplicate (a note with same creation time and text as an earlier one) is told apart by
+// its occurrence, which is mixed into the Id if it is more than 0.
+func legacyNoteId(note *Note, occurrence int) string {
+	name := fmt.Sprintf("%d|%s", note.CreatedAt, note.Text)
+	if occurrence > 0 {
+		name = fmt.Sprintf("%s|%d", name, occurrence)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// ShortId returns the leading characters of note's Id, for display purpose.
+func (note *Note) ShortId() string {
+	if len(note.Id) <= ShortIdLength {
+		return note.Id
+	}
+	return note.Id[:ShortIdLength]
+}
+
 // Type returns type of the note: main or incidental.
 func (note *Note) Type() string {
 	if note.IsMain {
@@ -65,9 +118,11 @@ func (note *Note) Strings() ([]string, error) {
 	strs = append(strs, printNoteField("Status", note.Status))
 	strs = append(strs, printNoteField("Tags", note.TagIds))
 	strs = append(strs, printNoteField("IsMain", note.IsMain))
-	strs = append(strs, printNoteField("CompleteBy", utils.UnixTimestampToLongTimeStr(note.CompleteBy)))
+	strs = append(strs, printNoteField("CompleteBy", utils.UnixTimestampToTimeStrInLocation(note.CompleteBy, time.RFC850, note.Location())))
+	strs = append(strs, printNoteField("Recurrence", note.Recurrence))
 	strs = append(strs, printNoteField("CreatedAt", utils.UnixTimestampToLongTimeStr(note.CreatedAt)))
 	strs = append(strs, printNoteField("UpdatedAt", utils.UnixTimestampToLongTimeStr(note.UpdatedAt)))
+	strs = append(strs, printNoteField("Id", note.Id))
 	return strs, nil
 }
 
@@ -89,25 +144,35 @@ func (note *Note) externalText(tagger Tagger) ([]string, error) {
 
 // ExternalText prints a note with its tags slugs.
 // This is used as final external reprensentation for display of a single note.
+// It also tells the note's due window (if set), and why the note shows up under "Approaching Due Date" (if it does).
 func (note *Note) ExternalText(reminderData *ReminderData) (string, error) {
 	strs, err := note.externalText(reminderData)
 	if err != nil {
 		return "", err
 	}
+	if note.LeadDays != nil || note.GraceDays != nil {
+		strs = append(strs, printNoteField("DueWindow", formatDueWindow(note.DueWindow)))
+	}
+	if note.IsSnoozed() {
+		strs = append(strs, printNoteField("SnoozedTill", note.DueDateStr(note.DeferUntil)))
+	}
+	if note.NoSync {
+		strs = append(strs, printNoteField("CalendarSync", "off"))
+	}
+	if reason := reminderData.VisibilityReason(note); reason != "" {
+		strs = append(strs, printNoteField("Visible", reason))
+	}
 	return strings.Join(strs, ""), nil
 }
 
-// SafeExtText prints a note with its tags slugs, but only the safe components.
+// SafeExtText prints a note with its tags slugs, but only the components allowed by the redaction
+// (or with nil, by the default redaction; see RedactionOptions).
 // This is used as final external reprensentation for display of a single note to external services like Google Calendar.
-func (note *Note) SafeExtText(tagger Tagger) (string, error) {
-	strs, err := note.externalText(tagger)
-	if err != nil {
-		return "", err
+func (note *Note) SafeExtText(tagger Tagger, redaction *Redaction) (string, error) {
+	if redaction == nil {
+		redaction = defaultRedaction()
 	}
-	// leaving out the comments, as they may contain sensitive information
-	commentIndex := 2
-	strs = append(strs[:commentIndex], strs[commentIndex+1:]...)
-	return strings.Join(strs, ""), nil
+	return redaction.Description(note, tagger)
 }
 
 // SearchableText provides string representation of the object.
@@ -162,12 +227,16 @@ func (note *Note) UpdateTags(tagIDs []int) error {
 }
 
 // UpdateStatus updates note's status ("done"/"pending").
-// Status of a note tag with repeat tag cannot be mared as "done".
+// Status of a note tag with repeat tag (or a note with recurrence) cannot be mared as "done";
+// instead, its occurrence is to be completed (see Complete).
 func (note *Note) UpdateStatus(status NoteStatus, repeatTagIDs []int) error {
 	noteIDsWithRepeat := utils.GetCommonMembersOfSlices(note.TagIds, repeatTagIDs)
-	if len(noteIDsWithRepeat) != 0 {
+	if (status == NoteStatus_Done) && (len(noteIDsWithRepeat) != 0) {
 		return errors.New("Note is part of a \"repeat\" group")
 	}
+	if (status == NoteStatus_Done) && (note.Recurrence != "") {
+		return errors.New("Note is recurring; complete its occurrence instead")
+	}
 	if note.Status == status {
 		return errors.New("Desired status is same as existing one")
 	}
@@ -213,8 +282,10 @@ func (note *Note) UpdateSummary(text string) error {
 }
 
 // UpdateCompleteBy updates note's due date.
-// The input is of the form DD-MM-YYYY or just DD-MM (with implicity value for year; either current or next).
-// If input is "nil", the existing due date is cleared.
+// The input is of the form DD-MM-YYYY or just DD-MM (with implicity value for year; either current or next),
+// optionally followed by time of day (HH:MM) and timezone (such as "Asia/Kolkata"); see utils.ParseDueDate.
+// The timezone in which the due date is taken is remembered as the note's timezone.
+// If input is "nil", the existing due date is cleared (along with the recurrence, which starts from it).
 func (note *Note) UpdateCompleteBy(text string) error {
 	// handle edge-case of empty text
 	if len(strings.TrimSpace(text)) == 0 {
@@ -223,22 +294,17 @@ func (note *Note) UpdateCompleteBy(text string) error {
 	// happy path
 	if text == "nil" {
 		note.CompleteBy = 0
+		note.TimeZone = ""
+		note.Recurrence = ""
 		defer logger.Info(fmt.Sprintln("Cleared the due date from the note."))
 	} else {
-		format := "2-1-2006"
-		// set current year as year if year part is missing
-		timeSplit := strings.Split(text, "-")
-		if len(timeSplit) == 2 {
-			year, err := utils.YearForDueDateDDMM(text)
-			if err != nil {
-				return err
-			}
-			text = fmt.Sprintf("%s-%d", text, year)
+		// note: the due date is taken in the note's timezone, unless the timezone is given
+		timeValue, err := utils.ParseDueDate(text, note.Location())
+		if err != nil {
+			return err
 		}
-		// parse and set the date
-		// note: this time value that date/month/year in 00:00:00 GMT+0000
-		timeValue, _ := time.Parse(format, text)
-		note.CompleteBy = int64(timeValue.Unix())
+		note.CompleteBy = timeValue.Unix()
+		note.TimeZone = timeZoneName(timeValue.Location())
 		defer logger.Info(fmt.Sprintln("Updated the note with new due date."))
 	}
 	// update the UpdatedAt as well
@@ -246,16 +312,259 @@ func (note *Note) UpdateCompleteBy(text string) error {
 	return nil
 }
 
-// RepeatType return - (Not-repeat), A (Annual-Repeat), or M (Monthly-Repeat) string
-// representing repeat-type of the note
-func (note *Note) RepeatType(repeatAnnuallyTagId int, repeatMonthlyTagId int) string {
-	repeat := "-" // non-repeat
+// Location returns the location of the note's timezone.
+func (note *Note) Location() *time.Location {
+	if note.TimeZone != "" {
+		if location, err := time.LoadLocation(note.TimeZone); err == nil {
+			return location
+		}
+	}
+	return utils.CurrentLocation()
+}
+
+// DueDateStr returns short representation of the given due date (such as the note's CompleteBy, or
+// one of its occurrences) in the note's timezone, along with its time of day (if any).
+// The timezone is mentioned along with the time of day, only if it differs from the timezone of the app.
+func (note *Note) DueDateStr(unixTimestamp int64) string {
+	if unixTimestamp <= 0 {
+		return "nil"
+	}
+	location := note.Location()
+	t := time.Unix(unixTimestamp, 0).In(location)
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format("02-Jan-06")
+	}
+	if timeZoneName(location) == timeZoneName(utils.CurrentLocation()) {
+		return t.Format("02-Jan-06 15:04")
+	}
+	return t.Format("02-Jan-06 15:04 MST")
+}
+
+// snoozeDurationRegex matches the snooze durations such as "3h", "2 days" or "1 week".
+var snoozeDurationRegex = regexp.MustCompile(`^(\d{1,4}) ?(h|hours?|d|days?|w|weeks?)$`)
+
+// Snooze snoozes the note, so that it is hidden (from "Approaching Due Date", "Main Notes" and
+// the notes of its tags) until the given time, after which it shows up again by itself.
+// The input is either a duration from now (such as "3h", "2 days" or "1 week"), or a date (such
+// as "15-11" or "tomorrow at 09:00", see utils.ParseDueDate) optionally preceded by "until".
+// If input is "nil", the note is woken up.
+func (note *Note) Snooze(text string) error {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if text == "" {
+		return errors.New("Note's snooze time is empty")
+	}
+	if text == "nil" {
+		note.DeferUntil = 0
+		defer logger.Info(fmt.Sprintln("Woke up the note."))
+		note.UpdatedAt = utils.CurrentUnixTimestamp()
+		return nil
+	}
+	currentTime := utils.CurrentTime().In(note.Location())
+	var deferUntil time.Time
+	if match := snoozeDurationRegex.FindStringSubmatch(strings.TrimPrefix(text, "for ")); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2][0] {
+		case 'h':
+			deferUntil = currentTime.Add(time.Duration(n) * time.Hour)
+		case 'd':
+			deferUntil = currentTime.AddDate(0, 0, n)
+		default:
+			deferUntil = currentTime.AddDate(0, 0, 7*n)
+		}
+	} else {
+		var err error
+		deferUntil, err = utils.ParseDueDate(strings.TrimPrefix(text, "until "), note.Location())
+		if err != nil {
+			return err
+		}
+	}
+	if !deferUntil.After(currentTime) {
+		return fmt.Errorf("Snooze time %s is already past", utils.DueDateToPreviewStr(deferUntil))
+	}
+	note.DeferUntil = deferUntil.Unix()
+	defer logger.Info(fmt.Sprintln("Snoozed the note."))
+	// update the UpdatedAt as well
+	note.UpdatedAt = utils.CurrentUnixTimestamp()
+	return nil
+}
+
+// IsSnoozed tells if the note is snoozed at the current time.
+func (note *Note) IsSnoozed() bool {
+	return note.DeferUntil > utils.CurrentUnixTimestamp()
+}
+
+// hasTimeOfDay tells if the note's due date has a time of day, that is, if it isn't at the start of the day.
+func (note *Note) hasTimeOfDay() bool {
+	t := time.Unix(note.CompleteBy, 0).In(note.Location())
+	return t.Hour() != 0 || t.Minute() != 0
+}
+
+// timeZoneName returns IANA name of the location, or blank string for the local timezone of the system.
+func timeZoneName(location *time.Location) string {
+	if location == time.Local {
+		return ""
+	}
+	return location.String()
+}
+
+// UpdateRecurrence updates note's recurrence rule (such as "FREQ=WEEKLY;BYDAY=MO,TH").
+// The recurrences start from the note's due date, and so the note must have a due date.
+// If input is "nil", the existing recurrence is cleared.
+func (note *Note) UpdateRecurrence(text string) error {
+	// handle edge-case of empty text
+	if len(strings.TrimSpace(text)) == 0 {
+		return errors.New("Note's recurrence is empty")
+	}
+	// happy path
+	if text == "nil" {
+		note.Recurrence = ""
+		defer logger.Info(fmt.Sprintln("Cleared the recurrence from the note."))
+	} else {
+		if note.CompleteBy == 0 {
+			return errors.New("Note's due date is required for its recurrence")
+		}
+		rule, err := rrule.Parse(text)
+		if err != nil {
+			return err
+		}
+		note.Recurrence = rule.String()
+		defer logger.Info(fmt.Sprintln("Updated the note with new recurrence."))
+	}
+	// update the UpdatedAt as well
+	note.UpdatedAt = utils.CurrentUnixTimestamp()
+	return nil
+}
+
+// RecurrenceRule returns the recurrence rule of the note, or nil if the note doesn't recur.
+// For a note without its own recurrence rule, the "repeat-annually" and "repeat-monthly"
+// tags (with given ids) work as shorthands for the yearly and monthly recurrences.
+func (note *Note) RecurrenceRule(repeatAnnuallyTagId int, repeatMonthlyTagId int) *rrule.Rule {
+	if note.Recurrence != "" {
+		rule, err := rrule.Parse(note.Recurrence)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Ignoring recurrence of the note %q: %v", note.Text, err))
+			return nil
+		}
+		return rule
+	}
 	if utils.IsMemberOfSlice(repeatAnnuallyTagId, note.TagIds) {
-		repeat = "A"
-	} else if utils.IsMemberOfSlice(repeatMonthlyTagId, note.TagIds) {
-		repeat = "M"
+		return &rrule.Rule{Freq: rrule.Yearly, Interval: 1}
+	}
+	if utils.IsMemberOfSlice(repeatMonthlyTagId, note.TagIds) {
+		return &rrule.Rule{Freq: rrule.Monthly, Interval: 1}
+	}
+	return nil
+}
+
+// RepeatType return - (Not-repeat), D (Daily-Repeat), W (Weekly-Repeat), M (Monthly-Repeat),
+// or A (Annual-Repeat) string representing repeat-type of the note
+func (note *Note) RepeatType(repeatAnnuallyTagId int, repeatMonthlyTagId int) string {
+	rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+	if rule == nil {
+		return "-" // non-repeat
+	}
+	switch rule.Freq {
+	case rrule.Daily:
+		return "D"
+	case rrule.Weekly:
+		return "W"
+	case rrule.Monthly:
+		return "M"
+	}
+	return "A"
+}
+
+/*
+Complete marks the current occurrence of the recurring note (with the given rule) as done.
+
+The completion is recorded (along with the optional comment), and the due date is rolled
+forward to the next occurrence; the note is marked as "done" if there is no next occurrence.
+The current occurrence is the upcoming one if it has already started showing up as
+approaching (that is, within the lead days of the given window), or else the last one which is due.
+*/
+func (note *Note) Complete(rule *rrule.Rule, window WindowDays, comment string) (*Completion, error) {
+	if rule == nil {
+		return nil, errors.New("Note is not recurring")
+	}
+	if note.CompleteBy == 0 {
+		return nil, errors.New("Note's due date is required for its recurrence")
+	}
+	// note: the occurrences are expanded in the note's timezone, so as to keep their time of day across DST changes
+	dtstart := time.Unix(note.CompleteBy, 0).In(note.Location())
+	currentTime := utils.CurrentTime().In(note.Location())
+	// find the current occurrence
+	next, hasNext := rule.After(dtstart, currentTime, false)
+	previous, hasPrevious := rule.Before(dtstart, currentTime, true)
+	var current time.Time
+	switch {
+	case hasNext && !next.After(currentTime.AddDate(0, 0, window.LeadDays)):
+		current = next
+	case hasPrevious:
+		current = previous
+	case hasNext:
+		current = next
+	default:
+		return nil, fmt.Errorf("Recurrence %q of the note has no occurrence", rule)
+	}
+	// record the completion (which also ends the snooze, if any)
+	note.DeferUntil = 0
+	completion := &Completion{DueDate: current.Unix(), Comment: strings.TrimSpace(comment), BaseStruct: BaseStruct{CreatedAt: currentTime.Unix()}}
+	note.Completions = append(note.Completions, completion)
+	// roll forward to the next occurrence
+	following, ok := rule.After(dtstart, current, false)
+	if !ok {
+		note.Status = NoteStatus_Done
+		defer logger.Info(fmt.Sprintln("Completed the last occurrence of the note."))
+	} else {
+		if rule.Count > 0 {
+			// the occurrences are counted from the new due date onwards
+			remaining := *rule
+			remaining.Count = rule.Count - len(rule.Between(dtstart, dtstart, current))
+			note.Recurrence = remaining.String()
+		}
+		note.CompleteBy = following.Unix()
+		defer logger.Info(fmt.Sprintln("Completed the occurrence of the note."))
 	}
-	return repeat
+	// update the UpdatedAt as well
+	note.UpdatedAt = currentTime.Unix()
+	return completion, nil
+}
+
+// Streaks returns the current and the longest streaks (counts of consecutive completed occurrences)
+// of the recurring note with the given rule.
+// The current streak is broken if the occurrence after the last completed one is already past
+// the grace days of the given window.
+func (note *Note) Streaks(rule *rrule.Rule, window WindowDays) (int, int) {
+	completions := note.Completions.sorted()
+	if rule == nil || len(completions) == 0 {
+		return 0, 0
+	}
+	// note: the occurrences are expanded from a completed one, disregarding the end of the recurrence
+	series := *rule
+	series.Count = 0
+	series.Until = time.Time{}
+	current, longest := 1, 1
+	for i := 1; i < len(completions); i++ {
+		if completions[i].DueDate == completions[i-1].DueDate {
+			// the same occurrence completed again
+			continue
+		}
+		dtstart := time.Unix(completions[i-1].DueDate, 0).In(note.Location())
+		next, ok := series.After(dtstart, dtstart, false)
+		if ok && next.Unix() == completions[i].DueDate {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	dueTime := time.Unix(note.CompleteBy, 0).In(note.Location())
+	if note.Status == NoteStatus_Pending && utils.CurrentTime().After(dueTime.AddDate(0, 0, window.GraceDays)) {
+		current = 0
+	}
+	return current, longest
 }
 
 // ToggleMainFlag toggles note's main flag.
@@ -267,19 +576,54 @@ func (note *Note) ToggleMainFlag() error {
 	return nil
 }
 
-// GoogleCalendarEvent converts a note to Google Calendar Event.
-func (note *Note) GoogleCalendarEvent(repeatAnnuallyTagId int, repeatMonthlyTagId int, timezoneIANA string, tagger Tagger) (*gc.Event, error) {
+// SetNoSync sets (or unsets) the flag which keeps the note out of the calendar sync.
+func (note *Note) SetNoSync(noSync bool) error {
+	note.NoSync = noSync
+	defer logger.Info(fmt.Sprintf("Set the note's no-sync flag to %v.", noSync))
+	// update the UpdatedAt as well
+	note.UpdatedAt = utils.CurrentUnixTimestamp()
+	return nil
+}
+
+// CalendarEvent converts a note to calendar event (see calendar.Event), with the appearance and the
+// reminders as per the options (or else as per calendar.DefaultEventOptions).
+func (note *Note) CalendarEvent(repeatAnnuallyTagId int, repeatMonthlyTagId int, timezoneIANA string, tagger Tagger, opts *calendar.EventOptions, redaction *Redaction) (*calendar.Event, error) {
+	if opts == nil {
+		opts = calendar.DefaultEventOptions()
+	}
+	if redaction == nil {
+		redaction = defaultRedaction()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	duration := opts.Duration
+	if duration <= 0 {
+		duration = calendar.DefaultEventOptions().Duration
+	}
+
 	// basic information
-	title := note.Text
-	start := utils.UnixTimestampToTime(note.CompleteBy) // this is the original time in 00:00:00 GMT+0000
-	offset, err := utils.GetZoneFromLocation(timezoneIANA)
-	if err != nil {
-		return nil, fmt.Errorf("Couldn't calculate offset for timezone %q; %w", timezoneIANA, err)
+	title := redaction.Title(note, tagger)
+	location := note.Location()
+	start := time.Unix(note.CompleteBy, 0).In(location)
+	if !note.hasTimeOfDay() {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 10, 0, 0, 0, location) // set notification for 10 AM of the due date
 	}
-	start = start.Add(offset)          // adjusting the start to local time for notification purpose
-	start = start.Add(-14 * time.Hour) // set notification for 10 AM of given timezoneIANA
-	repeatType := note.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId)
-	description, err := note.SafeExtText(tagger)
+	rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+	if rule != nil {
+		// start from the first occurrence, as the due date itself may not be one
+		first, ok := rule.After(start, start, true)
+		if !ok {
+			return nil, fmt.Errorf("Recurrence %q of the note %q has no occurrence", rule, note.Text)
+		}
+		start = first
+	}
+	// note: the event is in the note's timezone, falling back to the timezone of the calendar
+	timeZone := timeZoneName(location)
+	if timeZone == "" {
+		timeZone = timezoneIANA
+	}
+	description, err := note.SafeExtText(tagger, redaction)
 	if err != nil {
 		return nil, err
 	}
@@ -287,50 +631,38 @@ func (note *Note) GoogleCalendarEvent(repeatAnnuallyTagId int, repeatMonthlyTagI
 	// lego the information
 	var recurrence []string
 	title = fmt.Sprintf("%s%s", calendar.TitlePrefix, title)
-	startRFC3339 := &gc.EventDateTime{
+	startRFC3339 := &calendar.EventTime{
 		DateTime: start.Format(time.RFC3339),
-		TimeZone: timezoneIANA,
+		TimeZone: timeZone,
 	}
-	endRFC3339 := &gc.EventDateTime{
-		DateTime: start.Add(time.Duration(30 * time.Minute)).Format(time.RFC3339), // keeping the event for duration of only 30 mins
-		TimeZone: timezoneIANA,
+	endRFC3339 := &calendar.EventTime{
+		DateTime: start.Add(time.Duration(duration) * time.Minute).Format(time.RFC3339),
+		TimeZone: timeZone,
 	}
-	source := &gc.EventSource{
-		Title: "reminder",
-		Url:   "https://github.com/goyalmunish/reminder",
+	if opts.AllDay {
+		// note: the end of an all-day event is the (exclusive) next day
+		startRFC3339 = &calendar.EventTime{Date: start.Format("2006-01-02")}
+		endRFC3339 = &calendar.EventTime{Date: start.AddDate(0, 0, 1).Format("2006-01-02")}
 	}
-	rem := &gc.EventReminders{
-		Overrides:  []*gc.EventReminder{},
-		UseDefault: true,
-	}
-	// Refer https://developers.google.com/calendar/api/concepts/events-calendars
-	switch repeatType {
-	case "-":
-		recurrence = []string{}
-	case "A":
-		recurrence = []string{"RRULE:FREQ=YEARLY"}
-	case "M":
-		recurrence = []string{"RRULE:FREQ=MONTHLY"}
+	if rule != nil {
+		recurrence = []string{"RRULE:" + rule.String()}
 	}
 
 	// construct the event
-	event := &gc.Event{
-		// ICalUID
-		// Id
-		// Created
-		// Updated
-		Summary:      title,
-		Description:  description,
-		Start:        startRFC3339,
-		End:          endRFC3339,
-		Recurrence:   recurrence,
-		ColorId:      "10", // "Basil" color
-		Reminders:    rem,
-		EventType:    "default",
-		Source:       source,
-		Status:       "confirmed",
-		Transparency: "transparent",
-		Visibility:   "default",
+	event := &calendar.Event{
+		Summary:     title,
+		Description: description,
+		Start:       startRFC3339,
+		End:         endRFC3339,
+		Recurrence:  recurrence,
+		Status:      "confirmed",
+		Color:       opts.ColorFor(tagger.TagsFromIds(note.TagIds)),
+		Busy:        opts.Busy,
+		Reminders:   opts.Reminders,
+	}
+	// tag the event with the note's identity, so that it can be matched with the note while syncing
+	if err := calendar.TagEvent(event, note.Id); err != nil {
+		return nil, err
 	}
 	return event, nil
 }
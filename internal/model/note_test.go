@@ -4,10 +4,12 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/rrule"
 	"github.com/goyalmunish/reminder/pkg/utils"
-	gc "google.golang.org/api/calendar/v3"
 )
 
 func TestNoteStrings(t *testing.T) {
@@ -29,8 +31,10 @@ func TestNoteStrings(t *testing.T) {
    |          Tags:  [1 2]
    |        IsMain:  false
    |    CompleteBy:  Sunday, 03-Jan-21 10:20:35 UTC
+   |    Recurrence:  
    |     CreatedAt:  nil
    |     UpdatedAt:  nil
+   |            Id:  
 ]`
 	text, _ := note.Strings()
 	utils.AssertEqual(t, text, want)
@@ -58,8 +62,11 @@ func TestNoteExternalText(t *testing.T) {
   |              :  tag_2
   |        IsMain:  false
   |    CompleteBy:  Sunday, 03-Jan-21 10:20:35 UTC
+  |    Recurrence:  
   |     CreatedAt:  nil
   |     UpdatedAt:  nil
+  |            Id:  
+  |       Visible:  overdue since 03-Jan-21 10:20 (until it is marked as done)
 `
 	text, _ := note.ExternalText(reminderData)
 	utils.AssertEqual(t, text, want)
@@ -83,13 +90,24 @@ func TestNoteSafeExtText(t *testing.T) {
   |              :  tag_2
   |        IsMain:  false
   |    CompleteBy:  Sunday, 03-Jan-21 10:20:35 UTC
+  |    Recurrence:  
   |     CreatedAt:  nil
   |     UpdatedAt:  nil
+  |            Id:  
 `
-	text, _ := note.SafeExtText(reminderData)
+	text, _ := note.SafeExtText(reminderData, nil)
 	utils.AssertEqual(t, text, want)
 }
 
+func TestNoteShortId(t *testing.T) {
+	note := model.Note{Id: "3f2a9c1e-7b4d-4e2a-9c1e-7b4d4e2a9c1e"}
+	utils.AssertEqual(t, note.ShortId(), "3f2a9c1e")
+	note = model.Note{Id: "3f2a"}
+	utils.AssertEqual(t, note.ShortId(), "3f2a")
+	note = model.Note{}
+	utils.AssertEqual(t, note.ShortId(), "")
+}
+
 func TestNoteSearchableText(t *testing.T) {
 	// case 1
 	comments := model.Comments{&model.Comment{Text: "c1"}}
@@ -171,6 +189,10 @@ func TestNoteUpdateStatus(t *testing.T) {
 	err = note1.UpdateStatus(model.NoteStatus_Pending, []int{5, 6, 7})
 	utils.AssertEqual(t, err, nil)
 	utils.AssertEqual(t, note1.Status, model.NoteStatus_Pending)
+	// case 4 (a note of "repeat" group can still be suspended)
+	err = note1.UpdateStatus(model.NoteStatus_Suspended, []int{1, 2, 3})
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note1.Status, model.NoteStatus_Suspended)
 }
 
 func TestNoteUpdateText(t *testing.T) {
@@ -202,6 +224,7 @@ func TestNoteUpdateSummary(t *testing.T) {
 }
 
 func TestNoteUpdateCompleteBy(t *testing.T) {
+	utils.Location = utils.UTCLocation()
 	// create notes
 	note1 := model.Note{Text: "original text", Status: model.NoteStatus_Pending, TagIds: []int{1, 4}, BaseStruct: model.BaseStruct{UpdatedAt: 1600000001}}
 	utils.AssertEqual(t, note1.CompleteBy, 0)
@@ -214,10 +237,160 @@ func TestNoteUpdateCompleteBy(t *testing.T) {
 	err = note1.UpdateCompleteBy("31-12-2022")
 	utils.AssertEqual(t, err, nil)
 	utils.AssertEqual(t, note1.CompleteBy, 1672444800) // Sat Dec 31 2022 00:00:00 GMT+0000
-	// case 3
+	utils.AssertEqual(t, note1.TimeZone, "UTC")
+	// case 3 (with time of day and timezone)
+	err = note1.UpdateCompleteBy("15-12-2021 09:30 Asia/Kolkata")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note1.CompleteBy, 1639540800) // Wed Dec 15 2021 09:30:00 GMT+0530
+	utils.AssertEqual(t, note1.TimeZone, "Asia/Kolkata")
+	utils.AssertEqual(t, note1.DueDateStr(note1.CompleteBy), "15-Dec-21 09:30 IST")
+	// case 4 (the note's timezone is kept)
+	err = note1.UpdateCompleteBy("16-12-2021")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note1.CompleteBy, 1639593000) // Thu Dec 16 2021 00:00:00 GMT+0530
+	utils.AssertEqual(t, note1.DueDateStr(note1.CompleteBy), "16-Dec-21")
+	// case 5 (invalid date)
+	err = note1.UpdateCompleteBy("31-02-2022")
+	utils.AssertEqual(t, err != nil, true)
+	utils.AssertEqual(t, note1.CompleteBy, 1639593000)
+	// case 6
 	err = note1.UpdateCompleteBy("nil")
 	utils.AssertEqual(t, err, nil)
 	utils.AssertEqual(t, note1.CompleteBy, 0)
+	utils.AssertEqual(t, note1.TimeZone, "")
+}
+
+func TestNoteSnooze(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 15:04:05 GMT+0000
+	currentTime := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+	utils.CurrentTime = func() time.Time { return currentTime }
+	note := model.Note{Text: "renew the passport", Status: model.NoteStatus_Pending}
+	var tests = []struct {
+		text string
+		want time.Time
+	}{
+		{"3h", currentTime.Add(3 * time.Hour)},
+		{"2 days", currentTime.AddDate(0, 0, 2)},
+		{"for 1 week", currentTime.AddDate(0, 0, 7)},
+		{"until 15-11", time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC)},
+		{"Until tomorrow at 09:00", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		err := note.Snooze(test.text)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, note.DeferUntil, test.want.Unix())
+		utils.AssertEqual(t, note.IsSnoozed(), true)
+	}
+	// the snooze must end in future
+	err := note.Snooze("until today")
+	utils.AssertEqual(t, err, errors.New("Snooze time Fri, 16 Oct 2026 is already past"))
+	_ = note.Snooze("someday")
+	utils.AssertEqual(t, note.IsSnoozed(), true)
+	// wake up the note
+	_ = note.Snooze("nil")
+	utils.AssertEqual(t, note.DeferUntil, int64(0))
+	utils.AssertEqual(t, note.IsSnoozed(), false)
+}
+
+func TestNoteUpdateRecurrence(t *testing.T) {
+	note1 := model.Note{Text: "original text", Status: model.NoteStatus_Pending, BaseStruct: model.BaseStruct{UpdatedAt: 1600000001}}
+	// case 1 (the due date is required)
+	err := note1.UpdateRecurrence("FREQ=WEEKLY")
+	utils.AssertEqual(t, err, errors.New("Note's due date is required for its recurrence"))
+	// case 2 (the rule is saved in its canonical form)
+	_ = note1.UpdateCompleteBy("15-12-2021")
+	err = note1.UpdateRecurrence("rrule:freq=monthly;byday=-1fr;interval=1")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note1.Recurrence, "FREQ=MONTHLY;BYDAY=-1FR")
+	// case 3 (invalid rule)
+	err = note1.UpdateRecurrence("FREQ=HOURLY")
+	utils.AssertEqual(t, errors.Is(err, rrule.ErrorInvalidRule), true)
+	utils.AssertEqual(t, note1.Recurrence, "FREQ=MONTHLY;BYDAY=-1FR")
+	// case 4 (a recurring note can't be marked as done)
+	err = note1.UpdateStatus(model.NoteStatus_Done, []int{})
+	utils.AssertEqual(t, err, errors.New("Note is recurring; complete its occurrence instead"))
+	// case 5 (clearing the due date clears the recurrence as well)
+	err = note1.UpdateRecurrence("nil")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note1.Recurrence, "")
+	_ = note1.UpdateRecurrence("FREQ=DAILY")
+	_ = note1.UpdateCompleteBy("nil")
+	utils.AssertEqual(t, note1.Recurrence, "")
+}
+
+func TestNoteComplete(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	setCurrentTime := func(text string) {
+		currentTime, _ := time.Parse(time.RFC3339, text)
+		utils.CurrentTime = func() time.Time { return currentTime }
+	}
+	// weekly on Mondays and Thursdays, starting on Thu Jan 01 2026, for 5 occurrences
+	note := model.Note{Text: "water the plants", Status: model.NoteStatus_Pending, CompleteBy: 1767225600, Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=5"}
+	rule, _ := rrule.Parse(note.Recurrence)
+	window := model.WindowDays{LeadDays: 1, GraceDays: 2}
+	// case 1 (completed a day late)
+	setCurrentTime("2026-01-02T09:00:00Z")
+	completion, err := note.Complete(rule, window, " done ")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, completion.DueDate, int64(1767225600))
+	utils.AssertEqual(t, completion.Comment, "done")
+	utils.AssertEqual(t, note.CompleteBy, int64(1767571200)) // Mon Jan 05 2026
+	utils.AssertEqual(t, note.Recurrence, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4")
+	utils.AssertEqual(t, note.Status, model.NoteStatus_Pending)
+	// case 2 (completed a day early)
+	setCurrentTime("2026-01-04T09:00:00Z")
+	rule, _ = rrule.Parse(note.Recurrence)
+	completion, _ = note.Complete(rule, window, "")
+	utils.AssertEqual(t, completion.DueDate, int64(1767571200))
+	utils.AssertEqual(t, note.CompleteBy, int64(1767830400)) // Thu Jan 08 2026
+	// case 3 (the last occurrence marks the note as done; Jan 08 and Jan 12 are missed)
+	setCurrentTime("2026-01-15T09:00:00Z")
+	rule, _ = rrule.Parse(note.Recurrence)
+	completion, _ = note.Complete(rule, window, "")
+	utils.AssertEqual(t, completion.DueDate, int64(1768435200)) // Thu Jan 15 2026
+	utils.AssertEqual(t, note.Status, model.NoteStatus_Done)
+	utils.AssertEqual(t, len(note.Completions), 3)
+	// case 4 (a non-recurring note)
+	_, err = note.Complete(nil, window, "")
+	utils.AssertEqual(t, err, errors.New("Note is not recurring"))
+}
+
+func TestNoteCompleteAcrossDST(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	// weekly at 09:00 in New York, starting on Sun Mar 01 2026 (a week before the DST starts)
+	note := model.Note{Text: "call home", Status: model.NoteStatus_Pending, CompleteBy: 1772373600, TimeZone: "America/New_York", Recurrence: "FREQ=WEEKLY"}
+	rule, _ := rrule.Parse(note.Recurrence)
+	utils.CurrentTime = func() time.Time { return time.Unix(1772373600+3600, 0) }
+	_, err := note.Complete(rule, model.WindowDays{LeadDays: 1, GraceDays: 2}, "")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, note.CompleteBy, int64(1772974800)) // Sun Mar 08 2026 09:00:00 GMT-0400
+}
+
+func TestNoteStreaks(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	rule, _ := rrule.Parse("FREQ=DAILY")
+	day := int64(24 * 60 * 60)
+	start := int64(1767225600) // Thu Jan 01 2026
+	note := model.Note{Text: "exercise", Status: model.NoteStatus_Pending, CompleteBy: start + 6*day}
+	// completed on 1st, 2nd, 3rd (twice), and then on 5th and 6th
+	for _, offset := range []int64{0, 1, 2, 2, 4, 5} {
+		note.Completions = append(note.Completions, &model.Completion{DueDate: start + offset*day, BaseStruct: model.BaseStruct{CreatedAt: start + offset*day}})
+	}
+	window := model.WindowDays{LeadDays: 0, GraceDays: 1}
+	utils.CurrentTime = func() time.Time { return time.Unix(start+6*day, 0) }
+	current, longest := note.Streaks(rule, window)
+	utils.AssertEqual(t, current, 2)
+	utils.AssertEqual(t, longest, 3)
+	// the streak is broken once the next occurrence is missed
+	utils.CurrentTime = func() time.Time { return time.Unix(start+8*day, 0) }
+	current, longest = note.Streaks(rule, window)
+	utils.AssertEqual(t, current, 0)
+	utils.AssertEqual(t, longest, 3)
+	// unless it is still within the grace period
+	current, _ = note.Streaks(rule, model.WindowDays{GraceDays: 3})
+	utils.AssertEqual(t, current, 2)
 }
 
 func TestNoteRepeatType(t *testing.T) {
@@ -232,6 +405,11 @@ func TestNoteRepeatType(t *testing.T) {
 	utils.AssertEqual(t, note2.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), "A")
 	utils.AssertEqual(t, note3.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), "-")
 	utils.AssertEqual(t, note3.RepeatType(0, 0), "-")
+	// the recurrence rule takes precedence over the repeat tags
+	note4 := model.Note{Text: "original text4", Status: model.NoteStatus_Pending, TagIds: []int{3}, Recurrence: "FREQ=WEEKLY;BYDAY=MO"}
+	utils.AssertEqual(t, note4.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), "W")
+	note4.Recurrence = "FREQ=DAILY;COUNT=3"
+	utils.AssertEqual(t, note4.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), "D")
 }
 
 func TestNoteToggleMainFlag(t *testing.T) {
@@ -250,7 +428,7 @@ func TestNoteToggleMainFlag(t *testing.T) {
 	utils.AssertEqual(t, originalPriority != note1.IsMain, true)
 }
 
-func TestGoogleCalendarEvent(t *testing.T) {
+func TestCalendarEvent(t *testing.T) {
 	tagger := TestTagger{}
 	var tests = []struct {
 		name          string // has to be string
@@ -259,7 +437,7 @@ func TestGoogleCalendarEvent(t *testing.T) {
 		inputRMTID    int
 		inputTimezone string
 		inputTagger   model.Tagger
-		want          *gc.Event
+		want          *calendar.Event
 		wantErr       error
 		wantedErr     bool
 	}{
@@ -270,7 +448,7 @@ func TestGoogleCalendarEvent(t *testing.T) {
 			inputRMTID:    3,
 			inputTimezone: "Australia/Melbourne",
 			inputTagger:   tagger,
-			want: &gc.Event{
+			want: &calendar.Event{
 				Summary: "[reminder] original text",
 			},
 			wantedErr: false,
@@ -278,13 +456,119 @@ func TestGoogleCalendarEvent(t *testing.T) {
 	}
 	for position, subtest := range tests {
 		t.Run(subtest.name, func(t *testing.T) {
-			got, err := subtest.note.GoogleCalendarEvent(subtest.inputRATID, subtest.inputRMTID, subtest.inputTimezone, tagger)
+			got, err := subtest.note.CalendarEvent(subtest.inputRATID, subtest.inputRMTID, subtest.inputTimezone, tagger, nil, nil)
 			if (err != nil) != subtest.wantedErr {
-				t.Fatalf("GoogleCalendarEvent case %q (position=%d) with input <%+v> returns error <%v>; wantError <%v>", subtest.name, position, subtest.note, err, subtest.wantErr)
+				t.Fatalf("CalendarEvent case %q (position=%d) with input <%+v> returns error <%v>; wantError <%v>", subtest.name, position, subtest.note, err, subtest.wantErr)
 			}
 			if got.Summary != subtest.want.Summary {
-				t.Errorf("GoogleCalendarEvent case %q (position=%d) with input <%+v> returns <%+v>; want <%+v>", subtest.name, position, subtest.note, got, subtest.want)
+				t.Errorf("CalendarEvent case %q (position=%d) with input <%+v> returns <%+v>; want <%+v>", subtest.name, position, subtest.note, got, subtest.want)
 			}
 		})
 	}
 }
+
+func TestCalendarEventRecurrence(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	tagger := TestTagger{}
+	// Thu Jan 01 2026 00:00:00 GMT+0000
+	note := model.Note{Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1767225600, TagIds: []int{1}}
+	// case 1 (repeat tag)
+	event, err := note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.Recurrence, []string{"RRULE:FREQ=YEARLY"})
+	// case 2 (recurrence rule; the event starts from its first occurrence)
+	note.Recurrence = "FREQ=MONTHLY;BYDAY=2MO;COUNT=3"
+	event, err = note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.Recurrence, []string{"RRULE:FREQ=MONTHLY;BYDAY=2MO;COUNT=3"})
+	utils.AssertEqual(t, event.Start.DateTime, "2026-01-12T10:00:00Z")
+	// case 3 (non-recurring)
+	note.Recurrence = ""
+	event, _ = note.CalendarEvent(2, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, event.Recurrence, []string{})
+}
+
+func TestCalendarEventIdentity(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	tagger := TestTagger{}
+	note := model.Note{Id: "3f2a9c1e-0000-4000-8000-000000000000", Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1767225600}
+	event, err := note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.Properties[calendar.NoteIdProperty], note.Id)
+	hash := event.Properties[calendar.HashProperty]
+	// the hash changes along with the event
+	event, _ = note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, event.Properties[calendar.HashProperty], hash)
+	note.CompleteBy += 24 * 3600
+	event, _ = note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, event.Properties[calendar.HashProperty] != hash, true)
+}
+
+func TestCalendarEventTimezone(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	tagger := TestTagger{}
+	// case 1 (a due date without time of day is notified at 10 AM in the note's timezone)
+	// Thu Jan 01 2026 00:00:00 GMT+0530
+	note := model.Note{Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1767205800, TimeZone: "Asia/Kolkata"}
+	event, err := note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.Start.DateTime, "2026-01-01T10:00:00+05:30")
+	utils.AssertEqual(t, event.Start.TimeZone, "Asia/Kolkata")
+	// case 2 (a due date with time of day)
+	// Sun Mar 01 2026 09:00:00 GMT-0500
+	note = model.Note{Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1772373600, TimeZone: "America/New_York", Recurrence: "FREQ=WEEKLY"}
+	event, _ = note.CalendarEvent(1, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, event.Start.DateTime, "2026-03-01T09:00:00-05:00")
+	utils.AssertEqual(t, event.End.DateTime, "2026-03-01T09:30:00-05:00")
+	utils.AssertEqual(t, event.Start.TimeZone, "America/New_York")
+	// case 3 (a note without timezone, in the local timezone of the system, falls back to the timezone of the calendar)
+	utils.Location = nil
+	defer func() { utils.Location = utils.UTCLocation() }()
+	note = model.Note{Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1767225600}
+	event, _ = note.CalendarEvent(1, 3, "Australia/Melbourne", tagger, nil, nil)
+	utils.AssertEqual(t, event.Start.TimeZone, "Australia/Melbourne")
+}
+
+func TestCalendarEventOptions(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	tagger := TestTagger{}
+	// Thu Jan 01 2026 09:00:00 GMT+0000
+	note := model.Note{Text: "original text", Status: model.NoteStatus_Pending, CompleteBy: 1767258000, TagIds: []int{1, 4}}
+	// case 1 (default options)
+	event, err := note.CalendarEvent(2, 3, "UTC", tagger, nil, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.End.DateTime, "2026-01-01T09:30:00Z")
+	utils.AssertEqual(t, event.Color, "")
+	utils.AssertEqual(t, event.Busy, false)
+	utils.AssertEqual(t, len(event.Reminders), 0)
+	// case 2 (duration, colors and reminders; the color of the first matching tag wins)
+	opts := &calendar.EventOptions{
+		Color:     "basil",
+		TagColors: map[string]string{"1-4": "tomato", "0-1": "banana"},
+		Duration:  90,
+		Busy:      true,
+		Reminders: []calendar.EventReminder{{Method: calendar.ReminderMethod_Popup, Minutes: 10}, {Method: calendar.ReminderMethod_Email, Minutes: 1440}},
+	}
+	event, err = note.CalendarEvent(2, 3, "UTC", tagger, opts, nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.End.DateTime, "2026-01-01T10:30:00Z")
+	utils.AssertEqual(t, event.Color, "banana")
+	utils.AssertEqual(t, event.Busy, true)
+	utils.AssertEqual(t, event.Reminders, opts.Reminders)
+	note.TagIds = nil
+	event, _ = note.CalendarEvent(2, 3, "UTC", tagger, opts, nil)
+	utils.AssertEqual(t, event.Color, "basil")
+	// case 3 (all-day events)
+	opts.AllDay = true
+	event, _ = note.CalendarEvent(2, 3, "UTC", tagger, opts, nil)
+	utils.AssertEqual(t, event.Start, &calendar.EventTime{Date: "2026-01-01"})
+	utils.AssertEqual(t, event.End, &calendar.EventTime{Date: "2026-01-02"})
+	// case 4 (invalid options)
+	opts.TagColors["0-1"] = "pink"
+	_, err = note.CalendarEvent(2, 3, "UTC", tagger, opts, nil)
+	utils.AssertEqual(t, err.Error(), `Unknown event color "pink"; expected one of lavender, sage, grape, flamingo, banana, tangerine, peacock, graphite, blueberry, basil, tomato`)
+	opts.TagColors = nil
+	opts.Reminders = []calendar.EventReminder{{Method: "sms", Minutes: 10}}
+	_, err = note.CalendarEvent(2, 3, "UTC", tagger, opts, nil)
+	utils.AssertEqual(t, err.Error(), `Unknown reminder method "sms"; expected "popup" or "email"`)
+}
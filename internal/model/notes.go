@@ -35,12 +35,60 @@ func (notes Notes) ExternalTexts(maxStrLen int, repeatAnnuallyTagId int, repeatM
 		}
 		noteText = fmt.Sprintf(
 			"%*v {R: %s, C:%02d, S:%v, D:%v}", -maxStrLen, noteText,
-			note.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), len(note.Comments), strings.ToUpper(string(note.Status)[0:1]), utils.UnixTimestampToShortTimeStr(note.CompleteBy))
+			note.RepeatType(repeatAnnuallyTagId, repeatMonthlyTagId), len(note.Comments), strings.ToUpper(string(note.Status)[0:1]), utils.UnixTimestampToTimeStrInLocation(note.CompleteBy, "02-Jan-06", note.Location()))
 		allTexts = append(allTexts, noteText)
 	}
 	return allTexts
 }
 
+// BackfillIds assigns an Id to each of the notes which doesn't have one.
+// The assigned Id is derived from note's creation time and text, so that the same
+// note gets the same Id in different copies (such as a _CONFLICT file) of the data.
+// It returns the number of notes which were assigned an Id.
+func (notes Notes) BackfillIds() int {
+	count := 0
+	usedIds := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		usedIds[note.Id] = true
+	}
+	for _, note := range notes {
+		if note.Id == "" {
+			// note: the duplicates get the same Ids on every run as well (as long as their order is same)
+			note.Id = legacyNoteId(note, 0)
+			for occurrence := 1; usedIds[note.Id]; occurrence++ {
+				note.Id = legacyNoteId(note, occurrence)
+			}
+			usedIds[note.Id] = true
+			count++
+		}
+	}
+	return count
+}
+
+// WithId returns the note with given id.
+// It returns nil if no matching Note is found.
+func (notes Notes) WithId(id string) *Note {
+	for _, note := range notes {
+		if note.Id == id {
+			return note
+		}
+	}
+	return nil
+}
+
+// WithIdPrefix returns all notes whose Id starts with given prefix (case-insensitive).
+// It returns empty Notes if no matching Note is found.
+func (notes Notes) WithIdPrefix(prefix string) Notes {
+	var result Notes
+	prefix = strings.ToLower(prefix)
+	for _, note := range notes {
+		if strings.HasPrefix(strings.ToLower(note.Id), prefix) {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
 // PopulateTempDueDate popultes tempDueDate field of note from its CompleteBy field.
 func (notes Notes) PopulateTempDueDate() {
 	for _, note := range notes {
@@ -72,6 +120,30 @@ func (notes Notes) WithCompleteBy() Notes {
 	return result
 }
 
+// NotSnoozed filters-out the notes which are snoozed at the current time.
+// It returns empty Notes if all the notes are snoozed.
+func (notes Notes) NotSnoozed() Notes {
+	var result Notes
+	for _, note := range notes {
+		if !note.IsSnoozed() {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+// Snoozed filters-in the notes which are snoozed at the current time.
+// It returns empty Notes if no snoozed note is found.
+func (notes Notes) Snoozed() Notes {
+	var result Notes
+	for _, note := range notes {
+		if note.IsSnoozed() {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
 // OnlyMain filters notes which are set as main.
 // It returns empty Notes if no main notes is found.
 func (notes Notes) OnlyMain() Notes {
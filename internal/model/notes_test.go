@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"testing"
+	"time"
 
 	model "github.com/goyalmunish/reminder/internal/model"
 	"github.com/goyalmunish/reminder/pkg/utils"
@@ -102,6 +103,23 @@ func TestNotesOnlyMain(t *testing.T) {
 	utils.AssertEqual(t, got, want)
 }
 
+func TestNotesSnoozed(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.CurrentTime = func() time.Time { return time.Unix(1609669235, 0) }
+	var notes model.Notes
+	// case 1 (no notes)
+	utils.AssertEqual(t, notes.Snoozed(), model.Notes{})
+	utils.AssertEqual(t, notes.NotSnoozed(), model.Notes{})
+	// add some notes
+	note1 := model.Note{Text: "big fat cat", Status: model.NoteStatus_Pending}
+	note2 := model.Note{Text: "cute brown dog", Status: model.NoteStatus_Pending, DeferUntil: 1609669236}
+	note3 := model.Note{Text: "little hamster", Status: model.NoteStatus_Pending, DeferUntil: 1609669235}
+	notes = append(notes, &note1, &note2, &note3)
+	// case 2 (a note wakes up once its snooze is over)
+	utils.AssertEqual(t, notes.Snoozed(), model.Notes{&note2})
+	utils.AssertEqual(t, notes.NotSnoozed(), model.Notes{&note1, &note3})
+}
+
 func TestNotesWithTagIdAndStatus(t *testing.T) {
 	// var tags model.Tags
 	var notes model.Notes
@@ -140,3 +158,35 @@ func TestNotesWithTagIdAndStatus(t *testing.T) {
 	// case 6
 	utils.AssertEqual(t, notes.WithTagIdAndStatus(1, model.NoteStatus_Suspended), []*model.Note{&note6})
 }
+
+func TestNotesBackfillIds(t *testing.T) {
+	notes := model.Notes{&model.Note{Text: "1", Id: "existing-id"}, &model.Note{Text: "2"}, &model.Note{Text: "3"}}
+	utils.AssertEqual(t, notes.BackfillIds(), 2)
+	utils.AssertEqual(t, notes[0].Id, "existing-id")
+	utils.AssertEqual(t, len(notes[1].Id), 36)
+	utils.AssertEqual(t, notes[1].Id != notes[2].Id, true)
+	// case 2 (nothing left to backfill)
+	utils.AssertEqual(t, notes.BackfillIds(), 0)
+	// case 3 (the notes with same creation time and text get distinct, but deterministic, Ids)
+	var ids []string
+	for run := 0; run < 2; run++ {
+		notes = model.Notes{&model.Note{Text: "same", BaseStruct: model.BaseStruct{CreatedAt: 1}}, &model.Note{Text: "same", BaseStruct: model.BaseStruct{CreatedAt: 1}}, &model.Note{Text: "same", BaseStruct: model.BaseStruct{CreatedAt: 1}}}
+		utils.AssertEqual(t, notes.BackfillIds(), 3)
+		utils.AssertEqual(t, notes[0].Id != notes[1].Id && notes[1].Id != notes[2].Id && notes[0].Id != notes[2].Id, true)
+		if run == 0 {
+			ids = []string{notes[0].Id, notes[1].Id, notes[2].Id}
+		}
+	}
+	utils.AssertEqual(t, []string{notes[0].Id, notes[1].Id, notes[2].Id}, ids)
+}
+
+func TestNotesWithId(t *testing.T) {
+	note1 := model.Note{Text: "1", Id: "abc-1"}
+	note2 := model.Note{Text: "2", Id: "abd-2"}
+	notes := model.Notes{&note1, &note2}
+	utils.AssertEqual(t, notes.WithId("abd-2"), &note2)
+	utils.AssertEqual(t, notes.WithId("ab"), nil)
+	utils.AssertEqual(t, notes.WithIdPrefix("AB"), model.Notes{&note1, &note2})
+	utils.AssertEqual(t, notes.WithIdPrefix("abc"), model.Notes{&note1})
+	utils.AssertEqual(t, notes.WithIdPrefix("x"), model.Notes{})
+}
@@ -0,0 +1,268 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+An OutputFormat represents format in which notes, tags and stats are rendered.
+
+The "text" format is meant for humans, whereas rest of the formats are
+meant to be consumed by other tools (such as `jq` or spreadsheets).
+*/
+type OutputFormat string
+
+const (
+	OutputFormat_Text OutputFormat = "text"
+	OutputFormat_JSON OutputFormat = "json"
+	OutputFormat_YAML OutputFormat = "yaml"
+	OutputFormat_CSV  OutputFormat = "csv"
+)
+
+// OutputFormats returns all the supported output formats.
+func OutputFormats() []OutputFormat {
+	return []OutputFormat{OutputFormat_Text, OutputFormat_JSON, OutputFormat_YAML, OutputFormat_CSV}
+}
+
+// ParseOutputFormat returns OutputFormat corresponding to given (case-insensitive) name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	format := OutputFormat(strings.ToLower(strings.TrimSpace(name)))
+	if utils.IsMemberOfSlice(format, OutputFormats()) {
+		return format, nil
+	}
+	return "", fmt.Errorf("Unknown output format %q; supported formats are %v", name, OutputFormats())
+}
+
+// CommentRecord is the machine-readable representation of a comment.
+type CommentRecord struct {
+	Text      string `json:"text" yaml:"text"`
+	CreatedAt string `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+}
+
+// CompletionRecord is the machine-readable representation of a completed occurrence of a recurring note.
+type CompletionRecord struct {
+	DueDate     string `json:"due_date" yaml:"due_date"`
+	CompletedAt string `json:"completed_at" yaml:"completed_at"`
+	Comment     string `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// NoteRecord is the machine-readable representation of a note, with its tag slugs resolved.
+type NoteRecord struct {
+	Id         string          `json:"id" yaml:"id"`
+	Text       string          `json:"text" yaml:"text"`
+	Summary    string          `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Status     NoteStatus      `json:"status" yaml:"status"`
+	Type       string          `json:"type" yaml:"type"`
+	Tags       []string        `json:"tags" yaml:"tags"`
+	IsMain     bool            `json:"is_main" yaml:"is_main"`
+	CompleteBy string          `json:"complete_by,omitempty" yaml:"complete_by,omitempty"`
+	TimeZone   string          `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Recurrence string          `json:"recurrence,omitempty" yaml:"recurrence,omitempty"`
+	DeferUntil string          `json:"defer_until,omitempty" yaml:"defer_until,omitempty"`
+	NoSync     bool            `json:"no_sync,omitempty" yaml:"no_sync,omitempty"`
+	LeadDays   *int            `json:"lead_days,omitempty" yaml:"lead_days,omitempty"`
+	GraceDays  *int            `json:"grace_days,omitempty" yaml:"grace_days,omitempty"`
+	Comments   []CommentRecord `json:"comments" yaml:"comments"`
+	CreatedAt  string          `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	UpdatedAt  string          `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	// Completions are the completed occurrences of a recurring note.
+	Completions []CompletionRecord `json:"completions,omitempty" yaml:"completions,omitempty"`
+}
+
+// TagRecord is the machine-readable representation of a tag.
+type TagRecord struct {
+	Id           int    `json:"id" yaml:"id"`
+	Slug         string `json:"slug" yaml:"slug"`
+	Group        string `json:"group" yaml:"group"`
+	PendingNotes int    `json:"pending_notes" yaml:"pending_notes"`
+}
+
+// StatsRecord is the machine-readable representation of stats of the data file.
+type StatsRecord struct {
+	DataFile       string `json:"data_file" yaml:"data_file"`
+	Tags           int    `json:"tags" yaml:"tags"`
+	TotalNotes     int    `json:"total_notes" yaml:"total_notes"`
+	PendingNotes   int    `json:"pending_notes" yaml:"pending_notes"`
+	SuspendedNotes int    `json:"suspended_notes" yaml:"suspended_notes"`
+	DoneNotes      int    `json:"done_notes" yaml:"done_notes"`
+}
+
+// timestampToRecordStr converts unix timestamp to RFC3339 string, or blank string for unset timestamp.
+func timestampToRecordStr(unixTimestamp int64) string {
+	if unixTimestamp <= 0 {
+		return ""
+	}
+	return utils.TimeToStr(utils.UnixTimestampToTime(unixTimestamp))
+}
+
+// dueDateRecordStr converts note's due date to RFC3339 string (in the note's timezone), or blank string for unset due date.
+func (note *Note) dueDateRecordStr() string {
+	if note.CompleteBy <= 0 {
+		return ""
+	}
+	return utils.TimeToStr(time.Unix(note.CompleteBy, 0).In(note.Location()))
+}
+
+// Record returns machine-readable representation of the note.
+func (note *Note) Record(tagger Tagger) NoteRecord {
+	comments := make([]CommentRecord, 0, len(note.Comments))
+	for _, comment := range note.Comments {
+		comments = append(comments, CommentRecord{Text: comment.Text, CreatedAt: timestampToRecordStr(comment.CreatedAt)})
+	}
+	record := NoteRecord{
+		Id:         note.Id,
+		Text:       note.Text,
+		Summary:    note.Summary,
+		Status:     note.Status,
+		Type:       note.Type(),
+		Tags:       tagger.TagsFromIds(note.TagIds),
+		IsMain:     note.IsMain,
+		CompleteBy: note.dueDateRecordStr(),
+		TimeZone:   note.TimeZone,
+		DeferUntil: timestampToRecordStr(note.DeferUntil),
+		NoSync:     note.NoSync,
+		LeadDays:   note.LeadDays,
+		GraceDays:  note.GraceDays,
+		Recurrence: note.Recurrence,
+		Comments:   comments,
+		CreatedAt:  timestampToRecordStr(note.CreatedAt),
+		UpdatedAt:  timestampToRecordStr(note.UpdatedAt),
+	}
+	for _, completion := range note.Completions.sorted() {
+		record.Completions = append(record.Completions, CompletionRecord{DueDate: timestampToRecordStr(completion.DueDate), CompletedAt: timestampToRecordStr(completion.CreatedAt), Comment: completion.Comment})
+	}
+	return record
+}
+
+// Records returns machine-readable representation of the notes.
+// It returns empty []NoteRecord if there are no notes.
+func (notes Notes) Records(tagger Tagger) []NoteRecord {
+	records := make([]NoteRecord, 0, len(notes))
+	for _, note := range notes {
+		records = append(records, note.Record(tagger))
+	}
+	return records
+}
+
+// TagRecords returns machine-readable representation of all the tags (sorted by their slugs).
+func (rd *ReminderData) TagRecords() []TagRecord {
+	records := make([]TagRecord, 0, len(rd.Tags))
+	for _, slug := range rd.SortedTagSlugs() {
+		tag := rd.TagFromSlug(slug)
+		records = append(records, TagRecord{
+			Id:           tag.Id,
+			Slug:         tag.Slug,
+			Group:        tag.Group,
+			PendingNotes: len(rd.FindNotesByTagId(tag.Id, NoteStatus_Pending)),
+		})
+	}
+	return records
+}
+
+// StatsRecord returns machine-readable representation of stats of the data file.
+func (rd *ReminderData) StatsRecord() StatsRecord {
+	return StatsRecord{
+		DataFile:       rd.DataFile,
+		Tags:           len(rd.Tags),
+		TotalNotes:     len(rd.Notes),
+		PendingNotes:   len(rd.Notes.WithStatus(NoteStatus_Pending)),
+		SuspendedNotes: len(rd.Notes.WithStatus(NoteStatus_Suspended)),
+		DoneNotes:      len(rd.Notes.WithStatus(NoteStatus_Done)),
+	}
+}
+
+// RenderNotes renders given notes (such as a listing or search results) in given format.
+// In the "text" format, each note is rendered as a single line prefixed with its short id.
+func (rd *ReminderData) RenderNotes(notes Notes, format OutputFormat) (string, error) {
+	records := notes.Records(rd)
+	textFunc := func() (string, error) {
+		repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+		var lines []string
+		for i, text := range notes.ExternalTexts(0, repeatAnnuallyTagId, repeatMonthlyTagId) {
+			lines = append(lines, fmt.Sprintf("%s  %s\n", notes[i].ShortId(), text))
+		}
+		return strings.Join(lines, ""), nil
+	}
+	csvFunc := func() [][]string {
+		rows := [][]string{{"id", "text", "summary", "status", "type", "tags", "is_main", "complete_by", "recurrence", "comments", "created_at", "updated_at"}}
+		for _, r := range records {
+			rows = append(rows, []string{r.Id, r.Text, r.Summary, string(r.Status), r.Type, strings.Join(r.Tags, ";"), strconv.FormatBool(r.IsMain), r.CompleteBy, r.Recurrence, strconv.Itoa(len(r.Comments)), r.CreatedAt, r.UpdatedAt})
+		}
+		return rows
+	}
+	return render(format, records, textFunc, csvFunc)
+}
+
+// RenderTags renders all the tags in given format.
+func (rd *ReminderData) RenderTags(format OutputFormat) (string, error) {
+	records := rd.TagRecords()
+	textFunc := func() (string, error) {
+		var lines []string
+		for _, r := range records {
+			lines = append(lines, fmt.Sprintf("%-25s %-15s %3d pending\n", r.Slug, r.Group, r.PendingNotes))
+		}
+		return strings.Join(lines, ""), nil
+	}
+	csvFunc := func() [][]string {
+		rows := [][]string{{"id", "slug", "group", "pending_notes"}}
+		for _, r := range records {
+			rows = append(rows, []string{strconv.Itoa(r.Id), r.Slug, r.Group, strconv.Itoa(r.PendingNotes)})
+		}
+		return rows
+	}
+	return render(format, records, textFunc, csvFunc)
+}
+
+// RenderStats renders stats of the data file in given format.
+func (rd *ReminderData) RenderStats(format OutputFormat) (string, error) {
+	record := rd.StatsRecord()
+	csvFunc := func() [][]string {
+		return [][]string{
+			{"data_file", "tags", "total_notes", "pending_notes", "suspended_notes", "done_notes"},
+			{record.DataFile, strconv.Itoa(record.Tags), strconv.Itoa(record.TotalNotes), strconv.Itoa(record.PendingNotes), strconv.Itoa(record.SuspendedNotes), strconv.Itoa(record.DoneNotes)},
+		}
+	}
+	return render(format, record, rd.Stats, csvFunc)
+}
+
+// render renders the value in given format.
+// The textFunc and csvFunc provide the "text" and "csv" representations of the value.
+func render(format OutputFormat, value interface{}, textFunc func() (string, error), csvFunc func() [][]string) (string, error) {
+	switch format {
+	case OutputFormat_Text:
+		return textFunc()
+	case OutputFormat_JSON:
+		// unlike json.MarshalIndent, keep characters such as <, > and & as they are
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		return buffer.String(), nil
+	case OutputFormat_YAML:
+		byteValue, err := yaml.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(byteValue), nil
+	case OutputFormat_CSV:
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		if err := writer.WriteAll(csvFunc()); err != nil {
+			return "", err
+		}
+		return buffer.String(), nil
+	}
+	return "", fmt.Errorf("Unknown output format %q", format)
+}
@@ -0,0 +1,126 @@
+package model_test
+
+import (
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func outputTestReminderData() *model.ReminderData {
+	var tags model.Tags
+	tags = append(tags, &model.Tag{Id: 0, Slug: "tag_0", Group: "tag_group1"})
+	tags = append(tags, &model.Tag{Id: 1, Slug: "tag_1", Group: "tag_group1"})
+	comments := model.Comments{&model.Comment{Text: "c < 1", BaseStruct: model.BaseStruct{CreatedAt: 1609669235}}}
+	notes := model.Notes{
+		&model.Note{Id: "3f2a9c1e-0001", Text: "dummy < text", Comments: comments, Status: model.NoteStatus_Pending, TagIds: []int{1}, CompleteBy: 1609669235},
+		&model.Note{Id: "4b3c0d2f-0002", Text: "another, \"text\"", Comments: model.Comments{}, Status: model.NoteStatus_Done, TagIds: []int{0, 1}, IsMain: true},
+	}
+	return &model.ReminderData{DataFile: "data.json", Tags: tags, Notes: notes}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	format, err := model.ParseOutputFormat(" JSON ")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, format, model.OutputFormat_JSON)
+	_, err = model.ParseOutputFormat("xml")
+	utils.AssertEqual(t, err != nil, true)
+}
+
+func TestNoteRecord(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	reminderData := outputTestReminderData()
+	got := reminderData.Notes[0].Record(reminderData)
+	want := model.NoteRecord{
+		Id:         "3f2a9c1e-0001",
+		Text:       "dummy < text",
+		Status:     model.NoteStatus_Pending,
+		Type:       "incidental",
+		Tags:       []string{"tag_1"},
+		CompleteBy: "2021-01-03T10:20:35Z",
+		Comments:   []model.CommentRecord{{Text: "c < 1", CreatedAt: "2021-01-03T10:20:35Z"}},
+	}
+	utils.AssertEqual(t, got, want)
+}
+
+func TestRenderNotes(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	reminderData := outputTestReminderData()
+	// case 1 (text)
+	got, err := reminderData.RenderNotes(reminderData.Notes, model.OutputFormat_Text)
+	utils.AssertEqual(t, err, nil)
+	want := `3f2a9c1e  dummy < text {R: -, C:01, S:P, D:03-Jan-21}
+4b3c0d2f  another, "text" {R: -, C:00, S:D, D:nil}
+`
+	utils.AssertEqual(t, got, want)
+	// case 2 (json)
+	got, _ = reminderData.RenderNotes(reminderData.Notes[1:], model.OutputFormat_JSON)
+	want = `[
+    {
+        "id": "4b3c0d2f-0002",
+        "text": "another, \"text\"",
+        "status": "done",
+        "type": "main",
+        "tags": [
+            "tag_0",
+            "tag_1"
+        ],
+        "is_main": true,
+        "comments": []
+    }
+]
+`
+	utils.AssertEqual(t, got, want)
+	// case 3 (yaml)
+	got, _ = reminderData.RenderNotes(reminderData.Notes[1:], model.OutputFormat_YAML)
+	want = `- id: 4b3c0d2f-0002
+  text: another, "text"
+  status: done
+  type: main
+  tags:
+    - tag_0
+    - tag_1
+  is_main: true
+  comments: []
+`
+	utils.AssertEqual(t, got, want)
+	// case 4 (csv)
+	got, _ = reminderData.RenderNotes(reminderData.Notes, model.OutputFormat_CSV)
+	want = `id,text,summary,status,type,tags,is_main,complete_by,recurrence,comments,created_at,updated_at
+3f2a9c1e-0001,dummy < text,,pending,incidental,tag_1,false,2021-01-03T10:20:35Z,,1,,
+4b3c0d2f-0002,"another, ""text""",,done,main,tag_0;tag_1,true,,,0,,
+`
+	utils.AssertEqual(t, got, want)
+	// case 5 (no notes)
+	got, _ = reminderData.RenderNotes(model.Notes{}, model.OutputFormat_JSON)
+	utils.AssertEqual(t, got, "[]\n")
+}
+
+func TestRenderTags(t *testing.T) {
+	reminderData := outputTestReminderData()
+	got, _ := reminderData.RenderTags(model.OutputFormat_CSV)
+	want := `id,slug,group,pending_notes
+0,tag_0,tag_group1,0
+1,tag_1,tag_group1,1
+`
+	utils.AssertEqual(t, got, want)
+}
+
+func TestRenderStats(t *testing.T) {
+	reminderData := outputTestReminderData()
+	got, _ := reminderData.RenderStats(model.OutputFormat_JSON)
+	want := `{
+    "data_file": "data.json",
+    "tags": 2,
+    "total_notes": 2,
+    "pending_notes": 1,
+    "suspended_notes": 0,
+    "done_notes": 1
+}
+`
+	utils.AssertEqual(t, got, want)
+	// the text format is same as the Stats
+	got, _ = reminderData.RenderStats(model.OutputFormat_Text)
+	want, _ = reminderData.Stats()
+	utils.AssertEqual(t, got, want)
+}
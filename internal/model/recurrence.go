@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/rrule"
+)
+
+// repeatTagIds returns ids of the "repeat-annually" and "repeat-monthly" tags.
+// Note: tag ids are never negative, so -1 represents a missing tag.
+func (rd *ReminderData) repeatTagIds() (int, int) {
+	repeatAnnuallyTagId, repeatMonthlyTagId := -1, -1
+	if tag := rd.TagFromSlug("repeat-annually"); tag != nil {
+		repeatAnnuallyTagId = tag.Id
+	}
+	if tag := rd.TagFromSlug("repeat-monthly"); tag != nil {
+		repeatMonthlyTagId = tag.Id
+	}
+	return repeatAnnuallyTagId, repeatMonthlyTagId
+}
+
+// occurrenceAround returns the occurrence (of the rule starting at dueDate) which the current
+// time is within daysBefore and daysAfter of, preferring the previous occurrence over the next.
+// All the timestamps are unix timestamps, and the occurrences and days are reckoned in the location.
+func occurrenceAround(rule *rrule.Rule, dueDate int64, currentTimestamp int64, daysBefore int64, daysAfter int64, location *time.Location) (int64, bool) {
+	dtstart := time.Unix(dueDate, 0).In(location)
+	current := time.Unix(currentTimestamp, 0).In(location)
+	if previous, ok := rule.Before(dtstart, current, true); ok && !current.After(previous.AddDate(0, 0, int(daysAfter))) {
+		return previous.Unix(), true
+	}
+	if next, ok := rule.After(dtstart, current, false); ok && !current.Before(next.AddDate(0, 0, -int(daysBefore))) {
+		return next.Unix(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// noteFields are the fields of a note, in the order of its external text (see Note.Strings).
+var noteFields = []string{"text", "comments", "summary", "status", "tags", "is_main", "complete_by", "recurrence", "created_at", "updated_at", "id"}
+
+// The personal details which can be scrubbed from the notes (see RedactionOptions.Scrub).
+const (
+	Scrub_Email = "email"
+	Scrub_Phone = "phone"
+	Scrub_URL   = "url"
+)
+
+// scrubbers are the patterns of the personal details, along with their replacements, in the order
+// in which they are scrubbed (the URLs first, as they may contain the rest).
+var scrubbers = []struct {
+	kind        string
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{Scrub_URL, regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)[^\s<>"']*[^\s<>"'.,;:!?)]`), "[url]"},
+	{Scrub_Email, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[email]"},
+	{Scrub_Phone, regexp.MustCompile(`\+?\(?\d[\d ().-]{6,}\d`), "[phone]"},
+}
+
+// The minimum and maximum number of digits of a phone number (as per E.164), so that the dates (such
+// as "2026-10-16") aren't taken as phone numbers.
+const (
+	phoneMinDigits = 9
+	phoneMaxDigits = 15
+)
+
+/*
+A RedactionOptions represents what of the notes leaves the machine, with the calendar sync as well
+as with the exports of the notes (see ReminderData.ExportICS).
+
+By default, the events of the notes are titled with their text, and described with all of their
+fields but the comments.
+*/
+type RedactionOptions struct {
+	// Fields are the fields of the notes included in the descriptions of their events (see noteFields).
+	// Without "text", the events are titled with GenericTitle as well.
+	Fields []string `json:"fields" yaml:"fields" mapstructure:"fields"`
+	// PrivateTags are the tags (by their slugs) which mark the notes as private; the events of the
+	// private notes are titled with GenericTitle, and have no description.
+	PrivateTags []string `json:"private_tags" yaml:"private_tags" mapstructure:"private_tags"`
+	// GenericTitles titles the events of all the notes with GenericTitle, in place of their text.
+	GenericTitles bool `json:"generic_titles" yaml:"generic_titles" mapstructure:"generic_titles"`
+	// GenericTitle is the title of the events of the notes whose text is left out.
+	GenericTitle string `json:"generic_title" yaml:"generic_title" mapstructure:"generic_title"`
+	// Scrub are the personal details ("email", "phone" or "url") which are replaced (such as by
+	// "[email]") in the text, summary and comments of the notes.
+	Scrub []string `json:"scrub" yaml:"scrub" mapstructure:"scrub"`
+	// Patterns are the regular expressions of any other details which are replaced (by "[redacted]")
+	// in the text, summary and comments of the notes.
+	Patterns []string `json:"patterns" yaml:"patterns" mapstructure:"patterns"`
+}
+
+func DefaultRedactionOptions() *RedactionOptions {
+	return &RedactionOptions{
+		Fields:       []string{"text", "summary", "status", "tags", "is_main", "complete_by", "recurrence", "created_at", "updated_at", "id"},
+		PrivateTags:  []string{},
+		GenericTitle: "Busy",
+		Scrub:        []string{},
+		Patterns:     []string{},
+	}
+}
+
+/*
+A Redaction applies the RedactionOptions to the notes leaving the machine (see NewRedaction).
+*/
+type Redaction struct {
+	opts     *RedactionOptions
+	patterns []*regexp.Regexp
+}
+
+// NewRedaction validates the options (or with nil, takes the default options), and returns their redaction.
+func NewRedaction(opts *RedactionOptions) (*Redaction, error) {
+	if opts == nil {
+		opts = DefaultRedactionOptions()
+	}
+	for _, field := range opts.Fields {
+		if !utils.IsMemberOfSlice(field, noteFields) {
+			return nil, fmt.Errorf("Unknown field %q in the redaction; expected one of %s", field, strings.Join(noteFields, ", "))
+		}
+	}
+	for _, kind := range opts.Scrub {
+		if kind != Scrub_Email && kind != Scrub_Phone && kind != Scrub_URL {
+			return nil, fmt.Errorf("Unknown detail %q to be scrubbed; expected %q, %q or %q", kind, Scrub_Email, Scrub_Phone, Scrub_URL)
+		}
+	}
+	redaction := &Redaction{opts: opts}
+	for _, pattern := range opts.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern %q in the redaction: %w", pattern, err)
+		}
+		redaction.patterns = append(redaction.patterns, compiled)
+	}
+	return redaction, nil
+}
+
+// defaultRedaction returns the redaction of the default options.
+func defaultRedaction() *Redaction {
+	redaction, _ := NewRedaction(nil)
+	return redaction
+}
+
+// IsPrivate tells if the note is marked as private by any of its tags.
+func (redaction *Redaction) IsPrivate(note *Note, tagger Tagger) bool {
+	for _, slug := range tagger.TagsFromIds(note.TagIds) {
+		if utils.IsMemberOfSlice(slug, redaction.opts.PrivateTags) {
+			return true
+		}
+	}
+	return false
+}
+
+// Title returns the title of the event of the note.
+func (redaction *Redaction) Title(note *Note, tagger Tagger) string {
+	if redaction.opts.GenericTitles || !utils.IsMemberOfSlice("text", redaction.opts.Fields) || redaction.IsPrivate(note, tagger) {
+		return redaction.opts.GenericTitle
+	}
+	return redaction.Scrub(note.Text)
+}
+
+// Description returns the description of the event of the note, with just the fields to be
+// included, and with the personal details scrubbed.
+func (redaction *Redaction) Description(note *Note, tagger Tagger) (string, error) {
+	if redaction.IsPrivate(note, tagger) {
+		return "", nil
+	}
+	// describe a copy of the note, with its text (as titled) and the rest scrubbed
+	scrubbed := *note
+	scrubbed.Text = redaction.Title(note, tagger)
+	scrubbed.Summary = redaction.Scrub(note.Summary)
+	scrubbed.Comments = nil
+	for _, comment := range note.Comments {
+		scrubbedComment := *comment
+		scrubbedComment.Text = redaction.Scrub(comment.Text)
+		scrubbed.Comments = append(scrubbed.Comments, &scrubbedComment)
+	}
+	strs, err := scrubbed.externalText(tagger)
+	if err != nil {
+		return "", err
+	}
+	// note: the first one is the heading
+	included := []string{strs[0]}
+	for index, field := range noteFields {
+		if utils.IsMemberOfSlice(field, redaction.opts.Fields) {
+			included = append(included, strs[index+1])
+		}
+	}
+	return strings.Join(included, ""), nil
+}
+
+// Scrub replaces the personal details in the text, as per the options.
+func (redaction *Redaction) Scrub(text string) string {
+	for _, scrubber := range scrubbers {
+		if !utils.IsMemberOfSlice(scrubber.kind, redaction.opts.Scrub) {
+			continue
+		}
+		if scrubber.kind != Scrub_Phone {
+			text = scrubber.pattern.ReplaceAllString(text, scrubber.replacement)
+			continue
+		}
+		text = scrubber.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			digits := 0
+			for _, r := range match {
+				if unicode.IsDigit(r) {
+					digits++
+				}
+			}
+			if digits < phoneMinDigits || digits > phoneMaxDigits {
+				return match
+			}
+			return scrubber.replacement
+		})
+	}
+	for _, pattern := range redaction.patterns {
+		text = pattern.ReplaceAllString(text, "[redacted]")
+	}
+	return text
+}
@@ -0,0 +1,106 @@
+package model_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestRedaction(t *testing.T) {
+	utils.Location = utils.UTCLocation()
+	var tagger TestTagger
+	note := &model.Note{
+		Id:         "rent",
+		Text:       "pay the rent to bob@example.com on 2026-10-16",
+		Summary:    "details at https://bank.example.com/pay?id=7, or call +91 98765 43210",
+		Comments:   model.Comments{{Text: "account number 1234-5678"}},
+		Status:     model.NoteStatus_Pending,
+		TagIds:     []int{1, 2},
+		CompleteBy: 1767258000,
+	}
+	// case 1 (default options; just the comments are left out)
+	redaction, err := model.NewRedaction(nil)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, redaction.Title(note, tagger), note.Text)
+	description, _ := redaction.Description(note, tagger)
+	utils.AssertEqual(t, strings.Contains(description, note.Summary), true)
+	utils.AssertEqual(t, strings.Contains(description, "1234-5678"), false)
+	// case 2 (scrubbing, with the dates left alone)
+	opts := model.DefaultRedactionOptions()
+	opts.Fields = []string{"text", "summary", "comments"}
+	opts.Scrub = []string{model.Scrub_Email, model.Scrub_Phone, model.Scrub_URL}
+	opts.Patterns = []string{`account number [\d-]+`}
+	redaction, err = model.NewRedaction(opts)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, redaction.Title(note, tagger), "pay the rent to [email] on 2026-10-16")
+	description, _ = redaction.Description(note, tagger)
+	utils.AssertEqual(t, strings.Contains(description, "Summary:  details at [url], or call [phone]"), true)
+	utils.AssertEqual(t, strings.Contains(description, "[redacted]"), true)
+	// only the given fields are described
+	utils.AssertEqual(t, strings.Contains(description, "Status:"), false)
+	utils.AssertEqual(t, strings.Contains(description, "Id:"), false)
+	// case 3 (generic titles)
+	opts.GenericTitles = true
+	redaction, _ = model.NewRedaction(opts)
+	utils.AssertEqual(t, redaction.Title(note, tagger), "Busy")
+	description, _ = redaction.Description(note, tagger)
+	utils.AssertEqual(t, strings.Contains(description, "bob"), false)
+	// case 4 (private notes, by their tags)
+	opts.GenericTitles = false
+	opts.PrivateTags = []string{"1-2"}
+	redaction, _ = model.NewRedaction(opts)
+	utils.AssertEqual(t, redaction.IsPrivate(note, tagger), true)
+	event, err := note.CalendarEvent(2, 3, "UTC", tagger, nil, redaction)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, event.Summary, calendar.TitlePrefix+"Busy")
+	utils.AssertEqual(t, event.Description, "")
+	// case 5 (invalid options)
+	_, err = model.NewRedaction(&model.RedactionOptions{Fields: []string{"location"}})
+	utils.AssertEqual(t, err.Error(), `Unknown field "location" in the redaction; expected one of text, comments, summary, status, tags, is_main, complete_by, recurrence, created_at, updated_at, id`)
+	_, err = model.NewRedaction(&model.RedactionOptions{Scrub: []string{"address"}})
+	utils.AssertEqual(t, err.Error(), `Unknown detail "address" to be scrubbed; expected "email", "phone" or "url"`)
+	_, err = model.NewRedaction(&model.RedactionOptions{Patterns: []string{"(unclosed"}})
+	utils.AssertEqual(t, strings.HasPrefix(err.Error(), `Invalid pattern "(unclosed" in the redaction:`), true)
+}
+
+func TestExportICSRedacted(t *testing.T) {
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.Location = utils.UTCLocation()
+	// Fri Oct 16 2026 09:00:00 GMT+0000
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.Tags = model.Tags{{Id: 0, Slug: "health"}}
+	// Sun Nov 01 2026 00:00:00 GMT+0000
+	dueDate := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix()
+	reminderData.Notes = model.Notes{
+		{Id: "doctor", Text: "see dr. smith", Summary: "about the test results", Status: model.NoteStatus_Pending, CompleteBy: dueDate, TagIds: []int{0}},
+		{Id: "bank", Text: "call the bank at 020 7946 0958", Status: model.NoteStatus_Pending, CompleteBy: dueDate},
+	}
+	utils.AssertEqual(t, reminderData.SetRedactionOptions(&model.RedactionOptions{
+		Fields:       []string{"text"},
+		PrivateTags:  []string{"health"},
+		GenericTitle: "Appointment",
+		Scrub:        []string{model.Scrub_Phone},
+	}), nil)
+	data, err := reminderData.ExportICS(calendar.ICSComponent_Event)
+	utils.AssertEqual(t, err, nil)
+	events, _ := calendar.DecodeICS(data)
+	utils.AssertEqual(t, events[0].Summary, calendar.TitlePrefix+"Appointment")
+	utils.AssertEqual(t, events[1].Summary, calendar.TitlePrefix+"call the bank at [phone]")
+	for _, secret := range []string{"smith", "results", "7946"} {
+		utils.AssertEqual(t, strings.Contains(data, secret), false)
+	}
+	// the invalid options are rejected
+	utils.AssertEqual(t, reminderData.SetRedactionOptions(&model.RedactionOptions{Scrub: []string{"ssn"}}) != nil, true)
+}
@@ -1,22 +1,18 @@
 package model
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
 	"os"
-	"os/exec"
 	"path"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/goyalmunish/reminder/pkg/calendar"
 	"github.com/goyalmunish/reminder/pkg/logger"
 	"github.com/goyalmunish/reminder/pkg/utils"
-	gc "google.golang.org/api/calendar/v3"
 )
 
 const EnableCalendar bool = true
@@ -30,8 +26,23 @@ type ReminderData struct {
 	Tags         Tags   `json:"tags"`
 	DataFile     string `json:"data_file"`
 	LastBackupAt int64  `json:"last_backup_at"`
-	MutexLock    bool   `json:"mutex_lock"`
+	// SchemaVersion is the version of format of the data (see Migration).
+	SchemaVersion int `json:"schema_version"`
 	BaseStruct
+	// readOnly is set when another session holds the lock on the data file
+	readOnly bool
+	// store is where the data is persisted (see Store)
+	store Store
+	// migrations are the migrations applied when the data was read
+	migrations []MigrationResult
+	// dueWindowOptions are the default due windows of the notes (see SetDueWindowOptions)
+	dueWindowOptions *DueWindowOptions
+	// eventOptions are the appearance of the calendar events of the notes (see SetEventOptions)
+	eventOptions *calendar.EventOptions
+	// syncFilter selects the notes to be synced to the calendar (see SetSyncFilter)
+	syncFilter *calendar.SyncFilter
+	// redaction is applied to the notes leaving the machine (see SetRedactionOptions)
+	redaction *Redaction
 }
 
 // Tagger is interface representing ReminderData with TagsFromIds method.
@@ -39,31 +50,36 @@ type Tagger interface {
 	TagsFromIds(tagIDs []int) []string
 }
 
-// SyncCalendar syncs pending notes to Cloud Calendar.
-func (rd *ReminderData) SyncCalendar(calOptions *calendar.Options) error {
+// SyncCalendar syncs pending notes to the calendar selected by the options (see calendar.New).
+// It creates, updates and deletes just the events of the notes which have changed since the last sync,
+// after showing the planned changes; and then reports the changes which failed, offering to retry them.
+// The sync is stopped once the context is done.
+func (rd *ReminderData) SyncCalendar(ctx context.Context, calOptions *calendar.Options) error {
 	lookAheadYears := 5
 	if !EnableCalendar {
-		logger.Warn("Google Calendar is disabled.")
+		logger.Warn("Calendar sync is disabled.")
 		return nil
 	}
 
-	// Get calendar service
-	logger.Info("Retrieve the Cloud Calendar Service.")
-	srv, err := calendar.GetCalendarService(calOptions)
+	// Get the calendar
+	logger.Info("Retrieve the Calendar.")
+	cal, err := calendar.New(ctx, calOptions)
 	if err != nil {
-		return fmt.Errorf("Unable to retrieve Calendar client: %w", err)
+		return err
+	}
+	timeZone, err := cal.TimeZone(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Get list of all upcoming events, and display them
 	logger.Info("Fetch the list of all upcoming Calendar Events with each type of recurring event as single unit.")
-	existingEvents, eventDetails, timeZone, err := calendar.FetchUpcomingEventsAndDetails(srv, 0, lookAheadYears, "")
+	existingEvents, err := calendar.FetchUpcomingEvents(ctx, cal, lookAheadYears)
 	if err != nil {
 		return err
 	}
-	fmt.Println(eventDetails)
-	// Iterating through the Cloud Calendar Events
-	fmt.Printf("Listing upcoming calendar events (%v):\n", len(existingEvents))
-	fmt.Println("Note: It may take some time for Google Calendar read API to pick up the recently added events, or Calendar trash https://calendar.google.com/calendar/u/0/r/trash can also cause issues.")
+	// Iterating through the Calendar Events
+	fmt.Printf("Listing upcoming events of %s (%v):\n", cal.Name(), len(existingEvents))
 	if len(existingEvents) == 0 {
 		logger.Warn("No upcoming events found.")
 	} else {
@@ -76,54 +92,72 @@ func (rd *ReminderData) SyncCalendar(calOptions *calendar.Options) error {
 	}
 	fmt.Println() // just print a blank line
 
-	// Delete existing reminder events from Cloud Calendar
-	// Note: Your might like to check your Calendar trash
-	// https://calendar.google.com/calendar/u/0/r/trash and clear it from
-	// time to time. Otherwise, this obstructs with visibility of newly
-	// added event in the API call.
+	// Fetch the events registered by the app (including the ones deleted in the calendar), and pull
+	// the changes made to them in the calendar into the notes
 	logger.Info("Fetch all the events registered by reminder app.")
-	reminderEvents, _, _, err := calendar.FetchUpcomingEventsAndDetails(srv, 2, lookAheadYears, calendar.TitlePrefix)
+	reminderEvents, err := calendar.FetchReminderEvents(ctx, cal, 2, lookAheadYears)
 	if err != nil {
 		return fmt.Errorf("Unable to retrieve the events: %w", err)
 	}
-	fmt.Printf("Listing (%v) and deleting the events registered by the reminder app:\n", len(reminderEvents))
-	err = calendar.DeleteEvents(srv, reminderEvents, calOptions.DryMode)
-	if err != nil {
-		return fmt.Errorf("Unable to delete the events: %w", err)
+	if err := rd.PullCalendarChanges(reminderEvents, timeZone); err != nil {
+		return err
 	}
-
-	// Add events to Cloud Calendar
+	// Compare the events registered by the app with the events of the notes
+	// Note: Only the changed events are updated in the calendar.
 	logger.Info("Fetching events to be Synced.")
-	newEvents, err := rd.GoogleCalendarEvents(timeZone)
+	newEvents, err := rd.CalendarEvents(timeZone)
 	if err != nil {
 		return err
 	}
-	waitFor := 10 * time.Second
-	fmt.Printf("\nAdding %v events (pending ones with due-date) to Google Calendar. Hit Ctrl-c if you don't want to do it at the moment. The process will wait for %v.\n", len(newEvents), waitFor)
-	fmt.Printf("waiting for %v...\n", waitFor)
-	time.Sleep(waitFor)
-	fmt.Println("Starting the event addition process.")
-	err = calendar.AddEvents(srv, newEvents, calOptions.DryMode)
-	if err != nil {
+	plan := calendar.PlanSync(reminderEvents, newEvents)
+	fmt.Println(plan)
+	if plan.IsEmpty() {
+		fmt.Println("The calendar is already in sync.")
+		return nil
+	}
+	if !calOptions.DryMode {
+		apply, err := utils.AskBoolean(fmt.Sprintf("Apply the planned changes to %s?", cal.Name()))
+		if err != nil {
+			return err
+		}
+		if !apply {
+			fmt.Println("No changes made")
+			return nil
+		}
+	}
+	report := calendar.ApplySync(ctx, cal, plan, calOptions)
+	fmt.Println(report)
+	for report.Err() != nil && ctx.Err() == nil {
+		retry, err := utils.AskBoolean(fmt.Sprintf("Retry the %d failed changes?", len(report.Failed())))
+		if err != nil {
+			return err
+		}
+		if !retry {
+			break
+		}
+		report = calendar.ApplySync(ctx, cal, report.RetryPlan(), calOptions)
+		fmt.Println(report)
+	}
+	if err := report.Err(); err != nil {
 		return err
 	}
-	fmt.Println("Done with event addition process.")
+	fmt.Println("Done with the sync.")
 	return nil
 }
 
-// GoogleCalendarEvents returns list of Google Calendar Events.
-func (rd *ReminderData) GoogleCalendarEvents(timezoneIANA string) ([]*gc.Event, error) {
-	logger.Info("Start: GoogleCalendarEvents")
-	defer logger.Info("End: GoogleCalendarEvents")
-	// get all pending notes
-	allNotes := rd.Notes
-	relevantNotes := allNotes.WithStatus(NoteStatus_Pending).WithCompleteBy()
+// CalendarEvents returns the calendar events of the notes to be synced (see NotesToSync).
+func (rd *ReminderData) CalendarEvents(timezoneIANA string) ([]*calendar.Event, error) {
+	logger.Info("Start: CalendarEvents")
+	defer logger.Info("End: CalendarEvents")
+	relevantNotes, err := rd.NotesToSync()
+	if err != nil {
+		return nil, err
+	}
 	// construct Cloud Events
-	repeatAnnuallyTag := rd.TagFromSlug("repeat-annually")
-	repeatMonthlyTag := rd.TagFromSlug("repeat-monthly")
-	var events []*gc.Event
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	var events []*calendar.Event
 	for _, note := range relevantNotes {
-		event, err := note.GoogleCalendarEvent(repeatAnnuallyTag.Id, repeatMonthlyTag.Id, timezoneIANA, rd)
+		event, err := note.CalendarEvent(repeatAnnuallyTagId, repeatMonthlyTagId, timezoneIANA, rd, rd.eventOptions, rd.redaction)
 		if err != nil {
 			return nil, err
 		}
@@ -132,23 +166,67 @@ func (rd *ReminderData) GoogleCalendarEvents(timezoneIANA string) ([]*gc.Event,
 	return events, nil
 }
 
+// SetRedactionOptions sets the redaction of the notes leaving the machine, with the calendar sync
+// as well as with the exports, after validating it.
+func (rd *ReminderData) SetRedactionOptions(opts *RedactionOptions) error {
+	redaction, err := NewRedaction(opts)
+	if err != nil {
+		return err
+	}
+	rd.redaction = redaction
+	return nil
+}
+
+// SetEventOptions sets the appearance (and the reminders) of the calendar events of the notes.
+func (rd *ReminderData) SetEventOptions(opts *calendar.EventOptions) {
+	rd.eventOptions = opts
+}
+
+// SetReadOnly sets (or unsets) the read-only mode.
+// In the read-only mode, the data file is never written to.
+func (rd *ReminderData) SetReadOnly(readOnly bool) {
+	rd.readOnly = readOnly
+}
+
+// ReadOnly tells if the data is opened in read-only mode.
+func (rd *ReminderData) ReadOnly() bool {
+	return rd.readOnly
+}
+
+// Store returns the store in which the data is persisted.
+// Unless set otherwise, it is the JSON data file at rd.DataFile.
+func (rd *ReminderData) Store() Store {
+	if rd.store == nil {
+		return NewJSONStore(rd.DataFile)
+	}
+	return rd.store
+}
+
+// SetStore sets the store in which the data is persisted.
+func (rd *ReminderData) SetStore(store Store) {
+	rd.store = store
+}
+
+// Transaction runs fn such that all the changes saved by it are persisted together.
+func (rd *ReminderData) Transaction(fn func() error) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	store := rd.Store()
+	return store.Transaction(func(tx Store) error {
+		rd.store = tx
+		defer func() { rd.store = store }()
+		return fn()
+	})
+}
+
 // CreateDataFile creates data file with current state of `rd`.
 // The msg is any additional message to be printed.
 func (rd *ReminderData) CreateDataFile(msg string) error {
-	// update UpdatedAt field
-	// note that UpdatedAt of a whole ReminderData object is different
-	// from corresponding field of each note
-	currentTime := utils.CurrentUnixTimestamp()
-	rd.UpdatedAt = currentTime
-	rd.CreatedAt = currentTime
-	// marshal the data
-	byteValue, err := json.MarshalIndent(&rd, "", "    ")
-	if err != nil {
-		return err
+	if rd.readOnly {
+		return ErrorReadOnly
 	}
-	// persist the byte data to file
-	err = os.WriteFile(rd.DataFile, byteValue, 0755)
-	if err != nil {
+	if err := rd.Store().Create(rd); err != nil {
 		return err
 	}
 	if msg != "" {
@@ -161,53 +239,33 @@ func (rd *ReminderData) CreateDataFile(msg string) error {
 // UpdateDataFile updates data file with current state of `rd`.
 // The msg is any additional message to be printed.
 func (rd *ReminderData) UpdateDataFile(msg string) error {
-	var conflictError error
-	// if UpdatedAt timestamp of currently loaded data is not same as timestamp persisted on datafile
-	// some other process would have updated the data file, which can lead to data inconsistency
-	persistedData, err := ReadDataFile(rd.DataFile, true)
-	if err != nil {
-		return err
+	if rd.readOnly {
+		return ErrorReadOnly
 	}
-	persistedTimestamp := persistedData.UpdatedAt
-	inMemoryTimestamp := rd.UpdatedAt
-	currentTimestamp := utils.CurrentUnixTimestamp()
-	logger.Info(fmt.Sprintf("In-memory timestamp: %v, Persisted timestamp: %v, Current Timestamp (being persisted): %v", inMemoryTimestamp, persistedTimestamp, currentTimestamp))
-	if persistedTimestamp != inMemoryTimestamp {
-		newFilePath := fmt.Sprintf("%s_CONFLICT_%d", rd.DataFile, currentTimestamp)
-		rd.DataFile = newFilePath
-		logger.Error(fmt.Sprintf("It seems another instance of the application updated the data file; the data will instead be saved to confict file %q.", newFilePath))
-		conflictError = ErrorConflictFile
-	}
-	// update UpdatedAt field
-	// note that UpdatedAt of a whole ReminderData object is different
-	// from corresponding field of each note
-	rd.UpdatedAt = currentTimestamp
-	// marshal the data
-	// Refer https://pkg.go.dev/encoding/json#MarshalIndent
-	// Note: String values encoded as JSON strings are coerced to valid
-	// UTF-8, replacing invalid bytes with Unicode replacement rune. So
-	// that the JSON will be safe to embed inside HTML <script> tags, the
-	// string is encoded using HTMLEscape.
-	// For example, a text such as `comment with < and "` will be written
-	// as `"comment with \u003c and \"` but it will read back same as the
-	// original string
-	byteValue, err := json.MarshalIndent(&rd, "", "    ")
-	if err != nil {
-		return err
-	}
-	// persist the byte data to file
-	err = os.WriteFile(rd.DataFile, byteValue, 0755)
-	if err != nil {
+	err := rd.Store().Save(rd)
+	if err != nil && !errors.Is(err, ErrorConflictFile) {
 		return err
 	}
 	if msg != "" {
 		logger.Info(msg)
 	}
-	logger.Info(fmt.Sprintf("Updated the data file %q at %v!", rd.DataFile, rd.UpdatedAt))
-	if conflictError != nil {
-		return conflictError
+	return err
+}
+
+// saveNote persists the (new or updated) note.
+func (rd *ReminderData) saveNote(note *Note) error {
+	if rd.readOnly {
+		return ErrorReadOnly
 	}
-	return nil
+	return rd.Store().SaveNote(rd, note)
+}
+
+// saveTag persists the (new or updated) tag.
+func (rd *ReminderData) saveTag(tag *Tag) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	return rd.Store().SaveTag(rd, tag)
 }
 
 // SortedTagSlugs sorts the tags in-place and return slugs.
@@ -249,68 +307,197 @@ func (rd *ReminderData) FindNotesByTagSlug(tagSlug string, status NoteStatus) No
 	return rd.FindNotesByTagId(tag.Id, status)
 }
 
+// NoteByID returns the note with given id.
+// It returns nil if given note is not found.
+func (rd *ReminderData) NoteByID(id string) *Note {
+	return rd.Notes.WithId(id)
+}
+
+// NoteByIDPrefix returns the only note whose id starts with given prefix.
+// This lets humans refer a note by just first few characters of its id (such as its ShortId).
+// An exact match of the complete id is always preferred.
+func (rd *ReminderData) NoteByIDPrefix(prefix string) (*Note, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, ErrorNoteNotFound
+	}
+	if note := rd.NoteByID(prefix); note != nil {
+		return note, nil
+	}
+	notes := rd.Notes.WithIdPrefix(prefix)
+	switch len(notes) {
+	case 0:
+		return nil, ErrorNoteNotFound
+	case 1:
+		return notes[0], nil
+	}
+	return nil, ErrorAmbiguousNoteId
+}
+
 // UpdateNoteText updates note's text.
 func (rd *ReminderData) UpdateNoteText(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.UpdateText(text)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
 }
 
 // UpdateNoteSummary updates the note's summary.
 func (rd *ReminderData) UpdateNoteSummary(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.UpdateSummary(text)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
 }
 
 // UpdateNoteCompleteBy updates the note's due date (complete by).
 func (rd *ReminderData) UpdateNoteCompleteBy(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.UpdateCompleteBy(text)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
+}
+
+// UpdateNoteRecurrence updates the note's recurrence rule.
+func (rd *ReminderData) UpdateNoteRecurrence(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	err := note.UpdateRecurrence(text)
+	if err != nil {
+		return err
+	}
+	return rd.saveNote(note)
 }
 
 // AddNoteComment adds note's comment.
 func (rd *ReminderData) AddNoteComment(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.AddComment(text)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
 }
 
 // UpdateNoteTags updates note's tags.
 func (rd *ReminderData) UpdateNoteTags(note *Note, tagIDs []int) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.UpdateTags(tagIDs)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
 }
 
 // UpdateNoteStatus updates note's status.
+// Marking a recurring note as "done" completes its current occurrence instead (see CompleteNote).
 func (rd *ReminderData) UpdateNoteStatus(note *Note, status NoteStatus) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	if (status == NoteStatus_Done) && rd.IsRecurring(note) {
+		_, err := rd.CompleteNote(note, "")
+		return err
+	}
 	repeatTagIDs := rd.TagIdsForGroup("repeat")
 	err := note.UpdateStatus(status, repeatTagIDs)
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
+}
+
+// SnoozeNote snoozes the note (see Note.Snooze).
+func (rd *ReminderData) SnoozeNote(note *Note, text string) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	err := note.Snooze(text)
+	if err != nil {
+		return err
+	}
+	return rd.saveNote(note)
+}
+
+// IsRecurring tells if the note recurs (see Note.RecurrenceRule).
+func (rd *ReminderData) IsRecurring(note *Note) bool {
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	return note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId) != nil
+}
+
+// CompleteNote marks the current occurrence of the recurring note as done (see Note.Complete).
+func (rd *ReminderData) CompleteNote(note *Note, comment string) (*Completion, error) {
+	if rd.readOnly {
+		return nil, ErrorReadOnly
+	}
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+	completion, err := note.Complete(rule, rd.EffectiveWindow(note, rule).WindowDays, comment)
+	if err != nil {
+		return nil, err
+	}
+	return completion, rd.saveNote(note)
+}
+
+// CompletionHistory returns the completed occurrences of the recurring note, along with its streaks.
+func (rd *ReminderData) CompletionHistory(note *Note) string {
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
+	rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Completion History of %q: -------------------------------------", note.Text))
+	for _, completion := range note.Completions.sorted() {
+		lines = append(lines, fmt.Sprintf("  - %s", completion))
+	}
+	if len(note.Completions) == 0 {
+		lines = append(lines, "  (none)")
+	}
+	currentStreak, longestStreak := note.Streaks(rule, rd.EffectiveWindow(note, rule).WindowDays)
+	lines = append(lines, fmt.Sprintf("Current streak: %d, Longest streak: %d", currentStreak, longestStreak))
+	if rule != nil && note.Status == NoteStatus_Pending {
+		lines = append(lines, fmt.Sprintf("Next due: %s", note.DueDateStr(note.CompleteBy)))
+	}
+	return strings.Join(lines, "\n") + "\n"
 }
 
 // ToggleNoteMainFlag toggles note's priority.
 func (rd *ReminderData) ToggleNoteMainFlag(note *Note) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	err := note.ToggleMainFlag()
 	if err != nil {
 		return err
 	}
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
+}
+
+// UpdateNoteNoSync sets (or unsets) the flag which keeps the note out of the calendar sync.
+func (rd *ReminderData) UpdateNoteNoSync(note *Note, noSync bool) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
+	err := note.SetNoSync(noSync)
+	if err != nil {
+		return err
+	}
+	return rd.saveNote(note)
 }
 
 // RegisterBasicTags registers basic tags.
@@ -420,76 +607,27 @@ func (rd *ReminderData) SearchNotes() error {
 
 // NotesApprachingDueDate fetches all pending notes which are urgent.
 // It accepts view as an argument with "default" or "long" as acceptable values
-// Note: NotesApprachingDueDate is dangerous as it manipulates the due date (CompleteBy) date of repeating tags
-// which can cause persitence of manupulated dates, if the returned data is persisted.
+// A note is urgent as per its due window (see DueWindow); and a recurring note (see Note.RecurrenceRule)
+// is urgent around each of its occurrences.
+// Note: NotesApprachingDueDate sets the (temporary) due date of recurring notes to their matched occurrence.
 func (rd *ReminderData) NotesApprachingDueDate(view string) Notes {
 	allNotes := rd.Notes
-	pendingNotes := allNotes.WithStatus(NoteStatus_Pending)
+	// note: the snoozed notes are hidden until they wake up
+	pendingNotes := allNotes.WithStatus(NoteStatus_Pending).NotSnoozed()
 	// assuming there are at least 100 notes (on average)
 	currentNotes := make([]*Note, 0, 100)
-	repeatTagIDs := rd.TagIdsForGroup("repeat")
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
 	// populate tempDueDate
 	pendingNotes.PopulateTempDueDate()
+	currentTimestamp := utils.CurrentUnixTimestamp()
 	// populating currentNotes
 	for _, note := range pendingNotes {
-		noteIDsWithRepeat := utils.GetCommonMembersOfSlices(note.TagIds, repeatTagIDs)
-		// first process notes WITHOUT tag with group "repeat"
-		// start showing such notes 7 days in advance from their due date, and until they are marked done
-		minDay := note.tempDueDate - 7*24*60*60
-		if view == "long" {
-			minDay = note.tempDueDate - 365*24*60*60
-		}
-		currentTimestamp := utils.CurrentUnixTimestamp()
-		if (len(noteIDsWithRepeat) == 0) && (note.tempDueDate != 0) && (currentTimestamp >= minDay) {
+		rule := note.RecurrenceRule(repeatAnnuallyTagId, repeatMonthlyTagId)
+		if dueDate, _, ok := rd.approachingDueDate(note, rule, view, currentTimestamp); ok {
+			// temporarity update note's timestamp
+			note.tempDueDate = dueDate
 			currentNotes = append(currentNotes, note)
 		}
-		// check notes with tag with group "repeat"
-		// start showing notes with "repeat-annually" 7 days in advance
-		// start showing notes with "repeat-monthly" 3 days in advance
-		// don't show such notes after their due date is past by 2 day
-		if (len(noteIDsWithRepeat) > 0) && (note.tempDueDate != 0) {
-			// check for repeat-annually tag
-			// note: for the tempDueDate date of the note, we accept only date
-			// so, even if there is a time element recorded the the timestamp,
-			// we ignore it
-			repeatAnnuallyTag := rd.TagFromSlug("repeat-annually")
-			repeatMonthlyTag := rd.TagFromSlug("repeat-monthly")
-			if (repeatAnnuallyTag != nil) && utils.IsMemberOfSlice(repeatAnnuallyTag.Id, note.TagIds) {
-				_, noteMonth, noteDay := utils.UnixTimestampToTime(note.tempDueDate).Date()
-				noteTimestampCurrent := utils.UnixTimestampForCorrespondingCurrentYear(int(noteMonth), noteDay)
-				noteTimestampPrevious := noteTimestampCurrent - 365*24*60*60
-				noteTimestampNext := noteTimestampCurrent + 365*24*60*60
-				daysBefore := int64(3) // days before to start showing the note
-				daysAfter := int64(7)  // days after until to show the note
-				if view == "long" {
-					daysBefore = int64(365)
-				}
-				shouldDisplay, matchingTimestamp := utils.MatchedTimestamp(noteTimestampCurrent, noteTimestampPrevious, noteTimestampNext, daysBefore, daysAfter)
-				// temporarity update note's timestamp
-				note.tempDueDate = matchingTimestamp
-				if shouldDisplay {
-					currentNotes = append(currentNotes, note)
-				}
-			}
-			// check for repeat-monthly tag
-			if (repeatMonthlyTag != nil) && utils.IsMemberOfSlice(repeatMonthlyTag.Id, note.TagIds) {
-				_, _, noteDay := utils.UnixTimestampToTime(note.tempDueDate).Date()
-				noteTimestampCurrent := utils.UnixTimestampForCorrespondingCurrentYearMonth(noteDay)
-				noteTimestampPrevious := noteTimestampCurrent - 30*24*60*60
-				noteTimestampNext := noteTimestampCurrent + 30*24*60*60
-				daysBefore := int64(1) // days beofre to start showing the note
-				daysAfter := int64(3)  // days after until to show the note
-				if view == "long" {
-					daysBefore = int64(31)
-				}
-				shouldDisplay, matchingTimestamp := utils.MatchedTimestamp(noteTimestampCurrent, noteTimestampPrevious, noteTimestampNext, daysBefore, daysAfter)
-				// temporarity update note's timestamp
-				note.tempDueDate = matchingTimestamp
-				if shouldDisplay {
-					currentNotes = append(currentNotes, note)
-				}
-			}
-		}
 	}
 	// return unsorted list
 	return currentNotes
@@ -497,6 +635,9 @@ func (rd *ReminderData) NotesApprachingDueDate(view string) Notes {
 
 // NewTagRegistration registers a new tag.
 func (rd *ReminderData) NewTagRegistration() (int, error) {
+	if rd.readOnly {
+		return 0, ErrorReadOnly
+	}
 	// collect and ask info about the tag
 	tagID := rd.nextPossibleTagId()
 
@@ -520,6 +661,9 @@ func (rd *ReminderData) nextPossibleTagId() int {
 
 // newTagAppend appends a new tag.
 func (rd *ReminderData) newTagAppend(tag *Tag) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	// check if tag's slug is already present
 	isNewSlug := true
 	for _, existingTag := range rd.Tags {
@@ -533,18 +677,23 @@ func (rd *ReminderData) newTagAppend(tag *Tag) error {
 	// go ahead and append
 	logger.Info(fmt.Sprintf("Added Tag: %v\n", *tag))
 	rd.Tags = append(rd.Tags, tag)
-	return rd.UpdateDataFile("")
+	return rd.saveTag(tag)
 }
 
 // NewNoteRegistration registers new note.
+// Pass useText to use given text instead of prompting user.
 // The note is saved to the data file.
-func (rd *ReminderData) NewNoteRegistration(tagIDs []int) (*Note, error) {
+func (rd *ReminderData) NewNoteRegistration(tagIDs []int, useText string) (*Note, error) {
+	// note: the read-only mode is checked before prompting for the note
+	if rd.readOnly {
+		return nil, ErrorReadOnly
+	}
 	// collect info about the note
 	if tagIDs == nil {
 		// assuming each note with have on average 2 tags
 		tagIDs = make([]int, 0, 2)
 	}
-	note, err := NewNote(tagIDs, "")
+	note, err := NewNote(tagIDs, useText)
 	// validate and save data
 	if err != nil {
 		return note, err
@@ -559,9 +708,12 @@ func (rd *ReminderData) NewNoteRegistration(tagIDs []int) (*Note, error) {
 // newNoteAppend appends a new note.
 // The note is saved to the data file.
 func (rd *ReminderData) newNoteAppend(note *Note) error {
+	if rd.readOnly {
+		return ErrorReadOnly
+	}
 	logger.Info(fmt.Sprintf("Adding Note: %+v\n", *note))
 	rd.Notes = append(rd.Notes, note)
-	return rd.UpdateDataFile("")
+	return rd.saveNote(note)
 }
 
 // Stats returns current status.
@@ -587,29 +739,22 @@ Stats of "{{.DataFile}}":
 // Like utils.AskOptions, it prints any encountered error, but doesn't return the error.
 func (rd *ReminderData) CreateBackup() (string, error) {
 	// get backup file name
-	ext := path.Ext(rd.DataFile)
-	dstFile := rd.DataFile[:len(rd.DataFile)-len(ext)] + "_backup_" + strconv.FormatInt(int64(utils.CurrentUnixTimestamp()), 10) + ext
-	lnFile := rd.DataFile[:len(rd.DataFile)-len(ext)] + "_backup_latest" + ext
+	dstFile := backupPath(rd.DataFile, utils.CurrentUnixTimestamp())
+	lnFile := latestBackupPath(rd.DataFile)
 	logger.Info(fmt.Sprintf("Creating backup at %q.\n", dstFile))
 	// create backup
 	byteValue, err := os.ReadFile(rd.DataFile)
 	if err != nil {
 		return dstFile, err
 	}
-	err = os.WriteFile(dstFile, byteValue, 0644)
+	err = utils.WriteFileAtomic(dstFile, byteValue, dataFileMode)
 	if err != nil {
 		return dstFile, err
 	}
 	// create alias of latest backup
+	// note: the symlink is relative, so that it keeps working even if the directory is moved
 	logger.Info(fmt.Sprintf("Creating symlink at %q.\n", lnFile))
-	executable, _ := exec.LookPath("ln")
-	cmd := &exec.Cmd{
-		Path:   executable,
-		Args:   []string{executable, "-sf", dstFile, lnFile},
-		Stdout: os.Stdout,
-		Stdin:  os.Stdin,
-	}
-	err = cmd.Run()
+	err = utils.SymlinkAtomic(path.Base(dstFile), lnFile)
 	if err != nil {
 		return dstFile, err
 	}
@@ -619,9 +764,14 @@ func (rd *ReminderData) CreateBackup() (string, error) {
 // DisplayDataFile displays the data file.
 // Like utils.AskOptions, it prints any encountered error, and returns that error just for information.
 func (rd *ReminderData) DisplayDataFile() error {
+	if _, ok := rd.Store().(*JSONStore); !ok {
+		return fmt.Errorf("Displaying the data file %q is supported only for the json store", rd.DataFile)
+	}
+	if Encryption() != nil {
+		return fmt.Errorf("Displaying the data file %q is not supported as it is encrypted", rd.DataFile)
+	}
 	fmt.Printf("Printing contents (and if possible, its difference since last backup) of %q:\n", rd.DataFile)
-	ext := path.Ext(rd.DataFile)
-	lnFile := rd.DataFile[:len(rd.DataFile)-len(ext)] + "_backup_latest" + ext
+	lnFile := latestBackupPath(rd.DataFile)
 	err := utils.PerformWhich("wdiff")
 	if err != nil {
 		fmt.Printf("%v Warning: `wdiff` command is not available\n", utils.Symbols["error"])
@@ -645,7 +795,7 @@ func (rd *ReminderData) AutoBackup(gapSecs int64) (string, error) {
 	lastBackup := rd.LastBackupAt
 	gap := currentTime - lastBackup
 	logger.Info(fmt.Sprintf("Automatic Backup Gap = %vs/%vs\n", gap, gapSecs))
-	if gap < gapSecs {
+	if gap < gapSecs || rd.readOnly {
 		logger.Info(fmt.Sprintln("Skipping automatic backup."))
 		return dstFile, nil
 	}
@@ -705,11 +855,16 @@ func (rd *ReminderData) PrintNoteAndAskOptions(note *Note) string {
 		fmt.Sprintf("%v %v", utils.Symbols["upVote"], "Mark as done"),
 		fmt.Sprintf("%v %v", utils.Symbols["zzz"], "Mark as suspended"),
 		fmt.Sprintf("%v %v", utils.Symbols["downVote"], "Mark as pending"),
+		fmt.Sprintf("%v %v", utils.Symbols["snooze"], "Snooze"),
 		fmt.Sprintf("%v %v", utils.Symbols["calendar"], "Update due date"),
+		fmt.Sprintf("%v %v", utils.Symbols["refresh"], "Update recurrence"),
+		fmt.Sprintf("%v %v", utils.Symbols["clock"], "Update due window"),
+		fmt.Sprintf("%v %v", utils.Symbols["checkerdFlag"], "Completion history"),
 		fmt.Sprintf("%v %v", utils.Symbols["tag"], "Update tags"),
 		fmt.Sprintf("%v %v", utils.Symbols["text"], "Update text"),
 		fmt.Sprintf("%v %v", utils.Symbols["glossary"], "Update summary"),
-		fmt.Sprintf("%v %v", utils.Symbols["hat"], "Toggle main/incidental")},
+		fmt.Sprintf("%v %v", utils.Symbols["hat"], "Toggle main/incidental"),
+		fmt.Sprintf("%v %v", utils.Symbols["prohibited"], "Toggle calendar sync")},
 		"Select Action: ")
 	switch noteOption {
 	case fmt.Sprintf("%v %v", utils.Symbols["comment"], "Add comment"):
@@ -724,6 +879,18 @@ func (rd *ReminderData) PrintNoteAndAskOptions(note *Note) string {
 		fmt.Println("No changes made")
 		fmt.Print(note.ExternalText(rd))
 	case fmt.Sprintf("%v %v", utils.Symbols["upVote"], "Mark as done"):
+		if rd.IsRecurring(note) {
+			// complete the current occurrence of a recurring note
+			promptText, err := utils.GeneratePrompt("note_completion_comment", "")
+			utils.LogError(err)
+			completion, err := rd.CompleteNote(note, promptText)
+			utils.LogError(err)
+			if err == nil {
+				fmt.Printf("Completed the occurrence due on %s\n", note.DueDateStr(completion.DueDate))
+			}
+			fmt.Print(note.ExternalText(rd))
+			break
+		}
 		err := rd.UpdateNoteStatus(note, NoteStatus_Done)
 		utils.LogError(err)
 		fmt.Print(note.ExternalText(rd))
@@ -735,12 +902,41 @@ func (rd *ReminderData) PrintNoteAndAskOptions(note *Note) string {
 		err := rd.UpdateNoteStatus(note, NoteStatus_Pending)
 		utils.LogError(err)
 		fmt.Print(note.ExternalText(rd))
+	case fmt.Sprintf("%v %v", utils.Symbols["snooze"], "Snooze"):
+		promptText, err := utils.GeneratePrompt("note_snooze", "")
+		utils.LogError(err)
+		err = rd.SnoozeNote(note, promptText)
+		utils.LogError(err)
+		fmt.Print(note.ExternalText(rd))
 	case fmt.Sprintf("%v %v", utils.Symbols["calendar"], "Update due date"):
 		promptText, err := utils.GeneratePrompt("note_completed_by", "")
 		utils.LogError(err)
+		// preview the resolved due date before saving it
+		if dueDate, err := utils.ParseDueDate(promptText, note.Location()); err == nil {
+			save, err := utils.AskBoolean(fmt.Sprintf("Due date resolves to %s. Save it?", utils.DueDateToPreviewStr(dueDate)))
+			utils.LogError(err)
+			if !save {
+				fmt.Println("No changes made")
+				break
+			}
+		}
 		err = rd.UpdateNoteCompleteBy(note, promptText)
 		utils.LogError(err)
 		fmt.Print(note.ExternalText(rd))
+	case fmt.Sprintf("%v %v", utils.Symbols["clock"], "Update due window"):
+		promptText, err := utils.GeneratePrompt("note_due_window", formatDueWindow(note.DueWindow))
+		utils.LogError(err)
+		err = rd.UpdateNoteDueWindow(note, promptText)
+		utils.LogError(err)
+		fmt.Print(note.ExternalText(rd))
+	case fmt.Sprintf("%v %v", utils.Symbols["checkerdFlag"], "Completion history"):
+		fmt.Print(rd.CompletionHistory(note))
+	case fmt.Sprintf("%v %v", utils.Symbols["refresh"], "Update recurrence"):
+		promptText, err := utils.GeneratePrompt("note_recurrence", note.Recurrence)
+		utils.LogError(err)
+		err = rd.UpdateNoteRecurrence(note, promptText)
+		utils.LogError(err)
+		fmt.Print(note.ExternalText(rd))
 	case fmt.Sprintf("%v %v", utils.Symbols["text"], "Update text"):
 		promptText, err := utils.GeneratePrompt("note_text", note.Text)
 		utils.LogError(err)
@@ -766,10 +962,37 @@ func (rd *ReminderData) PrintNoteAndAskOptions(note *Note) string {
 		err := rd.ToggleNoteMainFlag(note)
 		utils.LogError(err)
 		fmt.Print(note.ExternalText(rd))
+	case fmt.Sprintf("%v %v", utils.Symbols["prohibited"], "Toggle calendar sync"):
+		err := rd.UpdateNoteNoSync(note, !note.NoSync)
+		utils.LogError(err)
+		fmt.Print(note.ExternalText(rd))
 	}
 	return "stay"
 }
 
+// ExportNotes asks for an output format, and prints given notes in that format.
+// Like utils.AskOptions, it prints any encountered error, and returns that error just for information.
+func (rd *ReminderData) ExportNotes(notes Notes) error {
+	var formats []string
+	for _, format := range OutputFormats() {
+		formats = append(formats, string(format))
+	}
+	_, formatName, err := utils.AskOption(formats, "Select Format: ")
+	if err != nil {
+		return err
+	}
+	format, err := ParseOutputFormat(formatName)
+	if err != nil {
+		return err
+	}
+	text, err := rd.RenderNotes(notes, format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
+
 // PrintNotesAndAskOptions (recursively) prints notes interactively.
 // In some cases, updated list notes will be fetched, so blank notes can be passed in those cases.
 // Unless notes are to be fetched, the passed `status` doesn't make sense, so in such cases it can be passed as "fake".
@@ -777,10 +1000,11 @@ func (rd *ReminderData) PrintNoteAndAskOptions(note *Note) string {
 // It accepts following values for `display_mode`:
 // - "done_notes": fetch only done notes
 // - "suspended_notes": fetch only suspended notes
+// - "snoozed_notes": fetch pending notes which are snoozed
 // - "pending_tag_notes": fetch pending notes with given tagID
 // - "pending_only_main_notes": fetch pending notes with IsMain set as true
 // - "pending_approaching_notes": fetch pending notes with approaching due date
-// - "pending_long_view_notes": fetch long-view (52 weeks) of pending notes
+// - "pending_long_view_notes": fetch long-view (see DueWindowOptions.LongView) of pending notes
 // - "passed_notes": use passed notes
 func (rd *ReminderData) PrintNotesAndAskOptions(notes Notes, display_mode string, tagID int, sortBy string) error {
 	// check if passed notes is to be used or to fetch latest notes
@@ -795,15 +1019,20 @@ func (rd *ReminderData) PrintNotesAndAskOptions(notes Notes, display_mode string
 		// fetch all the done notes
 		notes = rd.Notes.WithStatus(NoteStatus_Suspended)
 		fmt.Printf("A total of %v notes marked as 'suspended':\n", len(notes))
+	case "snoozed_notes":
+		// ignore the passed notes
+		// fetch all the pending notes which are snoozed
+		notes = rd.Notes.WithStatus(NoteStatus_Pending).Snoozed()
+		fmt.Printf("A total of %v pending notes snoozed (they show up again by themselves once woken up):\n", len(notes))
 	case "pending_tag_notes":
 		// this is for listing all notes associated with given tag, with asked status
 		// fetch pending notes with given tagID
-		notes = rd.FindNotesByTagId(tagID, NoteStatus_Pending)
+		notes = rd.FindNotesByTagId(tagID, NoteStatus_Pending).NotSnoozed()
 	case "pending_only_main_notes":
 		// this is for listing all main notes, with asked status
 		notes = rd.Notes.OnlyMain()
 		countAllMain := len(notes)
-		notes = notes.WithStatus(NoteStatus_Pending)
+		notes = notes.WithStatus(NoteStatus_Pending).NotSnoozed()
 		countPendingMain := len(notes)
 		fmt.Printf("A total of %v/%v notes flagged as 'main':\n", countPendingMain, countAllMain)
 	case "pending_long_view_notes":
@@ -814,11 +1043,18 @@ func (rd *ReminderData) PrintNotesAndAskOptions(notes Notes, display_mode string
 		// fetch notes approaching due date
 		fmt.Println("Note: A note can be in 'pending', 'suspended' or 'done' status.")
 		fmt.Println("Note: Notes marked as 'pending' are special and they show up everywhere, whereas notes with other status only show up in 'Search' or under their dedicated menu.")
-		fmt.Println("Note: Following are the pending notes with due date:")
-		fmt.Println("      - within a week or already crossed (for non repeat-annually or repeat-monthly)")
-		fmt.Println("      - within 3 days for repeat-annually and a week post due date (ignoring its year)")
-		fmt.Println("      - within 1 day for repeat-monthly and 3 days post due date (ignoring its year and month)")
-		fmt.Println("Note: The process may automatically adjust CompleteBy (due-date) with MM-YYYY for monthly repeating notes and YYYY for yearly repeating ones. This is done as part of search algorithm, and it does not impacts on any visibility of those notes.")
+		opts := rd.dueWindowOptions
+		if opts == nil {
+			opts = DefaultDueWindowOptions()
+		}
+		fmt.Println("Note: Following are the pending notes with due date (by default, as per the settings):")
+		fmt.Printf("      - within %s or already crossed (for non-recurring notes)\n", daysStr(opts.OneOff.LeadDays))
+		fmt.Printf("      - within %s before and %s after an occurrence of yearly recurring notes (such as with repeat-annually tag)\n", daysStr(opts.Yearly.LeadDays), daysStr(opts.Yearly.GraceDays))
+		fmt.Printf("      - within %s before and %s after an occurrence of monthly recurring notes (such as with repeat-monthly tag)\n", daysStr(opts.Monthly.LeadDays), daysStr(opts.Monthly.GraceDays))
+		fmt.Printf("      - within %s before and %s after an occurrence of weekly recurring notes\n", daysStr(opts.Weekly.LeadDays), daysStr(opts.Weekly.GraceDays))
+		fmt.Printf("      - within %s before and %s after an occurrence of daily recurring notes\n", daysStr(opts.Daily.LeadDays), daysStr(opts.Daily.GraceDays))
+		fmt.Println("Note: These lead and grace days can be overridden for a tag, and for a note (see \"Update due window\").")
+		fmt.Println("Note: The recurrences of a note start from its due date, as per its recurrence rule (see \"Update recurrence\").")
 		notes = rd.NotesApprachingDueDate("default")
 	case "passed_notes":
 		// use passed notes
@@ -838,13 +1074,12 @@ func (rd *ReminderData) PrintNotesAndAskOptions(notes Notes, display_mode string
 	case "default":
 		sort.Sort(Notes(notes))
 	}
-	repeatAnnuallyTag := rd.TagFromSlug("repeat-annually")
-	repeatMonthlyTag := rd.TagFromSlug("repeat-monthly")
+	repeatAnnuallyTagId, repeatMonthlyTagId := rd.repeatTagIds()
 	width, err := utils.TerminalWidth()
 	if err != nil {
 		return err
 	}
-	texts := notes.ExternalTexts(width-50, repeatAnnuallyTag.Id, repeatMonthlyTag.Id)
+	texts := notes.ExternalTexts(width-50, repeatAnnuallyTagId, repeatMonthlyTagId)
 
 	// ask user to select a note
 	promptText := ""
@@ -853,18 +1088,27 @@ func (rd *ReminderData) PrintNotesAndAskOptions(notes Notes, display_mode string
 	} else {
 		promptText = "Select Note: "
 	}
-	noteIndex, _, err := utils.AskOption(append(texts, fmt.Sprintf("%v %v", utils.Symbols["add"], "Add Note")), promptText)
+	noteIndex, _, err := utils.AskOption(append(texts,
+		fmt.Sprintf("%v %v", utils.Symbols["add"], "Add Note"),
+		fmt.Sprintf("%v %v", utils.Symbols["pad"], "Export Notes")), promptText)
 	if (err != nil) || (noteIndex == -1) {
 		return err
 	}
 
+	// export the listed notes
+	if noteIndex == len(texts)+1 {
+		err = rd.ExportNotes(notes)
+		utils.LogError(err)
+		return rd.PrintNotesAndAskOptions(notes, "passed_notes", tagID, sortBy)
+	}
+
 	// create new note
 	if noteIndex == len(texts) {
 		// add new note
 		if tagID < 0 {
 			return errors.New("The passed tagID is invalid!")
 		}
-		note, err := rd.NewNoteRegistration([]int{tagID})
+		note, err := rd.NewNoteRegistration([]int{tagID}, "")
 		if err != nil {
 			return err
 		}
@@ -266,6 +266,58 @@ func TestFindNotesByTagSlug(t *testing.T) {
 	utils.AssertEqual(t, reminderData.FindNotesByTagSlug("a", model.NoteStatus_Done), []*model.Note{})
 }
 
+func TestNoteByIDPrefix(t *testing.T) {
+	note1 := model.Note{Text: "1", Id: "3f2a9c1e-0000"}
+	note2 := model.Note{Text: "2", Id: "3f2b0000-0000"}
+	note3 := model.Note{Text: "3", Id: "3f2a"}
+	reminderData := model.ReminderData{Notes: model.Notes{&note1, &note2, &note3}}
+	// case 1 (complete id)
+	utils.AssertEqual(t, reminderData.NoteByID("3f2b0000-0000"), &note2)
+	utils.AssertEqual(t, reminderData.NoteByID("3f2b"), nil)
+	// case 2 (unique prefix)
+	got, err := reminderData.NoteByIDPrefix("3f2b")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, got, &note2)
+	// case 3 (exact match is preferred over ambiguous prefix)
+	got, err = reminderData.NoteByIDPrefix("3f2a")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, got, &note3)
+	// case 4 (ambiguous prefix)
+	_, err = reminderData.NoteByIDPrefix("3f2")
+	utils.AssertEqual(t, err, model.ErrorAmbiguousNoteId)
+	// case 5 (no match)
+	_, err = reminderData.NoteByIDPrefix("ffff")
+	utils.AssertEqual(t, err, model.ErrorNoteNotFound)
+	_, err = reminderData.NoteByIDPrefix(" ")
+	utils.AssertEqual(t, err, model.ErrorNoteNotFound)
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	reminderData.SetReadOnly(true)
+	utils.AssertEqual(t, reminderData.ReadOnly(), true)
+	// nothing is written to the data file
+	reminderData.Notes = model.Notes{&model.Note{Text: "1", Status: model.NoteStatus_Pending}}
+	utils.AssertEqual(t, reminderData.UpdateDataFile(""), model.ErrorReadOnly)
+	utils.AssertEqual(t, reminderData.PersistMigrations(), nil)
+	remiderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(remiderDataRe.Notes), 0)
+	// the notes are rejected before being changed
+	note := reminderData.Notes[0]
+	utils.AssertEqual(t, reminderData.UpdateNoteText(note, "2"), model.ErrorReadOnly)
+	utils.AssertEqual(t, note.Text, "1")
+	utils.AssertEqual(t, reminderData.UpdateNoteStatus(note, model.NoteStatus_Done), model.ErrorReadOnly)
+	utils.AssertEqual(t, note.Status, model.NoteStatus_Pending)
+	_, err := reminderData.NewNoteRegistration([]int{}, "3")
+	utils.AssertEqual(t, err, model.ErrorReadOnly)
+	utils.AssertEqual(t, len(reminderData.Notes), 1)
+}
+
 func TestNewTagRegistration(t *testing.T) {
 	dataFilePath := path.Join("..", "..", "test", "test_data_file.json")
 	reminderData, _ := model.ReadDataFile(dataFilePath, false)
@@ -426,6 +478,64 @@ func TestNotesApproachingDueDate(t *testing.T) {
 	// [NRP01a NRP02a NRP02b NRP03a NRP04a NRP04b NRP05a NRP05b NRP06a RAP02 RAP03 RAP04 RAP05 RAP08 RAP09 RAP10 RAP11 RAP14 RAP15 RAP16 RAP17 RMP02 RMP03]}
 }
 
+func TestNotesApproachingDueDateWithRecurrence(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	_ = reminderData.RegisterBasicTags()
+	currentTime := utils.CurrentUnixTimestamp()
+	repeatMonthlyTagId := reminderData.TagFromSlug("repeat-monthly").Id
+	reminderData.Notes = model.Notes{
+		{Text: "daily", Status: model.NoteStatus_Pending, CompleteBy: currentTime - 10*24*3600, Recurrence: "FREQ=DAILY"},
+		{Text: "weekly, next in 2 days", Status: model.NoteStatus_Pending, CompleteBy: currentTime - 5*24*3600, Recurrence: "FREQ=WEEKLY"},
+		{Text: "weekly, a day ago", Status: model.NoteStatus_Pending, CompleteBy: currentTime - 8*24*3600, Recurrence: "FREQ=WEEKLY"},
+		{Text: "monthly, ended", Status: model.NoteStatus_Pending, CompleteBy: currentTime - 40*24*3600, Recurrence: "FREQ=MONTHLY;COUNT=1"},
+		// the recurrence rule takes precedence over the repeat tag
+		{Text: "yearly, 5 days ago", Status: model.NoteStatus_Pending, TagIds: []int{repeatMonthlyTagId}, CompleteBy: currentTime - 5*24*3600, Recurrence: "FREQ=YEARLY"},
+		{Text: "daily, suspended", Status: model.NoteStatus_Suspended, CompleteBy: currentTime - 10*24*3600, Recurrence: "FREQ=DAILY"},
+	}
+	notesText := func(notes model.Notes) []string {
+		var texts []string
+		for _, note := range notes {
+			texts = append(texts, note.Text)
+		}
+		return texts
+	}
+	utils.AssertEqual(t, notesText(reminderData.NotesApprachingDueDate("default")), []string{"daily", "weekly, a day ago", "yearly, 5 days ago"})
+	utils.AssertEqual(t, notesText(reminderData.NotesApprachingDueDate("long")), []string{"daily", "weekly, next in 2 days", "weekly, a day ago", "yearly, 5 days ago"})
+	// the lead days of the long view are configurable as well
+	opts := model.DefaultDueWindowOptions()
+	opts.LongView.Weekly = 1
+	reminderData.SetDueWindowOptions(opts)
+	utils.AssertEqual(t, notesText(reminderData.NotesApprachingDueDate("long")), []string{"daily", "weekly, a day ago", "yearly, 5 days ago"})
+}
+
+func TestNotesApproachingDueDateWithSnooze(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	currentTime := utils.CurrentUnixTimestamp()
+	reminderData.Notes = model.Notes{
+		{Text: "due tomorrow", Status: model.NoteStatus_Pending, CompleteBy: currentTime + 24*3600},
+		{Text: "due tomorrow, snoozed", Status: model.NoteStatus_Pending, CompleteBy: currentTime + 24*3600},
+		{Text: "due tomorrow, woken up", Status: model.NoteStatus_Pending, CompleteBy: currentTime + 24*3600, DeferUntil: currentTime - 60},
+	}
+	err := reminderData.SnoozeNote(reminderData.Notes[1], "2 days")
+	utils.AssertEqual(t, err, nil)
+	var texts []string
+	for _, note := range reminderData.NotesApprachingDueDate("default") {
+		texts = append(texts, note.Text)
+	}
+	utils.AssertEqual(t, texts, []string{"due tomorrow", "due tomorrow, woken up"})
+	utils.AssertEqual(t, reminderData.Notes.Snoozed(), model.Notes{reminderData.Notes[1]})
+}
+
 func TestPrintStats(t *testing.T) {
 	var dataFilePath = "temp_test_dir/mydata.json"
 	// make sure temporary files and dirs are removed at the end of the test
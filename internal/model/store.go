@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+)
+
+/*
+A Store represents the storage backend in which the reminder data is persisted.
+
+The JSON data file (see JSONStore) is the default store, whereas the
+SQLite database (see SQLiteStore) saves each note and tag individually
+instead of re-writing the whole data on every change.
+*/
+type Store interface {
+	// Path returns path of the underlying file.
+	Path() string
+	// Exists tells if the store has been created already.
+	Exists() (bool, error)
+	// Create persists the newly created data.
+	Create(rd *ReminderData) error
+	// Load loads the whole data.
+	Load() (*ReminderData, error)
+	// Save persists the whole data.
+	Save(rd *ReminderData) error
+	// SaveNote persists the (new or updated) note of the data.
+	SaveNote(rd *ReminderData, note *Note) error
+	// SaveTag persists the (new or updated) tag of the data.
+	SaveTag(rd *ReminderData, tag *Tag) error
+	// Restore replaces the underlying file with the given backup of it.
+	Restore(backupFile string) error
+	// Transaction runs fn such that everything saved through tx is persisted together (or not at all).
+	Transaction(fn func(tx Store) error) error
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+type StoreKind string
+
+const (
+	StoreKind_JSON   StoreKind = "json"
+	StoreKind_SQLite StoreKind = "sqlite"
+)
+
+// OpenStore opens the store of given kind at given path.
+// A blank kind means the default (JSON) store.
+func OpenStore(kind StoreKind, dataFilePath string) (Store, error) {
+	switch kind {
+	case "", StoreKind_JSON:
+		return NewJSONStore(dataFilePath), nil
+	case StoreKind_SQLite:
+		return OpenSQLiteStore(dataFilePath)
+	}
+	return nil, fmt.Errorf("Unknown store %q; supported stores are %v", kind, []StoreKind{StoreKind_JSON, StoreKind_SQLite})
+}
+
+// MakeSureStoreExists makes sure that the store exists; a new store is created with basic tags.
+func MakeSureStoreExists(store Store, askUserInput bool) error {
+	exists, err := store.Exists()
+	if err != nil || exists {
+		return err
+	}
+	dataFilePath := store.Path()
+	logger.Info(fmt.Sprintf("Generating new data file %q.\n", dataFilePath))
+	if err := os.MkdirAll(path.Dir(dataFilePath), 0751); err != nil {
+		return err
+	}
+	reminderData, err := BlankReminder(askUserInput, dataFilePath)
+	if err != nil {
+		return err
+	}
+	reminderData.DataFile = dataFilePath // save absolute path
+	reminderData.SetStore(store)
+	if err := reminderData.CreateDataFile("Persisting the newly created data file"); err != nil {
+		return err
+	}
+	return reminderData.RegisterBasicTags()
+}
+
+// MigrateStore copies the whole data to the destination store, which must not exist already.
+func MigrateStore(rd *ReminderData, dst Store) error {
+	exists, err := dst.Exists()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("The destination %q already exists", dst.Path())
+	}
+	if err := os.MkdirAll(path.Dir(dst.Path()), 0751); err != nil {
+		return err
+	}
+	migrated := *rd
+	migrated.DataFile = dst.Path()
+	migrated.readOnly = false
+	migrated.SetStore(dst)
+	if err := dst.Create(&migrated); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Migrated %d notes and %d tags from %q to %q.", len(rd.Notes), len(rd.Tags), rd.Store().Path(), dst.Path()))
+	return nil
+}
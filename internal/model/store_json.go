@@ -0,0 +1,181 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+/*
+A JSONStore is the store backed by a single (pretty-printed) JSON data file.
+
+The whole data is re-written on every change. The data file is encrypted if
+the encryption is enabled (see SetEncryption).
+*/
+type JSONStore struct {
+	DataFile string
+}
+
+// NewJSONStore returns the store for the JSON data file at given path.
+func NewJSONStore(dataFilePath string) *JSONStore {
+	return &JSONStore{DataFile: utils.TryConvertTildaBasedPath(dataFilePath)}
+}
+
+func (s *JSONStore) Path() string {
+	return s.DataFile
+}
+
+func (s *JSONStore) Exists() (bool, error) {
+	_, err := os.Stat(s.DataFile)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Error finding existing data file: %v\n", err))
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *JSONStore) Create(rd *ReminderData) error {
+	// update UpdatedAt field
+	// note that UpdatedAt of a whole ReminderData object is different
+	// from corresponding field of each note
+	currentTime := utils.CurrentUnixTimestamp()
+	rd.UpdatedAt = currentTime
+	rd.CreatedAt = currentTime
+	// marshal the data
+	byteValue, err := json.MarshalIndent(&rd, "", "    ")
+	if err != nil {
+		return err
+	}
+	// persist the byte data to file
+	return writeDataFile(rd.DataFile, byteValue)
+}
+
+func (s *JSONStore) Load() (*ReminderData, error) {
+	rd, err := ReadDataFile(s.DataFile, false)
+	if err != nil {
+		return nil, err
+	}
+	// note: the path persisted in the data file is stale if the data file was moved (or restored from a backup)
+	rd.DataFile = s.DataFile
+	rd.SetStore(s)
+	return rd, nil
+}
+
+// Save re-writes the whole data file.
+// If the data file was updated by another process in the meanwhile, the data is instead
+// saved to a _CONFLICT file, and ErrorConflictFile is returned.
+func (s *JSONStore) Save(rd *ReminderData) error {
+	var conflictError error
+	// if UpdatedAt timestamp of currently loaded data is not same as timestamp persisted on datafile
+	// some other process would have updated the data file, which can lead to data inconsistency
+	persistedData, err := ReadDataFile(rd.DataFile, true)
+	if err != nil {
+		return err
+	}
+	persistedTimestamp := persistedData.UpdatedAt
+	inMemoryTimestamp := rd.UpdatedAt
+	currentTimestamp := utils.CurrentUnixTimestamp()
+	logger.Info(fmt.Sprintf("In-memory timestamp: %v, Persisted timestamp: %v, Current Timestamp (being persisted): %v", inMemoryTimestamp, persistedTimestamp, currentTimestamp))
+	if persistedTimestamp != inMemoryTimestamp {
+		newFilePath := fmt.Sprintf("%s_CONFLICT_%d", rd.DataFile, currentTimestamp)
+		rd.DataFile = newFilePath
+		logger.Error(fmt.Sprintf("It seems another instance of the application updated the data file; the data will instead be saved to confict file %q; run `reminder merge %q` to merge it back.", newFilePath, newFilePath))
+		conflictError = ErrorConflictFile
+	}
+	// update UpdatedAt field
+	// note that UpdatedAt of a whole ReminderData object is different
+	// from corresponding field of each note
+	rd.UpdatedAt = currentTimestamp
+	// marshal the data
+	// Refer https://pkg.go.dev/encoding/json#MarshalIndent
+	// Note: String values encoded as JSON strings are coerced to valid
+	// UTF-8, replacing invalid bytes with Unicode replacement rune. So
+	// that the JSON will be safe to embed inside HTML <script> tags, the
+	// string is encoded using HTMLEscape.
+	// For example, a text such as `comment with < and "` will be written
+	// as `"comment with \u003c and \"` but it will read back same as the
+	// original string
+	byteValue, err := json.MarshalIndent(&rd, "", "    ")
+	if err != nil {
+		return err
+	}
+	// persist the byte data to file
+	// note: the write is atomic, so a crash midway never leaves a truncated data file
+	err = writeDataFile(rd.DataFile, byteValue)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Updated the data file %q at %v!", rd.DataFile, rd.UpdatedAt))
+	return conflictError
+}
+
+// SaveNote re-writes the whole data file, as a single note can't be saved on its own.
+func (s *JSONStore) SaveNote(rd *ReminderData, note *Note) error {
+	return s.Save(rd)
+}
+
+// SaveTag re-writes the whole data file, as a single tag can't be saved on its own.
+func (s *JSONStore) SaveTag(rd *ReminderData, tag *Tag) error {
+	return s.Save(rd)
+}
+
+// Restore replaces the data file with the given backup (keeping the current one as its rolling backup).
+// The backup is re-encrypted (or decrypted) as per the current encryption setting.
+func (s *JSONStore) Restore(backupFile string) error {
+	byteValue, err := os.ReadFile(backupFile)
+	if err != nil {
+		return err
+	}
+	if byteValue, err = decodeDataFile(backupFile, byteValue, dataFileVault); err != nil {
+		return err
+	}
+	return writeDataFile(s.DataFile, byteValue)
+}
+
+// Transaction defers all the saves through tx to a single re-write of the data file at the end.
+func (s *JSONStore) Transaction(fn func(tx Store) error) error {
+	tx := &jsonTransaction{JSONStore: s}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if tx.pending == nil {
+		return nil
+	}
+	return s.Save(tx.pending)
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// jsonTransaction is the JSONStore which just records the data to be saved at the end of the transaction.
+type jsonTransaction struct {
+	*JSONStore
+	pending *ReminderData
+}
+
+func (tx *jsonTransaction) Save(rd *ReminderData) error {
+	tx.pending = rd
+	return nil
+}
+
+func (tx *jsonTransaction) SaveNote(rd *ReminderData, note *Note) error {
+	return tx.Save(rd)
+}
+
+func (tx *jsonTransaction) SaveTag(rd *ReminderData, tag *Tag) error {
+	return tx.Save(rd)
+}
+
+// Transaction runs nested transaction as part of the current one.
+func (tx *jsonTransaction) Transaction(fn func(tx Store) error) error {
+	return fn(tx)
+}
@@ -0,0 +1,333 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+
+	// pure-Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema is the schema of the SQLite store.
+// Each note and tag is kept as JSON (same as in the JSON data file), along
+// with the columns required to order and query them.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS reminder (
+	id         INTEGER PRIMARY KEY CHECK (id = 1),
+	data       TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tags (
+	id       INTEGER PRIMARY KEY,
+	position INTEGER NOT NULL,
+	slug     TEXT NOT NULL UNIQUE,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS notes (
+	id          TEXT PRIMARY KEY,
+	position    INTEGER NOT NULL,
+	status      TEXT NOT NULL,
+	complete_by INTEGER NOT NULL,
+	updated_at  INTEGER NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notes_by_status ON notes (status, complete_by);
+`
+
+// sqlConn is the common interface of *sql.DB and *sql.Tx.
+type sqlConn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+/*
+A SQLiteStore is the store backed by an embedded SQLite database file.
+
+Unlike JSONStore, a change to a note (or a tag) saves just that note (or tag).
+*/
+type SQLiteStore struct {
+	DataFile string
+	db       *sql.DB
+	conn     sqlConn
+}
+
+// OpenSQLiteStore opens (and if required, creates) the SQLite database at given path.
+func OpenSQLiteStore(dataFilePath string) (*SQLiteStore, error) {
+	dataFilePath = utils.TryConvertTildaBasedPath(dataFilePath)
+	if err := os.MkdirAll(path.Dir(dataFilePath), 0751); err != nil {
+		return nil, err
+	}
+	db, err := openSQLiteDB(dataFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(dataFilePath, dataFileMode); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{DataFile: dataFilePath, db: db, conn: db}, nil
+}
+
+// openSQLiteDB opens the database, and makes sure its schema exists.
+func openSQLiteDB(dataFilePath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dataFilePath)
+	if err != nil {
+		return nil, err
+	}
+	// note: a single connection is sufficient, as only one session updates the data at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Unable to open the database %q: %w", dataFilePath, err)
+	}
+	return db, nil
+}
+
+func (s *SQLiteStore) Path() string {
+	return s.DataFile
+}
+
+func (s *SQLiteStore) Exists() (bool, error) {
+	var count int
+	err := s.conn.QueryRow("SELECT COUNT(*) FROM reminder").Scan(&count)
+	return count > 0, err
+}
+
+func (s *SQLiteStore) Create(rd *ReminderData) error {
+	currentTime := utils.CurrentUnixTimestamp()
+	rd.UpdatedAt = currentTime
+	rd.CreatedAt = currentTime
+	return s.Save(rd)
+}
+
+func (s *SQLiteStore) Load() (*ReminderData, error) {
+	var rd ReminderData
+	var data string
+	err := s.conn.QueryRow("SELECT data FROM reminder WHERE id = 1").Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("The database %q is empty", s.DataFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(data), &rd); err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrorCorruptDataFile, s.DataFile, err)
+	}
+	if err := queryJSONRows(s.conn, "SELECT data FROM tags ORDER BY position", func() interface{} {
+		tag := &Tag{}
+		rd.Tags = append(rd.Tags, tag)
+		return tag
+	}); err != nil {
+		return nil, err
+	}
+	if err := queryJSONRows(s.conn, "SELECT data FROM notes ORDER BY position", func() interface{} {
+		note := &Note{}
+		rd.Notes = append(rd.Notes, note)
+		return note
+	}); err != nil {
+		return nil, err
+	}
+	if rd.Notes == nil {
+		rd.Notes = Notes{}
+	}
+	if rd.Tags == nil {
+		rd.Tags = Tags{}
+	}
+	rd.DataFile = s.DataFile
+	rd.SetStore(s)
+	// upgrade the data persisted by older versions of the app
+	if err := rd.migrate(); err != nil {
+		return nil, err
+	}
+	return &rd, nil
+}
+
+// queryJSONRows unmarshals JSON of each of the returned rows into the value returned by next.
+func queryJSONRows(conn sqlConn, query string, next func() interface{}) error {
+	rows, err := conn.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(data), next()); err != nil {
+			return fmt.Errorf("%w: %v", ErrorCorruptDataFile, err)
+		}
+	}
+	return rows.Err()
+}
+
+// Save re-writes all the notes and tags.
+func (s *SQLiteStore) Save(rd *ReminderData) error {
+	return s.Transaction(func(tx Store) error {
+		conn := tx.(*SQLiteStore).conn
+		// note: notes are identified by their ids in the database
+		rd.Notes.BackfillIds()
+		if err := saveReminderRow(conn, rd); err != nil {
+			return err
+		}
+		if _, err := conn.Exec("DELETE FROM tags"); err != nil {
+			return err
+		}
+		for i, tag := range rd.Tags {
+			if err := saveTagRow(conn, i, tag); err != nil {
+				return err
+			}
+		}
+		if _, err := conn.Exec("DELETE FROM notes"); err != nil {
+			return err
+		}
+		for i, note := range rd.Notes {
+			if err := saveNoteRow(conn, i, note); err != nil {
+				return err
+			}
+		}
+		logger.Info(fmt.Sprintf("Updated the database %q at %v!", s.DataFile, rd.UpdatedAt))
+		return nil
+	})
+}
+
+// SaveNote saves just the given note.
+// Note: The note keeps its position among the notes (or, a new note is added at the end), irrespective
+// of any re-sorting of the notes of the data (such as by SearchNotes).
+func (s *SQLiteStore) SaveNote(rd *ReminderData, note *Note) error {
+	if !utils.IsMemberOfSlice(note, rd.Notes) {
+		return fmt.Errorf("The note %q is not part of the data", note.Text)
+	}
+	if note.Id == "" {
+		note.Id = NewNoteId()
+	}
+	return s.Transaction(func(tx Store) error {
+		conn := tx.(*SQLiteStore).conn
+		if err := saveReminderRow(conn, rd); err != nil {
+			return err
+		}
+		position, err := rowPosition(conn, "notes", note.Id)
+		if err != nil {
+			return err
+		}
+		return saveNoteRow(conn, position, note)
+	})
+}
+
+// SaveTag saves just the given tag.
+// Note: Like SaveNote, the tag keeps its position among the tags.
+func (s *SQLiteStore) SaveTag(rd *ReminderData, tag *Tag) error {
+	if !utils.IsMemberOfSlice(tag, rd.Tags) {
+		return fmt.Errorf("The tag %q is not part of the data", tag.Slug)
+	}
+	return s.Transaction(func(tx Store) error {
+		conn := tx.(*SQLiteStore).conn
+		if err := saveReminderRow(conn, rd); err != nil {
+			return err
+		}
+		position, err := rowPosition(conn, "tags", tag.Id)
+		if err != nil {
+			return err
+		}
+		return saveTagRow(conn, position, tag)
+	})
+}
+
+// rowPosition returns the current position of the row with given id in the table, or the position
+// after the last row if there is no such row yet.
+func rowPosition(conn sqlConn, table string, id interface{}) (int, error) {
+	var position int
+	err := conn.QueryRow(`SELECT COALESCE((SELECT position FROM `+table+` WHERE id = ?), (SELECT COALESCE(MAX(position), -1) + 1 FROM `+table+`))`, id).Scan(&position)
+	return position, err
+}
+
+// Restore replaces the database with the given backup, and re-opens it.
+func (s *SQLiteStore) Restore(backupFile string) error {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return errors.New("The database can't be restored within a transaction")
+	}
+	byteValue, err := os.ReadFile(backupFile)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	// note: the database is re-opened even if the restore fails
+	restoreErr := utils.WriteFileAtomic(s.DataFile, byteValue, dataFileMode)
+	db, err := openSQLiteDB(s.DataFile)
+	if err != nil {
+		return err
+	}
+	s.db, s.conn = db, db
+	return restoreErr
+}
+
+// Transaction runs fn within a database transaction.
+// A transaction nested within another one is run as part of the outer transaction.
+func (s *SQLiteStore) Transaction(fn func(tx Store) error) error {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return fn(s)
+	}
+	sqlTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(&SQLiteStore{DataFile: s.DataFile, db: s.db, conn: sqlTx}); err != nil {
+		_ = sqlTx.Rollback()
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// saveReminderRow saves rest of the data (such as user details), apart from notes and tags.
+func saveReminderRow(conn sqlConn, rd *ReminderData) error {
+	rd.UpdatedAt = utils.CurrentUnixTimestamp()
+	rest := *rd
+	rest.Notes, rest.Tags = nil, nil
+	data, err := json.Marshal(&rest)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(`INSERT INTO reminder (id, data, updated_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`, string(data), rd.UpdatedAt)
+	return err
+}
+
+func saveTagRow(conn sqlConn, position int, tag *Tag) error {
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(`INSERT INTO tags (id, position, slug, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET position = excluded.position, slug = excluded.slug, data = excluded.data`,
+		tag.Id, position, tag.Slug, string(data))
+	return err
+}
+
+func saveNoteRow(conn sqlConn, position int, note *Note) error {
+	data, err := json.Marshal(note)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(`INSERT INTO notes (id, position, status, complete_by, updated_at, data) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET position = excluded.position, status = excluded.status,
+		complete_by = excluded.complete_by, updated_at = excluded.updated_at, data = excluded.data`,
+		note.Id, position, string(note.Status), note.CompleteBy, note.UpdatedAt, string(data))
+	return err
+}
@@ -0,0 +1,127 @@
+package model_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	model "github.com/goyalmunish/reminder/internal/model"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestOpenStore(t *testing.T) {
+	store, err := model.OpenStore("", "temp_test_dir/mydata.json")
+	utils.AssertEqual(t, err, nil)
+	_, ok := store.(*model.JSONStore)
+	utils.AssertEqual(t, ok, true)
+	_, err = model.OpenStore("xml", "temp_test_dir/mydata.xml")
+	utils.AssertEqual(t, err != nil, true)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.db"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	store, err := model.OpenStore(model.StoreKind_SQLite, dataFilePath)
+	utils.AssertEqual(t, err, nil)
+	defer store.Close()
+	// case 1 (new store is created with basic tags)
+	exists, _ := store.Exists()
+	utils.AssertEqual(t, exists, false)
+	utils.AssertEqual(t, model.MakeSureStoreExists(store, false), nil)
+	reminderData, err := store.Load()
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(reminderData.Tags), len(model.BasicTags()))
+	utils.AssertEqual(t, reminderData.DataFile, dataFilePath)
+	// case 2 (individual notes and tags are saved)
+	note, err := reminderData.NewNoteRegistration([]int{0}, "a note")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, reminderData.AddNoteComment(note, "a comment"), nil)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "another note")
+	reminderData.Tags[0].Group = "updated"
+	utils.AssertEqual(t, reminderData.UpdateDataFile(""), nil)
+	reminderDataRe, _ := store.Load()
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 2)
+	utils.AssertEqual(t, reminderDataRe.Notes[0].Id, note.Id)
+	utils.AssertEqual(t, reminderDataRe.Notes[0].Comments[0].Text, "a comment")
+	utils.AssertEqual(t, reminderDataRe.Notes[1].Text, "another note")
+	utils.AssertEqual(t, reminderDataRe.Tags[0].Group, "updated")
+	// case 3 (changes within a failed transaction are rolled back)
+	err = reminderData.Transaction(func() error {
+		_ = reminderData.UpdateNoteText(note, "updated note")
+		return errors.New("failed")
+	})
+	utils.AssertEqual(t, err.Error(), "failed")
+	reminderDataRe, _ = store.Load()
+	utils.AssertEqual(t, reminderDataRe.Notes[0].Text, "a note")
+	// case 4 (the saved notes and tags keep their positions, even if the data is re-sorted)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "third note")
+	for i, j := 0, len(reminderData.Notes)-1; i < j; i, j = i+1, j-1 {
+		reminderData.Notes[i], reminderData.Notes[j] = reminderData.Notes[j], reminderData.Notes[i]
+	}
+	utils.AssertEqual(t, reminderData.UpdateNoteText(note, "updated note"), nil)
+	_, _ = reminderData.NewNoteRegistration([]int{}, "fourth note")
+	var tagIDs []int
+	for _, tag := range reminderData.Tags {
+		tagIDs = append(tagIDs, tag.Id)
+	}
+	tag := reminderData.Tags[0]
+	for i, j := 0, len(reminderData.Tags)-1; i < j; i, j = i+1, j-1 {
+		reminderData.Tags[i], reminderData.Tags[j] = reminderData.Tags[j], reminderData.Tags[i]
+	}
+	utils.AssertEqual(t, reminderData.UpdateTagDueWindow(tag, "60"), nil)
+	reminderDataRe, _ = store.Load()
+	var texts []string
+	for _, n := range reminderDataRe.Notes {
+		texts = append(texts, n.Text)
+	}
+	utils.AssertEqual(t, texts, []string{"updated note", "another note", "third note", "fourth note"})
+	var tagIDsRe []int
+	for _, tag := range reminderDataRe.Tags {
+		tagIDsRe = append(tagIDsRe, tag.Id)
+	}
+	utils.AssertEqual(t, tagIDsRe, tagIDs)
+}
+
+func TestJSONStoreTransaction(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	// the data file is written only at the end of the transaction
+	err := reminderData.Transaction(func() error {
+		note, _ := reminderData.NewNoteRegistration([]int{}, "a note")
+		reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+		utils.AssertEqual(t, len(reminderDataRe.Notes), 0)
+		return reminderData.ToggleNoteMainFlag(note)
+	})
+	utils.AssertEqual(t, err, nil)
+	reminderDataRe, _ := model.ReadDataFile(dataFilePath, false)
+	utils.AssertEqual(t, len(reminderDataRe.Notes), 1)
+	utils.AssertEqual(t, reminderDataRe.Notes[0].IsMain, true)
+}
+
+func TestMigrateStore(t *testing.T) {
+	var dataFilePath = "temp_test_dir/mydata.json"
+	var dbFilePath = "temp_test_dir/mydata.db"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(dataFilePath))
+	// create the file and required dirs
+	_ = model.MakeSureFileExists(dataFilePath, false)
+	reminderData, _ := model.ReadDataFile(dataFilePath, false)
+	_, _ = reminderData.NewNoteRegistration([]int{1}, "a note")
+	// migrate from json to sqlite store
+	dst, _ := model.OpenStore(model.StoreKind_SQLite, dbFilePath)
+	defer dst.Close()
+	utils.AssertEqual(t, model.MigrateStore(reminderData, dst), nil)
+	migrated, _ := dst.Load()
+	utils.AssertEqual(t, migrated.Notes[0].Text, "a note")
+	utils.AssertEqual(t, migrated.Notes[0].TagIds, []int{1})
+	utils.AssertEqual(t, len(migrated.Tags), len(reminderData.Tags))
+	utils.AssertEqual(t, migrated.User, reminderData.User)
+	// the destination must not exist already
+	utils.AssertEqual(t, model.MigrateStore(reminderData, dst) != nil, true)
+}
@@ -8,11 +8,13 @@ import (
 A Tag represents classification of a note.
 
 A note can have multiple tags, and a tag can be associated with multiple notes.
+A tag can have a due window (see DueWindow) for its notes.
 */
 type Tag struct {
 	Id    int    `json:"id"`    // internal int-based id of the tag
 	Slug  string `json:"slug"`  // client-facing string-based id for tag
 	Group string `json:"group"` // a note can be part of only one tag within a group
+	DueWindow
 	BaseStruct
 }
 
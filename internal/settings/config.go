@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/goyalmunish/reminder/internal/appinfo"
+	"github.com/goyalmunish/reminder/internal/model"
 	"github.com/goyalmunish/reminder/pkg/calendar"
 	"github.com/goyalmunish/reminder/pkg/logger"
 	"github.com/goyalmunish/reminder/pkg/utils"
@@ -18,13 +19,21 @@ type Settings struct {
 	AppInfo  *appinfo.Options
 	Log      *logger.Options
 	Calendar *calendar.Options
+	Backup   *model.BackupOptions
+	// DueWindow is the default due windows of the notes.
+	DueWindow *model.DueWindowOptions `json:"due_window" yaml:"due_window" mapstructure:"due_window"`
+	// Redaction is what of the notes leaves the machine, with the calendar sync and the exports.
+	Redaction *model.RedactionOptions `json:"redaction" yaml:"redaction" mapstructure:"redaction"`
 }
 
 func DefaultSettings() *Settings {
 	return &Settings{
-		AppInfo:  appinfo.DefaultOptions(),
-		Log:      logger.DefaultOptions(),
-		Calendar: calendar.DefaultOptions(),
+		AppInfo:   appinfo.DefaultOptions(),
+		Log:       logger.DefaultOptions(),
+		Calendar:  calendar.DefaultOptions(),
+		Backup:    model.DefaultBackupOptions(),
+		DueWindow: model.DefaultDueWindowOptions(),
+		Redaction: model.DefaultRedactionOptions(),
 	}
 }
 
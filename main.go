@@ -1,11 +1,14 @@
 package main
 
 import (
+	"os"
+
 	"github.com/goyalmunish/reminder/cmd/reminder"
 	"github.com/goyalmunish/reminder/pkg/utils"
 )
 
 func main() {
-	err := reminder.Run()
+	err := reminder.Run(os.Args[1:])
 	utils.LogError(err)
+	os.Exit(reminder.ExitCode(err))
 }
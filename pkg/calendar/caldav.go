@@ -0,0 +1,242 @@
+package calendar
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// CalDAVPasswordEnv is the environment variable which takes precedence over CalDAVOptions.PasswordFile.
+const CalDAVPasswordEnv = "REMINDER_CALDAV_PASSWORD"
+
+// caldavRequestTimeout bounds each of the requests to the CalDAV server.
+const caldavRequestTimeout = 30 * time.Second
+
+// caldavCalendar is a calendar collection on a CalDAV (RFC 4791) server, such as Nextcloud, Radicale
+// or iCloud. Each event is stored as an iCalendar resource named after its Id.
+// Note: A CalDAV server doesn't keep the deleted events, and so, an event deleted in the calendar
+// is synced again (as if it were never synced) rather than marking its note as done.
+type caldavCalendar struct {
+	url      string
+	username string
+	password string
+	timeZone string
+	client   *http.Client
+	retry    *RetryOptions
+	// hrefs are the paths of the resources of the fetched events, by their Ids
+	hrefs   map[string]string
+	hrefsMu sync.Mutex
+}
+
+// newCalDAVCalendar returns the CalDAV calendar collection given by the options.
+func newCalDAVCalendar(options *Options) (Calendar, error) {
+	if options.CalDAV == nil || options.CalDAV.URL == "" {
+		return nil, errors.New("The URL of the CalDAV calendar (calendar.caldav.url) isn't set")
+	}
+	if _, err := url.Parse(options.CalDAV.URL); err != nil {
+		return nil, fmt.Errorf("Invalid URL of the CalDAV calendar: %w", err)
+	}
+	password, err := caldavPassword(options.CalDAV.PasswordFile)
+	if err != nil {
+		return nil, err
+	}
+	cal := NewCalDAVCalendar(options.CalDAV.URL, options.CalDAV.Username, password, options.TimeZone, &http.Client{Timeout: caldavRequestTimeout}).(*caldavCalendar)
+	cal.retry = options.Retry
+	return cal, nil
+}
+
+// NewCalDAVCalendar returns the CalDAV calendar collection at the URL, accessed with the HTTP client
+// using basic authentication (if the username is given).
+func NewCalDAVCalendar(collectionURL string, username string, password string, timeZone string, client *http.Client) Calendar {
+	if !strings.HasSuffix(collectionURL, "/") {
+		collectionURL += "/"
+	}
+	return &caldavCalendar{url: collectionURL, username: username, password: password, timeZone: timeZone, client: client, hrefs: make(map[string]string)}
+}
+
+// caldavPassword returns the password from the environment, or else from the file.
+func caldavPassword(passwordFile string) (string, error) {
+	if password := os.Getenv(CalDAVPasswordEnv); password != "" {
+		return password, nil
+	}
+	if passwordFile == "" {
+		return "", nil
+	}
+	passwordFile = utils.TryConvertTildaBasedPath(passwordFile)
+	if info, err := os.Stat(passwordFile); err == nil && info.Mode().Perm()&0077 != 0 {
+		logger.Warn(fmt.Sprintf("The CalDAV password file %q is accessible by other users; consider `chmod 600` on it.", passwordFile))
+	}
+	byteValue, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(byteValue), "\r\n"), nil
+}
+
+func (cal *caldavCalendar) Name() string {
+	return fmt.Sprintf("CalDAV calendar %q", cal.url)
+}
+
+func (cal *caldavCalendar) TimeZone(ctx context.Context) (string, error) {
+	return cal.timeZone, nil
+}
+
+// caldavMultistatus is the response of a REPORT request.
+type caldavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// Events fetches the events within the range of the query, and filters them by the rest of the query.
+func (cal *caldavCalendar) Events(ctx context.Context, query EventsQuery) ([]*Event, error) {
+	timeRange := ""
+	if !query.Start.IsZero() && !query.Stop.IsZero() {
+		timeRange = fmt.Sprintf(`<C:time-range start="%s" end="%s"/>`, query.Start.UTC().Format(icsUTCTimeFormat), query.Stop.UTC().Format(icsUTCTimeFormat))
+	}
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">` + timeRange + `</C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`
+	response, err := cal.do(ctx, "REPORT", cal.url, body, map[string]string{"Depth": "1", "Content-Type": "application/xml; charset=utf-8"}, http.StatusMultiStatus)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the events: %w", err)
+	}
+	var multistatus caldavMultistatus
+	if err := xml.Unmarshal(response, &multistatus); err != nil {
+		return nil, fmt.Errorf("Unable to parse the events: %w", err)
+	}
+	var events []*Event
+	for _, item := range multistatus.Responses {
+		for _, propstat := range item.Propstat {
+			if propstat.Prop.CalendarData == "" {
+				continue
+			}
+			resourceEvents, err := DecodeICS(propstat.Prop.CalendarData)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse the event %q: %w", item.Href, err)
+			}
+			for _, event := range resourceEvents {
+				cal.setHref(event.Id, item.Href)
+				if query.Matches(event) {
+					events = append(events, event)
+				}
+			}
+		}
+	}
+	logger.Info(fmt.Sprintf("Total number of events found: %d", len(events)))
+	return events, nil
+}
+
+func (cal *caldavCalendar) InsertEvent(ctx context.Context, event *Event) error {
+	inserted := *event
+	if inserted.Id == "" {
+		inserted.Id = uuid.New().String()
+	}
+	href := cal.url + url.PathEscape(inserted.Id) + ".ics"
+	_, err := cal.do(ctx, http.MethodPut, href, EncodeICS([]*Event{&inserted}), map[string]string{"If-None-Match": "*", "Content-Type": "text/calendar; charset=utf-8"}, http.StatusCreated, http.StatusNoContent, http.StatusOK)
+	if err == nil {
+		cal.setHref(inserted.Id, href)
+	}
+	return err
+}
+
+// UpdateEvent replaces the event in the calendar (as CalDAV has no partial updates).
+func (cal *caldavCalendar) UpdateEvent(ctx context.Context, event *Event) error {
+	_, err := cal.do(ctx, http.MethodPut, cal.href(event.Id), EncodeICS([]*Event{event}), map[string]string{"Content-Type": "text/calendar; charset=utf-8"}, http.StatusCreated, http.StatusNoContent, http.StatusOK)
+	return err
+}
+
+func (cal *caldavCalendar) DeleteEvent(ctx context.Context, event *Event) error {
+	_, err := cal.do(ctx, http.MethodDelete, cal.href(event.Id), "", nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	if err == nil {
+		cal.setHref(event.Id, "")
+	}
+	return err
+}
+
+// setHref sets (or with blank href, removes) the path of the resource of the event with given id.
+func (cal *caldavCalendar) setHref(id string, href string) {
+	cal.hrefsMu.Lock()
+	defer cal.hrefsMu.Unlock()
+	if href == "" {
+		delete(cal.hrefs, id)
+		return
+	}
+	cal.hrefs[id] = href
+}
+
+// href returns URL of the resource of the event with given id.
+func (cal *caldavCalendar) href(id string) string {
+	cal.hrefsMu.Lock()
+	href, ok := cal.hrefs[id]
+	cal.hrefsMu.Unlock()
+	if !ok {
+		return cal.url + url.PathEscape(id) + ".ics"
+	}
+	// the hrefs returned by the server are usually just the paths
+	if reference, err := url.Parse(href); err == nil {
+		if base, err := url.Parse(cal.url); err == nil {
+			return base.ResolveReference(reference).String()
+		}
+	}
+	return href
+}
+
+// do sends the request to the server (retrying it as long as it fails temporarily), and returns the
+// body of the response if it has one of the expected statuses.
+func (cal *caldavCalendar) do(ctx context.Context, method string, target string, body string, headers map[string]string, expectedStatuses ...int) ([]byte, error) {
+	var content []byte
+	err := retry(ctx, cal.retry, func() (err error) {
+		content, err = cal.send(ctx, method, target, body, headers, expectedStatuses)
+		return err
+	})
+	return content, err
+}
+
+// send sends the request to the server once.
+func (cal *caldavCalendar) send(ctx context.Context, method string, target string, body string, headers map[string]string, expectedStatuses []int) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	if cal.username != "" {
+		request.SetBasicAuth(cal.username, cal.password)
+	}
+	response, err := cal.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expectedStatuses {
+		if response.StatusCode == status {
+			return content, nil
+		}
+	}
+	return nil, &statusError{method: method, target: target, statusCode: response.StatusCode, status: response.Status, header: response.Header}
+}
@@ -0,0 +1,170 @@
+package calendar_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// caldavStandIn is a minimal in-memory CalDAV server, serving a single calendar collection.
+type caldavStandIn struct {
+	mu        sync.Mutex
+	resources map[string]string
+	// failures are the statuses of the next failing requests, by their methods
+	failures map[string][]int
+}
+
+func (server *caldavStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if user, password, ok := r.BasicAuth(); !ok || user != "me" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if statuses := server.failures[r.Method]; len(statuses) > 0 {
+		server.failures[r.Method] = statuses[1:]
+		w.WriteHeader(statuses[0])
+		return
+	}
+	switch r.Method {
+	case "REPORT":
+		var paths []string
+		for path := range server.resources {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`)
+		for _, path := range paths {
+			fmt.Fprintf(&b, `<D:response><D:href>%s</D:href><D:propstat><D:prop><C:calendar-data>%s</C:calendar-data></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, path, html.EscapeString(server.resources[path]))
+		}
+		b.WriteString(`</D:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(b.String()))
+	case http.MethodPut:
+		_, exists := server.resources[r.URL.Path]
+		if exists && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		server.resources[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if _, exists := server.resources[r.URL.Path]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(server.resources, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestCalDAVCalendar(t *testing.T) {
+	standIn := &caldavStandIn{resources: map[string]string{
+		// an event of another app, which is left alone
+		"/calendars/me/reminder/other.ics": calendar.EncodeICS([]*calendar.Event{{Id: "other", Summary: "dinner", Start: &calendar.EventTime{DateTime: "2026-10-16T19:00:00Z"}}}),
+	}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	cal := calendar.NewCalDAVCalendar(server.URL+"/calendars/me/reminder", "me", "secret", "Asia/Kolkata", server.Client())
+	ctx := context.Background()
+	timeZone, _ := cal.TimeZone(ctx)
+	utils.AssertEqual(t, timeZone, "Asia/Kolkata")
+	// first sync
+	events, err := calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 0)
+	desired := []*calendar.Event{taggedEvent("note-1", "pay the rent"), taggedEvent("note-2", "call the bank")}
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, calendar.PlanSync(events, desired), calendar.DefaultOptions()).Err(), nil)
+	utils.AssertEqual(t, len(standIn.resources), 3)
+	// second sync, with one of the notes changed and the other one removed
+	events, err = calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 2)
+	desired = []*calendar.Event{taggedEvent("note-1", "pay the rent on time")}
+	plan := calendar.PlanSync(events, desired)
+	utils.AssertEqual(t, len(plan.Updates), 1)
+	utils.AssertEqual(t, len(plan.Deletes), 1)
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, plan, calendar.DefaultOptions()).Err(), nil)
+	events, _ = cal.Events(ctx, calendar.EventsQuery{})
+	utils.AssertEqual(t, len(events), 2)
+	var summaries []string
+	for _, event := range events {
+		summaries = append(summaries, event.Summary)
+	}
+	sort.Strings(summaries)
+	utils.AssertEqual(t, summaries, []string{calendar.TitlePrefix + "pay the rent on time", "dinner"})
+	// third sync has nothing to do
+	events, _ = calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, calendar.PlanSync(events, desired).IsEmpty(), true)
+	// wrong credentials
+	cal = calendar.NewCalDAVCalendar(server.URL+"/calendars/me/reminder/", "me", "wrong", "", server.Client())
+	_, err = cal.Events(ctx, calendar.EventsQuery{})
+	utils.AssertEqual(t, strings.Contains(err.Error(), "401 Unauthorized"), true)
+}
+
+func TestApplySync(t *testing.T) {
+	standIn := &caldavStandIn{resources: map[string]string{}, failures: map[string][]int{}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	t.Setenv(calendar.CalDAVPasswordEnv, "secret")
+	options := &calendar.Options{
+		Provider:    calendar.Provider_CalDAV,
+		CalDAV:      &calendar.CalDAVOptions{URL: server.URL + "/calendars/me/reminder/", Username: "me"},
+		Concurrency: 1,
+		Retry:       &calendar.RetryOptions{MaxAttempts: 3, InitialDelay: 1, MaxDelay: 5},
+	}
+	ctx := context.Background()
+	cal, err := calendar.New(ctx, options)
+	utils.AssertEqual(t, err, nil)
+	// the temporary failures are retried
+	standIn.failures[http.MethodPut] = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	report := calendar.ApplySync(ctx, cal, &calendar.SyncPlan{Inserts: []*calendar.Event{taggedEvent("note-1", "pay the rent")}}, options)
+	utils.AssertEqual(t, report.Err(), nil)
+	utils.AssertEqual(t, len(standIn.resources), 1)
+	// the rest of the failures are reported, without stopping the rest of the changes
+	standIn.failures[http.MethodPut] = []int{http.StatusForbidden}
+	plan := &calendar.SyncPlan{Inserts: []*calendar.Event{taggedEvent("note-2", "call the bank"), taggedEvent("note-3", "book the tickets")}}
+	report = calendar.ApplySync(ctx, cal, plan, options)
+	utils.AssertEqual(t, len(report.Results), 2)
+	utils.AssertEqual(t, len(report.Failed()), 1)
+	utils.AssertEqual(t, report.Failed()[0].NoteId, "note-2")
+	utils.AssertEqual(t, strings.Contains(report.Err().Error(), "Failed to make 1 of the 2 changes to the calendar"), true)
+	utils.AssertEqual(t, strings.Contains(report.String(), "Sync report: 1 changes made, 1 failed"), true)
+	utils.AssertEqual(t, len(standIn.resources), 2)
+	// and the failed changes can be retried
+	retryPlan := report.RetryPlan()
+	utils.AssertEqual(t, len(retryPlan.Inserts), 1)
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, retryPlan, options).Err(), nil)
+	utils.AssertEqual(t, len(standIn.resources), 3)
+	// the retries are given up after the maximum number of attempts
+	standIn.failures[http.MethodDelete] = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+	events, _ := calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	report = calendar.ApplySync(ctx, cal, calendar.PlanSync(events, nil), options)
+	utils.AssertEqual(t, len(report.Failed()), 1)
+	utils.AssertEqual(t, strings.Contains(report.Failed()[0].Err.Error(), "502 Bad Gateway"), true)
+	utils.AssertEqual(t, len(standIn.resources), 1)
+	// the changes yet to be made are failed once the context is done
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	report = calendar.ApplySync(cancelledCtx, cal, report.RetryPlan(), options)
+	utils.AssertEqual(t, errors.Is(report.Failed()[0].Err, context.Canceled), true)
+	// nothing is changed in dry mode
+	report = calendar.ApplySync(ctx, cal, calendar.PlanSync(events, nil), &calendar.Options{DryMode: true})
+	utils.AssertEqual(t, report.Err(), nil)
+	utils.AssertEqual(t, len(standIn.resources), 1)
+}
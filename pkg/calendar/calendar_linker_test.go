@@ -0,0 +1,7 @@
+package calendar
+
+import gc "google.golang.org/api/calendar/v3"
+
+func PrivateGoogleCalendar(srv *gc.Service, retry *RetryOptions) Calendar {
+	return &googleCalendar{srv: srv, id: googlePrimaryCalendarId, retry: retry}
+}
@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+An Event is a calendar event, independent of the calendar it belongs to (see Calendar).
+Its fields follow the iCalendar (RFC 5545) model, which all the supported calendars are based upon.
+*/
+type Event struct {
+	// Id is the identifier of the event in its calendar (blank for a new event).
+	Id          string `json:"id,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Start and End are nil for a deleted event (in some calendars).
+	Start *EventTime `json:"start,omitempty"`
+	End   *EventTime `json:"end,omitempty"`
+	// Recurrence are the RRULE (and EXDATE) lines of a recurring event, such as "RRULE:FREQ=WEEKLY".
+	Recurrence []string `json:"recurrence,omitempty"`
+	// Status is "confirmed", or "cancelled" for a deleted event.
+	Status string `json:"status,omitempty"`
+	// RecurringEventId is the Id of the recurring event, for a changed occurrence of it.
+	RecurringEventId string `json:"recurring_event_id,omitempty"`
+	// Properties are the private properties of the event, such as the ones set by TagEvent.
+	Properties map[string]string `json:"properties,omitempty"`
+	// Color is the color of the event (see EventColors), or blank for the default color of its calendar.
+	Color string `json:"color,omitempty"`
+	// Busy tells if the event is shown as busy (rather than as free).
+	Busy bool `json:"busy,omitempty"`
+	// Reminders are the reminders of the event; if none, the default reminders of its calendar apply.
+	Reminders []EventReminder `json:"reminders,omitempty"`
+}
+
+// The methods of the reminders of the events.
+const (
+	ReminderMethod_Popup = "popup"
+	ReminderMethod_Email = "email"
+)
+
+// maxReminderMinutes is how early (in minutes) a reminder can be, at most (4 weeks, as in Google Calendar).
+const maxReminderMinutes = 4 * 7 * 24 * 60
+
+// An EventReminder is a reminder of an event, given minutes before the event starts.
+type EventReminder struct {
+	Method  string `json:"method" yaml:"method" mapstructure:"method"`
+	Minutes int    `json:"minutes" yaml:"minutes" mapstructure:"minutes"`
+}
+
+// An eventColor is a color of the events (as named in Google Calendar), along with its id in Google
+// Calendar, and its closest CSS color (as used by iCalendar).
+type eventColor struct {
+	name     string
+	googleId string
+	css      string
+}
+
+var eventColors = []eventColor{
+	{"lavender", "1", "mediumpurple"},
+	{"sage", "2", "mediumseagreen"},
+	{"grape", "3", "darkorchid"},
+	{"flamingo", "4", "lightcoral"},
+	{"banana", "5", "gold"},
+	{"tangerine", "6", "orangered"},
+	{"peacock", "7", "deepskyblue"},
+	{"graphite", "8", "gray"},
+	{"blueberry", "9", "royalblue"},
+	{"basil", "10", "seagreen"},
+	{"tomato", "11", "red"},
+}
+
+// EventColors returns the names of the colors of the events.
+func EventColors() []string {
+	var names []string
+	for _, color := range eventColors {
+		names = append(names, color.name)
+	}
+	return names
+}
+
+// findEventColor returns the color with the name (or with the Google Calendar id, or the CSS color),
+// or nil if there is no such color.
+func findEventColor(value string) *eventColor {
+	value = strings.ToLower(value)
+	for i, color := range eventColors {
+		if value == color.name || value == color.googleId || value == color.css {
+			return &eventColors[i]
+		}
+	}
+	return nil
+}
+
+// An EventTime is the start or end of an event.
+type EventTime struct {
+	// Date is the date (as "YYYY-MM-DD") of an all-day event.
+	Date string `json:"date,omitempty"`
+	// DateTime is the time (in RFC3339 format) of an event which isn't all-day.
+	DateTime string `json:"date_time,omitempty"`
+	// TimeZone is the IANA timezone of the event (optional).
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// EventString returns the basic details of the event, such as its title and start.
+func EventString(event *Event) string {
+	details := []string{}
+	details = append(details, event.Summary)
+	// Note: if an event is deleted, but still present in trash
+	// it will have event.Start as nil.
+	if event.Start != nil {
+		if event.Start.DateTime != "" {
+			details = append(details, event.Start.DateTime)
+		} else {
+			details = append(details, event.Start.Date)
+		}
+	}
+	details = append(details, event.Recurrence...)
+	return strings.Join(details, " | ")
+}
+
+// An EventsQuery filters the events to be fetched.
+type EventsQuery struct {
+	// Start and Stop are the range of time in which the events are to be fetched (open on the side
+	// which is zero).
+	Start time.Time
+	Stop  time.Time
+	// Text is the free text to be searched in the events (optional).
+	Text string
+	// PrivateProperty is the private property (as "name=value") of the events (optional).
+	PrivateProperty string
+	// ShowDeleted tells if the deleted (cancelled) events are to be fetched as well.
+	ShowDeleted bool
+}
+
+// Matches tells if the event matches the query.
+// It is used by the calendars which can't filter the events by themselves.
+// A recurring event matches if it starts before the end of the range.
+func (query EventsQuery) Matches(event *Event) bool {
+	if IsCancelled(event) && !query.ShowDeleted {
+		return false
+	}
+	if query.Text != "" {
+		text := strings.ToLower(query.Text)
+		if !strings.Contains(strings.ToLower(event.Summary), text) && !strings.Contains(strings.ToLower(event.Description), text) {
+			return false
+		}
+	}
+	if query.PrivateProperty != "" {
+		name, value, _ := strings.Cut(query.PrivateProperty, "=")
+		if eventProperty(event, name) != value {
+			return false
+		}
+	}
+	start, _, err := EventStart(event, time.UTC)
+	if err != nil {
+		// the deleted events may not have a start
+		return IsCancelled(event)
+	}
+	if !query.Stop.IsZero() && !start.Before(query.Stop) {
+		return false
+	}
+	if query.Start.IsZero() || len(event.Recurrence) > 0 {
+		return true
+	}
+	end := start
+	if event.End != nil {
+		if value, _, err := EventStart(&Event{Start: event.End}, time.UTC); err == nil {
+			end = value
+		}
+	}
+	return !end.Before(query.Start)
+}
@@ -0,0 +1,49 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+)
+
+// FeedPath is the path at which the iCalendar feed is served (see NewFeedHandler).
+const FeedPath = "/reminder.ics"
+
+// NewFeedHandler returns the HTTP handler serving the iCalendar feed at FeedPath, with the feed
+// generated afresh for each request, so that the subscribers always get the latest notes.
+// The unchanged feed isn't sent again to the subscribers which already have it (by its ETag).
+func NewFeedHandler(feed func() (string, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(FeedPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := feed()
+		if err != nil {
+			logger.Error(err)
+			http.Error(w, "Unable to generate the feed", http.StatusInternalServerError)
+			return
+		}
+		// note: DTSTAMP changes with every feed, and so, it is left out of the ETag
+		var lines []string
+		for _, line := range strings.Split(data, "\r\n") {
+			if !strings.HasPrefix(line, "DTSTAMP:") {
+				lines = append(lines, line)
+			}
+		}
+		hash := sha256.Sum256([]byte(strings.Join(lines, "\r\n")))
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(hash[:16])))
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="reminder.ics"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(data))
+	})
+	return mux
+}
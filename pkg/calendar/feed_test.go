@@ -0,0 +1,48 @@
+package calendar_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestFeedHandler(t *testing.T) {
+	events := []*calendar.Event{taggedEvent("note-1", "pay the rent")}
+	handler := calendar.NewFeedHandler(func() (string, error) {
+		return calendar.ExportICS(events, calendar.ICSComponent_Event, "reminder")
+	})
+	get := func(method string, target string, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	w := get(http.MethodGet, calendar.FeedPath, "")
+	utils.AssertEqual(t, w.Code, http.StatusOK)
+	utils.AssertEqual(t, w.Header().Get("Content-Type"), "text/calendar; charset=utf-8")
+	decoded, err := calendar.DecodeICS(w.Body.String())
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(decoded), 1)
+	// the unchanged feed isn't sent again
+	etag := w.Header().Get("ETag")
+	utils.AssertEqual(t, etag != "", true)
+	utils.AssertEqual(t, get(http.MethodGet, calendar.FeedPath, etag).Code, http.StatusNotModified)
+	// the changed one is
+	events = append(events, taggedEvent("note-2", "call the bank"))
+	w = get(http.MethodGet, calendar.FeedPath, etag)
+	utils.AssertEqual(t, w.Code, http.StatusOK)
+	utils.AssertEqual(t, w.Header().Get("ETag") != etag, true)
+	// other methods and paths
+	utils.AssertEqual(t, get(http.MethodPost, calendar.FeedPath, "").Code, http.StatusMethodNotAllowed)
+	utils.AssertEqual(t, get(http.MethodGet, "/other.ics", "").Code, http.StatusNotFound)
+	// the failure to generate the feed
+	handler = calendar.NewFeedHandler(func() (string, error) { return "", errors.New("Unable to read the data") })
+	utils.AssertEqual(t, get(http.MethodGet, calendar.FeedPath, "").Code, http.StatusInternalServerError)
+}
@@ -0,0 +1,312 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+
+	gc "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// googlePrimaryCalendarId is the id of the primary calendar of the user in Google Calendar.
+const googlePrimaryCalendarId = "primary"
+
+// googleDefaultColorId is the color ("basil") of the events without a color.
+const googleDefaultColorId = "10"
+
+// googleCalendar is a calendar of the user in Google Calendar.
+type googleCalendar struct {
+	srv   *gc.Service
+	id    string
+	retry *RetryOptions
+	// summary and timeZone of the calendar, as fetched along with the events
+	summary  string
+	timeZone string
+}
+
+// newGoogleCalendar returns the Google Calendar, authorizing the app if needed.
+func newGoogleCalendar(ctx context.Context, options *Options) (Calendar, error) {
+	srv, err := GetCalendarService(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve Calendar client: %w", err)
+	}
+	cal := &googleCalendar{srv: srv, id: options.CalendarId, retry: options.Retry}
+	if cal.id == "" {
+		cal.id = googlePrimaryCalendarId
+	}
+	if options.CalendarName != "" {
+		if cal.id, err = cal.findOrCreate(ctx, options.CalendarName, options.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	return cal, nil
+}
+
+// findOrCreate returns id of the calendar of the user with the name, after creating the calendar
+// if there isn't any.
+func (cal *googleCalendar) findOrCreate(ctx context.Context, name string, timeZone string) (string, error) {
+	var id string
+	err := retry(ctx, cal.retry, func() error {
+		id = ""
+		return cal.srv.CalendarList.List().MinAccessRole("writer").Pages(ctx, func(list *gc.CalendarList) error {
+			for _, entry := range list.Items {
+				if id == "" && entry.Summary == name {
+					id = entry.Id
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve the calendars; authorize the app again (with `reminder calendar auth login`) if the access is denied: %w", err)
+	}
+	if id != "" {
+		return id, nil
+	}
+	// note: the calendar is created in the timezone of the primary calendar, unless given
+	var created *gc.Calendar
+	err = retry(ctx, cal.retry, func() (err error) {
+		created, err = cal.srv.Calendars.Insert(&gc.Calendar{Summary: name, TimeZone: timeZone, Description: "Notes synced by reminder"}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to create the calendar %q: %w", name, err)
+	}
+	logger.Info(fmt.Sprintf("Created the calendar %q.", name))
+	return created.Id, nil
+}
+
+func (cal *googleCalendar) Name() string {
+	if cal.summary == "" {
+		return "Google Calendar"
+	}
+	return fmt.Sprintf("Google Calendar %q", cal.summary)
+}
+
+func (cal *googleCalendar) TimeZone(ctx context.Context) (string, error) {
+	if cal.timeZone == "" {
+		// the timezone comes along with the events
+		var events *gc.Events
+		err := retry(ctx, cal.retry, func() (err error) {
+			events, err = cal.srv.Events.List(cal.id).MaxResults(1).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("Unable to retrieve the calendar: %w", err)
+		}
+		cal.summary, cal.timeZone = events.Summary, events.TimeZone
+	}
+	return cal.timeZone, nil
+}
+
+// Events fetches all the pages of the events matching the query.
+func (cal *googleCalendar) Events(ctx context.Context, query EventsQuery) ([]*Event, error) {
+	// Get list of all events, with recurring events as a
+	// unit (and not as separate single events).
+	var allEvents []*Event
+	tStart := query.Start.Format(time.RFC3339)
+	tStop := query.Stop.Format(time.RFC3339)
+	var pageToken string
+	logger.Info(fmt.Sprintf("Fetching Calendar items with query %q (property %q) from %s to %s", query.Text, query.PrivateProperty, tStart, tStop))
+	for i := 0; ; i++ {
+		logger.Info(fmt.Sprintf("Fetching Page-%d with token %q", i, pageToken))
+		eventsList := cal.srv.Events.List(cal.id).
+			ShowDeleted(query.ShowDeleted).
+			SingleEvents(false).
+			MaxResults(250) // max no. of events per page; 250 is default and is maximum value; but results in each page may be far lesser then this upper limit
+		// note: the zero start (or stop) leaves the range open
+		if !query.Start.IsZero() {
+			eventsList = eventsList.TimeMin(tStart)
+		}
+		if !query.Stop.IsZero() {
+			eventsList = eventsList.TimeMax(tStop)
+		}
+		if query.Text != "" {
+			eventsList = eventsList.Q(query.Text)
+		}
+		if query.PrivateProperty != "" {
+			eventsList = eventsList.PrivateExtendedProperty(query.PrivateProperty)
+		}
+		if pageToken != "" {
+			eventsList = eventsList.PageToken(pageToken)
+		}
+		var pageEvents *gc.Events
+		err := retry(ctx, cal.retry, func() (err error) {
+			pageEvents, err = eventsList.Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the events: %w", err)
+		}
+		cal.summary, cal.timeZone = pageEvents.Summary, pageEvents.TimeZone
+		logger.Info(fmt.Sprintf("Found %d items; adding them to overall results", len(pageEvents.Items)))
+		for _, item := range pageEvents.Items {
+			allEvents = append(allEvents, fromGoogleEvent(item))
+		}
+		// break if token for next page is not found
+		if pageEvents.NextPageToken == "" {
+			break
+		}
+		if pageEvents.NextPageToken == pageToken {
+			return nil, fmt.Errorf("Unable to retrieve the events: the page token %q is repeated", pageToken)
+		}
+		pageToken = pageEvents.NextPageToken
+	}
+	logger.Info(fmt.Sprintf("Total number of events found: %d", len(allEvents)))
+	return allEvents, nil
+}
+
+// InsertEvent adds the event to the calendar.
+// Note: If an insertion is retried after it has actually succeeded, the event is inserted twice;
+// the duplicate event is then deleted with the next sync (see PlanSync).
+func (cal *googleCalendar) InsertEvent(ctx context.Context, event *Event) error {
+	return retry(ctx, cal.retry, func() error {
+		_, err := cal.srv.Events.Insert(cal.id, toGoogleEvent(event)).Context(ctx).Do()
+		return err
+	})
+}
+
+// UpdateEvent patches the event in the calendar.
+// Only the fields set by the app are patched, so that the rest (such as the attendees and their
+// responses) are left as they are.
+func (cal *googleCalendar) UpdateEvent(ctx context.Context, event *Event) error {
+	return retry(ctx, cal.retry, func() error {
+		_, err := cal.srv.Events.Patch(cal.id, event.Id, toGoogleEvent(event)).Context(ctx).Do()
+		return err
+	})
+}
+
+// DeleteEvent deletes the event from the calendar.
+// The event which is already gone (such as deleted by the user meanwhile, or by an earlier attempt
+// of the request) is taken as deleted.
+// Note: The deleted events stay in the trash of the calendar (https://calendar.google.com/calendar/u/0/r/trash)
+// for a while.
+func (cal *googleCalendar) DeleteEvent(ctx context.Context, event *Event) error {
+	err := retry(ctx, cal.retry, func() error {
+		return cal.srv.Events.Delete(cal.id, event.Id).Context(ctx).Do()
+	})
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+		logger.Info(fmt.Sprintf("The event %q is already deleted.", event.Id))
+		return nil
+	}
+	return err
+}
+
+// toGoogleEvent converts the event to Google Calendar Event.
+func toGoogleEvent(event *Event) *gc.Event {
+	googleEvent := &gc.Event{
+		Id:          event.Id,
+		Summary:     event.Summary,
+		Description: event.Description,
+		Start:       toGoogleEventDateTime(event.Start),
+		End:         toGoogleEventDateTime(event.End),
+		Recurrence:  event.Recurrence,
+		Status:      event.Status,
+		ColorId:     googleDefaultColorId,
+		Reminders: &gc.EventReminders{
+			Overrides:  []*gc.EventReminder{},
+			UseDefault: true,
+		},
+		EventType: "default",
+		Source: &gc.EventSource{
+			Title: "reminder",
+			Url:   "https://github.com/goyalmunish/reminder",
+		},
+		Transparency: "transparent",
+		Visibility:   "default",
+		// the blank description and recurrence are sent as well, so that they are cleared while patching
+		ForceSendFields: []string{"Description", "Recurrence"},
+	}
+	if googleEvent.Recurrence == nil {
+		googleEvent.Recurrence = []string{}
+	}
+	if color := findEventColor(event.Color); color != nil {
+		googleEvent.ColorId = color.googleId
+	}
+	if event.Busy {
+		googleEvent.Transparency = "opaque"
+	}
+	if len(event.Reminders) > 0 {
+		googleEvent.Reminders = &gc.EventReminders{
+			// note: UseDefault is sent even though false, as it can't be true along with the overrides
+			ForceSendFields: []string{"UseDefault"},
+		}
+		for _, reminder := range event.Reminders {
+			googleEvent.Reminders.Overrides = append(googleEvent.Reminders.Overrides, &gc.EventReminder{
+				Method:          reminder.Method,
+				Minutes:         int64(reminder.Minutes),
+				ForceSendFields: []string{"Minutes"},
+			})
+		}
+	}
+	if event.Properties != nil {
+		googleEvent.ExtendedProperties = &gc.EventExtendedProperties{Private: event.Properties}
+	}
+	return googleEvent
+}
+
+// fromGoogleEvent converts the Google Calendar Event to event.
+func fromGoogleEvent(googleEvent *gc.Event) *Event {
+	event := &Event{
+		Id:               googleEvent.Id,
+		Summary:          googleEvent.Summary,
+		Description:      googleEvent.Description,
+		Start:            fromGoogleEventDateTime(googleEvent.Start),
+		End:              fromGoogleEventDateTime(googleEvent.End),
+		Recurrence:       googleEvent.Recurrence,
+		Status:           googleEvent.Status,
+		RecurringEventId: googleEvent.RecurringEventId,
+	}
+	if googleEvent.ExtendedProperties != nil {
+		event.Properties = googleEvent.ExtendedProperties.Private
+	}
+	if color := findEventColor(googleEvent.ColorId); color != nil && color.googleId != googleDefaultColorId {
+		event.Color = color.name
+	}
+	event.Busy = googleEvent.Transparency != "transparent"
+	if googleEvent.Reminders != nil && !googleEvent.Reminders.UseDefault {
+		for _, reminder := range googleEvent.Reminders.Overrides {
+			event.Reminders = append(event.Reminders, EventReminder{Method: reminder.Method, Minutes: int(reminder.Minutes)})
+		}
+	}
+	return event
+}
+
+func toGoogleEventDateTime(value *EventTime) *gc.EventDateTime {
+	if value == nil {
+		return nil
+	}
+	return &gc.EventDateTime{Date: value.Date, DateTime: value.DateTime, TimeZone: value.TimeZone}
+}
+
+func fromGoogleEventDateTime(value *gc.EventDateTime) *EventTime {
+	if value == nil {
+		return nil
+	}
+	return &EventTime{Date: value.Date, DateTime: value.DateTime, TimeZone: value.TimeZone}
+}
+
+// Get Calendar Service.
+func GetCalendarService(ctx context.Context, options *Options) (*gc.Service, error) {
+	logger.Info("Start: GetCalendarService")
+	defer logger.Info("End: GetCalendarService")
+	auth, err := NewGoogleAuth(options)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := auth.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := gc.NewService(ctx, option.WithHTTPClient(client))
+	return srv, err
+}
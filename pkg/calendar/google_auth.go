@@ -0,0 +1,474 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	gc "google.golang.org/api/calendar/v3"
+)
+
+// The endpoints of Google OAuth which aren't part of the client secret file.
+const (
+	googleDeviceAuthURL = "https://oauth2.googleapis.com/device/code"
+	googleRevokeURL     = "https://oauth2.googleapis.com/revoke"
+)
+
+// authTimeout is how long the authorization waits for the user to grant the access.
+const authTimeout = 5 * time.Minute
+
+// deviceGrantType is the grant type of the device authorization (RFC 8628).
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// GoogleAuth authorizes the app to access the Google Calendar of the user (with OAuth 2.0), and
+// keeps the token of the user in the TokenFile.
+type GoogleAuth struct {
+	Config *oauth2.Config
+	// TokenFile keeps the access and refresh tokens of the user (with 0600 permissions).
+	TokenFile string
+	// DeviceAuthURL and RevokeURL are the endpoints for the device authorization, and for
+	// revoking the token.
+	DeviceAuthURL string
+	RevokeURL     string
+	// Browse opens the authorization link in a browser; if nil (or if it fails), the user is
+	// asked to open it.
+	Browse func(authURL string) error
+	// Out is where the instructions for the user are written.
+	Out io.Writer
+}
+
+// NewGoogleAuth returns the authorization of the app, as per the client secret file.
+func NewGoogleAuth(options *Options) (*GoogleAuth, error) {
+	credFile := options.CredentialFile
+	b, err := os.ReadFile(utils.TryConvertTildaBasedPath(credFile))
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't read the client secret file %q; Refer instructions on https://github.com/goyalmunish/reminder#setting-up-the-environment-for-google-calendar-sync; Underneath error: %w", credFile, err)
+	}
+	logger.Info(fmt.Sprintf("Read client secret file %q.", credFile))
+
+	// If modifying these scopes, delete your previously saved token file.
+	// note: the dedicated calendar (if any) is to be found among (or created along with) all the calendars
+	scope := gc.CalendarEventsScope
+	if options.CalendarName != "" {
+		scope = gc.CalendarScope
+	}
+	config, err := google.ConfigFromJSON(b, scope)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse client secret file to config; If you changed the scope, then deleted your current %q token file and try again; Underneath error: %w", options.TokenFile, err)
+	}
+	return &GoogleAuth{
+		Config:        config,
+		TokenFile:     utils.TryConvertTildaBasedPath(options.TokenFile),
+		DeviceAuthURL: googleDeviceAuthURL,
+		RevokeURL:     googleRevokeURL,
+		Browse:        OpenBrowser,
+		Out:           os.Stdout,
+	}, nil
+}
+
+// Token returns the saved token of the user.
+func (auth *GoogleAuth) Token() (*oauth2.Token, error) {
+	return tokenFromFile(auth.TokenFile)
+}
+
+// Client returns the HTTP client authorized by the saved token, after obtaining the token (see
+// Authorize) if there isn't any yet. The token is refreshed as needed, and the refreshed token is
+// saved back to the TokenFile.
+func (auth *GoogleAuth) Client(ctx context.Context) (*http.Client, error) {
+	token, err := auth.Token()
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Warn(fmt.Sprintf("Token file doesn't exist; envoking the authentication process to generate one at %q.", auth.TokenFile))
+		token, err = auth.Authorize(ctx, IsHeadless())
+	}
+	if err != nil {
+		return nil, err
+	}
+	source := &savingTokenSource{source: auth.Config.TokenSource(ctx, token), file: auth.TokenFile, saved: token}
+	return oauth2.NewClient(ctx, source), nil
+}
+
+// Authorize obtains a new token from the user, and saves it.
+// By default, it opens the consent page in the browser, which redirects back to a local server
+// once the access is granted (with PKCE and state verification). With device (for the machines
+// without a browser), the user enters a code on another device instead.
+func (auth *GoogleAuth) Authorize(ctx context.Context, device bool) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, authTimeout)
+	defer cancel()
+	var token *oauth2.Token
+	var err error
+	if device {
+		token, err = auth.authorizeDevice(ctx)
+	} else {
+		token, err = auth.authorizeLoopback(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := saveToken(auth.TokenFile, token); err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("Saved the token file %q.", auth.TokenFile))
+	return token, nil
+}
+
+// authorizeLoopback obtains the token by the authorization code flow, with the loopback redirect.
+func (auth *GoogleAuth) authorizeLoopback(ctx context.Context) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to listen for the authorization redirect: %w", err)
+	}
+	config := *auth.Config
+	config.RedirectURL = "http://" + listener.Addr().String() + "/"
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+
+	// wait for the redirect, with either the code or the error
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{ReadHeaderTimeout: 10 * time.Second, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		// note: a request without the state isn't a redirect of this authorization, and so, it is ignored
+		if r.URL.Path != "/" || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+			http.Error(w, "Invalid authorization redirect", http.StatusBadRequest)
+			return
+		}
+		code := query.Get("code")
+		if code == "" {
+			fmt.Fprintln(w, "The authorization has failed; you can close this page.")
+			select {
+			case errs <- fmt.Errorf("The authorization has failed: %s", query.Get("error")):
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "The app is authorized; you can close this page.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+	auth.printf("Open the following link in your browser, and allow the access to your calendar:\n%s\n", authURL)
+	if auth.Browse != nil {
+		if err := auth.Browse(authURL); err != nil {
+			logger.Warn(fmt.Sprintf("Unable to open the browser: %v", err))
+		}
+	}
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("Timed out waiting for the authorization: %w", ctx.Err())
+	}
+	token, err := config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve token from web: %w", err)
+	}
+	return token, nil
+}
+
+// deviceAuthResponse is the response of the device authorization endpoint.
+type deviceAuthResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURL is the VerificationURI, as named by Google
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// tokenResponse is the response of the token endpoint, while polling for the device authorization.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// authorizeDevice obtains the token by the device authorization flow (RFC 8628).
+// Note: Google supports it only for the OAuth clients of type "TVs and Limited Input devices".
+func (auth *GoogleAuth) authorizeDevice(ctx context.Context) (*oauth2.Token, error) {
+	var device deviceAuthResponse
+	values := url.Values{"client_id": {auth.Config.ClientID}, "scope": {strings.Join(auth.Config.Scopes, " ")}}
+	if err := postForm(ctx, auth.DeviceAuthURL, values, &device); err != nil {
+		return nil, fmt.Errorf("Unable to start the device authorization; it needs an OAuth client of type \"TVs and Limited Input devices\": %w", err)
+	}
+	verificationURI := device.VerificationURI
+	if verificationURI == "" {
+		verificationURI = device.VerificationURL
+	}
+	auth.printf("On any device, open %s and enter the code %s to allow the access to your calendar.\n", verificationURI, device.UserCode)
+	if device.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(device.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+	// poll for the token, at the interval asked for by the server
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	values = url.Values{
+		"client_id":     {auth.Config.ClientID},
+		"client_secret": {auth.Config.ClientSecret},
+		"device_code":   {device.DeviceCode},
+		"grant_type":    {deviceGrantType},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Timed out waiting for the authorization: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+		var response tokenResponse
+		err := postForm(ctx, auth.Config.Endpoint.TokenURL, values, &response)
+		switch response.Error {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		case "":
+			if err == nil && response.AccessToken == "" {
+				err = errors.New("The token is missing in the response")
+			}
+		default:
+			err = fmt.Errorf("%s (%v)", response.Error, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("The device authorization has failed: %w", err)
+		}
+		token := &oauth2.Token{AccessToken: response.AccessToken, TokenType: response.TokenType, RefreshToken: response.RefreshToken}
+		if response.ExpiresIn > 0 {
+			token.Expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+		}
+		return token, nil
+	}
+}
+
+// AuthStatus is the status of the authorization of the app.
+type AuthStatus struct {
+	TokenFile  string
+	Authorized bool
+	// Expiry is when the access token expires; it is refreshed afterwards, if Refreshable.
+	Expiry      time.Time
+	Refreshable bool
+}
+
+func (status AuthStatus) String() string {
+	if !status.Authorized {
+		return fmt.Sprintf("Not authorized (no token at %q)", status.TokenFile)
+	}
+	var expiry string
+	switch {
+	case status.Expiry.IsZero():
+		expiry = "doesn't expire"
+	case status.Expiry.Before(time.Now()):
+		expiry = "expired at " + status.Expiry.Format(time.RFC3339)
+	default:
+		expiry = "expires at " + status.Expiry.Format(time.RFC3339)
+	}
+	refresh := "can't be refreshed; authorize again once it expires"
+	if status.Refreshable {
+		refresh = "is refreshed automatically"
+	}
+	return fmt.Sprintf("Authorized (token at %q); the access token %s, and %s", status.TokenFile, expiry, refresh)
+}
+
+// Status returns the status of the authorization, as per the saved token.
+func (auth *GoogleAuth) Status() (AuthStatus, error) {
+	status := AuthStatus{TokenFile: auth.TokenFile}
+	token, err := auth.Token()
+	if errors.Is(err, fs.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	status.Authorized = true
+	status.Expiry = token.Expiry
+	status.Refreshable = token.RefreshToken != ""
+	return status, nil
+}
+
+// Revoke revokes the saved token (along with its refresh token), and deletes the TokenFile.
+func (auth *GoogleAuth) Revoke(ctx context.Context) error {
+	token, err := auth.Token()
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("The app isn't authorized; there is no token at %q", auth.TokenFile)
+	}
+	if err != nil {
+		return err
+	}
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	var response tokenResponse
+	if err := postForm(ctx, auth.RevokeURL, url.Values{"token": {value}}, &response); err != nil {
+		// note: the token which is already invalid (such as the one revoked from the account settings) is just deleted
+		if response.Error != "invalid_token" {
+			return fmt.Errorf("Unable to revoke the token: %w", err)
+		}
+	}
+	return os.Remove(auth.TokenFile)
+}
+
+func (auth *GoogleAuth) printf(format string, a ...interface{}) {
+	if auth.Out != nil {
+		fmt.Fprintf(auth.Out, format, a...)
+	}
+}
+
+// savingTokenSource is the token source which saves the refreshed tokens to the file.
+type savingTokenSource struct {
+	source oauth2.TokenSource
+	file   string
+	mu     sync.Mutex
+	saved  *oauth2.Token
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token.AccessToken != s.saved.AccessToken {
+		// note: the refreshed token works for the current session even if it couldn't be saved
+		if err := saveToken(s.file, token); err != nil {
+			logger.Warn(fmt.Sprintf("Unable to save the refreshed token: %v", err))
+		}
+		s.saved = token
+	}
+	return token, nil
+}
+
+// IsHeadless tells if the app is likely running on a machine without a browser (such as over SSH).
+func IsHeadless() bool {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return true
+	}
+	return runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// OpenBrowser opens the URL in the default browser.
+func OpenBrowser(link string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", link).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", link).Start()
+	default:
+		return exec.Command("xdg-open", link).Start()
+	}
+}
+
+// randomToken returns a random URL-safe string, as used for the state and the PKCE code verifier.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// postForm posts the form to the OAuth server, and decodes its JSON response (including the
+// error response) into v.
+func postForm(ctx context.Context, endpoint string, values url.Values, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := http.DefaultClient
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = c
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, v); err != nil && response.StatusCode < 300 {
+			return fmt.Errorf("Invalid response from %q: %w", endpoint, err)
+		}
+	}
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s from %q", response.Status, endpoint)
+	}
+	return nil
+}
+
+// Retrieves a token from a local file.
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tok := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(tok)
+	return tok, err
+}
+
+// Saves a token to a file path, readable only by the user.
+func saveToken(path string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("Unable to encode token: %w", err)
+	}
+	path = utils.TryConvertTildaBasedPath(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Unable to cache oauth token: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("Unable to cache oauth token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package calendar_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"golang.org/x/oauth2"
+)
+
+// oauthStandIn is a minimal OAuth 2.0 server, supporting the authorization code flow (with PKCE),
+// the device flow, the refresh of the tokens, and their revocation.
+type oauthStandIn struct {
+	mu          sync.Mutex
+	challenge   string
+	issued      int
+	accessToken string
+	devicePolls int
+	revoked     []string
+}
+
+func (server *oauthStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	writeJSON := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	// issue returns a new token, which expires too soon, and so, is refreshed on its first use
+	issue := func() {
+		server.issued++
+		server.accessToken = fmt.Sprintf("access-%d", server.issued)
+		writeJSON(http.StatusOK, map[string]interface{}{"access_token": server.accessToken, "token_type": "Bearer", "refresh_token": "refresh", "expires_in": 1})
+	}
+	_ = r.ParseForm()
+	switch r.URL.Path {
+	case "/auth":
+		// the consent is granted right away
+		if r.Form.Get("code_challenge_method") != "S256" || r.Form.Get("client_id") != "client" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		server.challenge = r.Form.Get("code_challenge")
+		http.Redirect(w, r, r.Form.Get("redirect_uri")+"?"+url.Values{"code": {"code"}, "state": {r.Form.Get("state")}}.Encode(), http.StatusFound)
+	case "/device":
+		writeJSON(http.StatusOK, map[string]interface{}{"device_code": "device", "user_code": "ABCD-EFGH", "verification_url": "https://example.com/device", "expires_in": 60, "interval": 1})
+	case "/token":
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			hash := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(hash[:]) != server.challenge {
+				writeJSON(http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			issue()
+		case "refresh_token":
+			issue()
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			if server.devicePolls++; server.devicePolls == 1 {
+				writeJSON(http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+				return
+			}
+			issue()
+		}
+	case "/revoke":
+		server.revoked = append(server.revoked, r.Form.Get("token"))
+	case "/api":
+		if r.Header.Get("Authorization") != "Bearer "+server.accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestGoogleAuth(t *testing.T) {
+	var tokenFile = "temp_test_dir/token.json"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(tokenFile))
+	standIn := &oauthStandIn{}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	auth := &calendar.GoogleAuth{
+		Config: &oauth2.Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Endpoint:     oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token", AuthStyle: oauth2.AuthStyleInParams},
+			Scopes:       []string{"calendar"},
+		},
+		TokenFile:     tokenFile,
+		DeviceAuthURL: server.URL + "/device",
+		RevokeURL:     server.URL + "/revoke",
+		Out:           io.Discard,
+	}
+	// the browser, which has the access granted, and is redirected back
+	auth.Browse = func(authURL string) error {
+		parsed, _ := url.Parse(authURL)
+		// a redirect with another state is rejected
+		response, err := http.Get(parsed.Query().Get("redirect_uri") + "?code=forged&state=forged")
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, response.StatusCode, http.StatusBadRequest)
+		response, err = http.Get(authURL)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, response.StatusCode, http.StatusOK)
+		return nil
+	}
+	status, err := auth.Status()
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, status.Authorized, false)
+	// authorize with the loopback redirect
+	token, err := auth.Authorize(context.Background(), false)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, token.AccessToken, "access-1")
+	info, err := os.Stat(tokenFile)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, info.Mode().Perm(), os.FileMode(0600))
+	status, _ = auth.Status()
+	utils.AssertEqual(t, status.Authorized, true)
+	utils.AssertEqual(t, status.Refreshable, true)
+	// the refreshed token is saved back
+	client, err := auth.Client(context.Background())
+	utils.AssertEqual(t, err, nil)
+	response, err := client.Get(server.URL + "/api")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, response.StatusCode, http.StatusOK)
+	token, _ = auth.Token()
+	utils.AssertEqual(t, token.AccessToken, "access-2")
+	// authorize with the device flow
+	token, err = auth.Authorize(context.Background(), true)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, token.AccessToken, "access-3")
+	utils.AssertEqual(t, standIn.devicePolls, 2)
+	// revoke the authorization
+	utils.AssertEqual(t, auth.Revoke(context.Background()), nil)
+	utils.AssertEqual(t, standIn.revoked, []string{"refresh"})
+	status, _ = auth.Status()
+	utils.AssertEqual(t, status.Authorized, false)
+	err = auth.Revoke(context.Background())
+	utils.AssertEqual(t, err.Error(), `The app isn't authorized; there is no token at "temp_test_dir/token.json"`)
+}
@@ -0,0 +1,50 @@
+package calendar_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+	gc "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+func TestGoogleDeleteEvent(t *testing.T) {
+	// the statuses of the deletions, by the ids of the events
+	statuses := map[string]int{
+		"live":      http.StatusNoContent,
+		"gone":      http.StatusGone,
+		"missing":   http.StatusNotFound,
+		"forbidden": http.StatusForbidden,
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		status := statuses[path.Base(r.URL.Path)]
+		if r.Method != http.MethodDelete || status == 0 {
+			status = http.StatusMethodNotAllowed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusNoContent {
+			fmt.Fprintf(w, `{"error": {"code": %d, "message": "%s", "errors": [{"reason": "%s"}]}}`, status, http.StatusText(status), http.StatusText(status))
+		}
+	}))
+	defer server.Close()
+	srv, err := gc.NewService(context.Background(), option.WithEndpoint(server.URL+"/calendar/v3/"), option.WithHTTPClient(server.Client()))
+	utils.AssertEqual(t, err, nil)
+	cal := calendar.PrivateGoogleCalendar(srv, &calendar.RetryOptions{MaxAttempts: 3, InitialDelay: 1, MaxDelay: 1})
+	ctx := context.Background()
+	utils.AssertEqual(t, cal.DeleteEvent(ctx, &calendar.Event{Id: "live"}), nil)
+	// the events already gone are taken as deleted (without any retries)
+	utils.AssertEqual(t, cal.DeleteEvent(ctx, &calendar.Event{Id: "gone"}), nil)
+	utils.AssertEqual(t, cal.DeleteEvent(ctx, &calendar.Event{Id: "missing"}), nil)
+	utils.AssertEqual(t, requests, 3)
+	// the rest of the failures are still reported
+	utils.AssertEqual(t, cal.DeleteEvent(ctx, &calendar.Event{Id: "forbidden"}) != nil, true)
+}
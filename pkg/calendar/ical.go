@@ -0,0 +1,421 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// icsProductId identifies the app as the producer of the iCalendar data.
+const icsProductId = "-//goyalmunish//reminder//EN"
+
+// icsLineLength is the length (in octets) beyond which the iCalendar lines are folded.
+const icsLineLength = 75
+
+const (
+	icsDateFormat      = "20060102"
+	icsLocalTimeFormat = "20060102T150405"
+	icsUTCTimeFormat   = "20060102T150405Z"
+)
+
+// Components which the events are written as (see ExportICS).
+const (
+	ICSComponent_Event = "VEVENT"
+	ICSComponent_Todo  = "VTODO"
+)
+
+// icsRefreshInterval is how often the subscribers of an exported feed are suggested to refresh it.
+const icsRefreshInterval = "PT1H"
+
+// EncodeICS returns the iCalendar (RFC 5545) representation of the events.
+// The private properties of the events are written as "X-" properties (such as
+// "X-REMINDER-NOTE-ID" for "reminder_note_id").
+// Note: The timezones are referred by their IANA names (without VTIMEZONE components), as
+// understood by the common calendar apps.
+func EncodeICS(events []*Event) string {
+	return encodeICS(events, ICSComponent_Event, "")
+}
+
+// ExportICS returns the iCalendar feed of the events, named as given, for the calendar apps to
+// import (or subscribe to). The events are written as the given components: events (VEVENT),
+// or to-dos (VTODO) which are due at the start of their events.
+func ExportICS(events []*Event, component string, name string) (string, error) {
+	if component != ICSComponent_Event && component != ICSComponent_Todo {
+		return "", fmt.Errorf("Unknown iCalendar component %q; expected %q or %q", component, ICSComponent_Event, ICSComponent_Todo)
+	}
+	return encodeICS(events, component, name), nil
+}
+
+// encodeICS returns the iCalendar representation of the events as the components, along with
+// the name of the calendar (if any).
+func encodeICS(events []*Event, component string, name string) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+	stamp := time.Now().UTC().Format(icsUTCTimeFormat)
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:" + icsProductId)
+	writeLine("CALSCALE:GREGORIAN")
+	if name != "" {
+		writeLine("X-WR-CALNAME:" + escapeICSText(name))
+		writeLine("REFRESH-INTERVAL;VALUE=DURATION:" + icsRefreshInterval)
+		writeLine("X-PUBLISHED-TTL:" + icsRefreshInterval)
+	}
+	for _, event := range events {
+		writeLine("BEGIN:" + component)
+		writeLine("UID:" + escapeICSText(event.Id))
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("SUMMARY:" + escapeICSText(event.Summary))
+		if event.Description != "" {
+			writeLine("DESCRIPTION:" + escapeICSText(event.Description))
+		}
+		if line := icsTimeLine("DTSTART", event.Start); line != "" {
+			writeLine(line)
+		}
+		if component == ICSComponent_Todo {
+			if line := icsTimeLine("DUE", event.Start); line != "" {
+				writeLine(line)
+			}
+		} else if line := icsTimeLine("DTEND", event.End); line != "" {
+			writeLine(line)
+		}
+		for _, line := range event.Recurrence {
+			writeLine(line)
+		}
+		switch {
+		case IsCancelled(event):
+			writeLine("STATUS:CANCELLED")
+		case component == ICSComponent_Todo:
+			writeLine("STATUS:NEEDS-ACTION")
+		default:
+			writeLine("STATUS:CONFIRMED")
+		}
+		if color := findEventColor(event.Color); color != nil {
+			writeLine("COLOR:" + color.css)
+		}
+		if component == ICSComponent_Event {
+			if event.Busy {
+				writeLine("TRANSP:OPAQUE")
+			} else {
+				writeLine("TRANSP:TRANSPARENT")
+			}
+		}
+		// the properties are sorted, so that the output is stable
+		var names []string
+		for name := range event.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			writeLine(icsPropertyName(name) + ":" + escapeICSText(event.Properties[name]))
+		}
+		for _, reminder := range event.Reminders {
+			writeLine("BEGIN:VALARM")
+			if reminder.Method == ReminderMethod_Email {
+				writeLine("ACTION:EMAIL")
+				writeLine("SUMMARY:" + escapeICSText(event.Summary))
+			} else {
+				writeLine("ACTION:DISPLAY")
+			}
+			writeLine("DESCRIPTION:" + escapeICSText(event.Summary))
+			writeLine(fmt.Sprintf("TRIGGER:-PT%dM", reminder.Minutes))
+			writeLine("END:VALARM")
+		}
+		writeLine("END:" + component)
+	}
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// DecodeICS returns the events (VEVENT components) of the iCalendar data.
+// A changed occurrence of a recurring event (with RECURRENCE-ID) gets the Id "<UID>_<RECURRENCE-ID>".
+func DecodeICS(data string) ([]*Event, error) {
+	var events []*Event
+	var event *Event
+	var recurrenceId string
+	var alarm *EventReminder
+	depth := 0 // nesting of the components within the VEVENT (such as VALARM)
+	for _, line := range unfoldICSLines(data) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, params, value, err := parseICSLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			// note: an event is busy unless it is transparent
+			event, recurrenceId = &Event{Status: "confirmed", Busy: true}, ""
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return nil, fmt.Errorf("Invalid iCalendar data: unexpected %q", line)
+			}
+			if recurrenceId != "" {
+				event.RecurringEventId = event.Id
+				event.Id = event.Id + "_" + recurrenceId
+			}
+			events = append(events, event)
+			event = nil
+			continue
+		case event == nil:
+			continue
+		case name == "BEGIN":
+			if depth++; depth == 1 && strings.EqualFold(value, "VALARM") {
+				alarm = &EventReminder{}
+			}
+			continue
+		case name == "END":
+			if depth--; depth == 0 && alarm != nil {
+				// note: only the alarms before the start (of the supported kinds) are taken as reminders
+				if alarm.Method != "" && alarm.Minutes >= 0 {
+					event.Reminders = append(event.Reminders, *alarm)
+				}
+				alarm = nil
+			}
+			continue
+		case depth == 1 && alarm != nil:
+			switch name {
+			case "ACTION":
+				alarm.Method = map[string]string{"DISPLAY": ReminderMethod_Popup, "EMAIL": ReminderMethod_Email}[strings.ToUpper(value)]
+			case "TRIGGER":
+				alarm.Minutes = -1
+				if minutes, ok := icsTriggerMinutes(value); ok && params["RELATED"] != "END" && params["VALUE"] == "" {
+					alarm.Minutes = minutes
+				}
+			}
+			continue
+		case depth > 0:
+			continue
+		}
+		switch name {
+		case "UID":
+			event.Id = unescapeICSText(value)
+		case "SUMMARY":
+			event.Summary = unescapeICSText(value)
+		case "DESCRIPTION":
+			event.Description = unescapeICSText(value)
+		case "DTSTART", "DTEND":
+			eventTime, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s of the event %q: %w", name, event.Id, err)
+			}
+			if name == "DTSTART" {
+				event.Start = eventTime
+			} else {
+				event.End = eventTime
+			}
+		case "RRULE", "EXDATE", "RDATE":
+			event.Recurrence = append(event.Recurrence, line)
+		case "STATUS":
+			if strings.EqualFold(value, "CANCELLED") {
+				event.Status = "cancelled"
+			}
+		case "RECURRENCE-ID":
+			recurrenceId = value
+		case "COLOR":
+			if color := findEventColor(value); color != nil {
+				event.Color = color.name
+			}
+		case "TRANSP":
+			event.Busy = !strings.EqualFold(value, "TRANSPARENT")
+		default:
+			if strings.HasPrefix(name, "X-") {
+				if event.Properties == nil {
+					event.Properties = make(map[string]string)
+				}
+				event.Properties[strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, "X-"), "-", "_"))] = unescapeICSText(value)
+			}
+		}
+	}
+	if event != nil {
+		return nil, fmt.Errorf("Invalid iCalendar data: the event %q isn't ended", event.Id)
+	}
+	return events, nil
+}
+
+// icsPropertyName returns name of the "X-" property for the private property of an event.
+func icsPropertyName(name string) string {
+	return "X-" + strings.ToUpper(strings.ReplaceAll(name, "_", "-"))
+}
+
+// icsTimeLine returns the iCalendar line (such as DTSTART) for the start or end of an event.
+func icsTimeLine(name string, value *EventTime) string {
+	if value == nil {
+		return ""
+	}
+	if value.DateTime == "" {
+		date, err := time.Parse("2006-01-02", value.Date)
+		if err != nil {
+			return ""
+		}
+		return name + ";VALUE=DATE:" + date.Format(icsDateFormat)
+	}
+	t, err := time.Parse(time.RFC3339, value.DateTime)
+	if err != nil {
+		return ""
+	}
+	if value.TimeZone != "" && value.TimeZone != "UTC" {
+		if location, err := time.LoadLocation(value.TimeZone); err == nil {
+			return name + ";TZID=" + value.TimeZone + ":" + t.In(location).Format(icsLocalTimeFormat)
+		}
+	}
+	return name + ":" + t.UTC().Format(icsUTCTimeFormat)
+}
+
+// parseICSTime parses the value (with its parameters) of DTSTART or DTEND.
+func parseICSTime(params map[string]string, value string) (*EventTime, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icsDateFormat) {
+		date, err := time.Parse(icsDateFormat, value)
+		if err != nil {
+			return nil, err
+		}
+		return &EventTime{Date: date.Format("2006-01-02"), TimeZone: params["TZID"]}, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsUTCTimeFormat, value)
+		if err != nil {
+			return nil, err
+		}
+		return &EventTime{DateTime: t.Format(time.RFC3339)}, nil
+	}
+	// a floating time is taken in UTC, unless the timezone is given
+	location := time.UTC
+	if zone := params["TZID"]; zone != "" {
+		var err error
+		if location, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("Unknown timezone %q: %w", zone, err)
+		}
+	}
+	t, err := time.ParseInLocation(icsLocalTimeFormat, value, location)
+	if err != nil {
+		return nil, err
+	}
+	return &EventTime{DateTime: t.Format(time.RFC3339), TimeZone: params["TZID"]}, nil
+}
+
+// icsTriggerMinutes returns the minutes before the start of an event, given by the (relative)
+// trigger of an alarm, such as "-PT30M" or "-P1D".
+func icsTriggerMinutes(value string) (int, bool) {
+	before := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") {
+		return 0, false
+	}
+	seconds, number, inTime := 0, 0, false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			continue
+		case r == 'T':
+			inTime = true
+		case r == 'W' && !inTime:
+			seconds += number * 7 * 24 * 3600
+		case r == 'D' && !inTime:
+			seconds += number * 24 * 3600
+		case r == 'H' && inTime:
+			seconds += number * 3600
+		case r == 'M' && inTime:
+			seconds += number * 60
+		case r == 'S' && inTime:
+			seconds += number
+		default:
+			return 0, false
+		}
+		number = 0
+	}
+	if !before && seconds != 0 {
+		return 0, false
+	}
+	return seconds / 60, true
+}
+
+// parseICSLine splits the iCalendar line into its name, parameters and value.
+func parseICSLine(line string) (string, map[string]string, string, error) {
+	// the value starts after the first colon which isn't within a quoted parameter value
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("Invalid iCalendar line %q", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+// unfoldICSLines returns the lines of the iCalendar data, with the folded lines joined.
+func unfoldICSLines(data string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// foldICSLine folds the line into lines of at most icsLineLength octets, without splitting a character.
+func foldICSLine(line string) string {
+	var b strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > icsLineLength {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	return b.String()
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeICSText escapes the text value of an iCalendar property.
+func escapeICSText(text string) string {
+	return icsTextEscaper.Replace(text)
+}
+
+// unescapeICSText reverses escapeICSText.
+func unescapeICSText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+			switch text[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(text[i])
+			}
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
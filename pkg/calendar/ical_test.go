@@ -0,0 +1,151 @@
+package calendar_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestEncodeDecodeICS(t *testing.T) {
+	events := []*calendar.Event{
+		taggedEvent("note-1", "pay the rent; on time, please"),
+		{
+			Id:          "e2",
+			Summary:     "team offsite",
+			Description: "line 1\nline 2 with a long text which is going to be folded as it is longer than seventy-five octets",
+			Start:       &calendar.EventTime{DateTime: "2026-10-16T15:30:00+05:30", TimeZone: "Asia/Kolkata"},
+			End:         &calendar.EventTime{DateTime: "2026-10-16T16:00:00+05:30", TimeZone: "Asia/Kolkata"},
+			Recurrence:  []string{"RRULE:FREQ=WEEKLY;BYDAY=FR"},
+			Status:      "confirmed",
+			Color:       "tomato",
+			Busy:        true,
+			Reminders:   []calendar.EventReminder{{Method: calendar.ReminderMethod_Popup, Minutes: 10}, {Method: calendar.ReminderMethod_Email, Minutes: 1440}},
+		},
+		{Id: "e3", Summary: "holiday", Start: &calendar.EventTime{Date: "2026-10-20"}, Status: "cancelled"},
+	}
+	events[0].Id = "e1"
+	events[0].Status = "confirmed"
+	data := calendar.EncodeICS(events)
+	utils.AssertEqual(t, strings.Contains(data, "DTSTART;TZID=Asia/Kolkata:20261016T153000\r\n"), true)
+	utils.AssertEqual(t, strings.Contains(data, "DTSTART;VALUE=DATE:20261020\r\n"), true)
+	utils.AssertEqual(t, strings.Contains(data, "X-REMINDER-NOTE-ID:note-1\r\n"), true)
+	utils.AssertEqual(t, strings.Contains(data, "COLOR:red\r\nTRANSP:OPAQUE\r\n"), true)
+	utils.AssertEqual(t, strings.Contains(data, "ACTION:DISPLAY\r\nDESCRIPTION:team offsite\r\nTRIGGER:-PT10M\r\n"), true)
+	for _, line := range strings.Split(data, "\r\n") {
+		utils.AssertEqual(t, len(line) <= 75, true)
+	}
+	decoded, err := calendar.DecodeICS(data)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, decoded[0].Summary, events[0].Summary)
+	utils.AssertEqual(t, decoded[0].Properties, events[0].Properties)
+	utils.AssertEqual(t, decoded[0].Start, events[0].Start)
+	utils.AssertEqual(t, decoded[1], events[1])
+	utils.AssertEqual(t, decoded[2], events[2])
+	// the tagged event keeps its hash, and so, it is in sync after the round trip
+	utils.AssertEqual(t, calendar.PlanSync(decoded[:1], events[:1]).IsEmpty(), true)
+}
+
+func TestDecodeICS(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:e1",
+		"SUMMARY:dentist",
+		"DTSTART:20261016T100000Z",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"DESCRIPTION:ignored",
+		"TRIGGER:-P1DT2H",
+		"END:VALARM",
+		"BEGIN:VALARM",
+		"ACTION:AUDIO",
+		"TRIGGER:-PT5M",
+		"END:VALARM",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER;RELATED=END:PT0S",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:e1",
+		"RECURRENCE-ID:20261023T100000Z",
+		"SUMMARY:dentist (moved)",
+		"DTSTART:20261024T100000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\n")
+	events, err := calendar.DecodeICS(data)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 2)
+	utils.AssertEqual(t, events[0].Description, "")
+	utils.AssertEqual(t, events[0].Reminders, []calendar.EventReminder{{Method: calendar.ReminderMethod_Popup, Minutes: 26 * 60}})
+	utils.AssertEqual(t, events[0].Busy, true)
+	utils.AssertEqual(t, events[0].Start.DateTime, "2026-10-16T10:00:00Z")
+	utils.AssertEqual(t, events[1].Id, "e1_20261023T100000Z")
+	utils.AssertEqual(t, events[1].RecurringEventId, "e1")
+	_, err = calendar.DecodeICS("BEGIN:VEVENT\nUID:e1\n")
+	utils.AssertEqual(t, err.Error(), `Invalid iCalendar data: the event "e1" isn't ended`)
+}
+
+func TestICSCalendar(t *testing.T) {
+	var icsFile = "temp_test_dir/reminder.ics"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(icsFile))
+	ctx := context.Background()
+	options := &calendar.Options{Provider: calendar.Provider_ICS, ICSFile: icsFile, Concurrency: 2}
+	cal, err := calendar.New(ctx, options)
+	utils.AssertEqual(t, err, nil)
+	// a missing file has no events
+	events, err := cal.Events(ctx, calendar.EventsQuery{})
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, len(events), 0)
+	plan := calendar.PlanSync(events, []*calendar.Event{taggedEvent("note-1", "pay the rent"), taggedEvent("note-2", "call the bank")})
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, plan, options).Err(), nil)
+	// the changes are read back from the file
+	cal, _ = calendar.New(ctx, options)
+	events, _ = calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, len(events), 2)
+	plan = calendar.PlanSync(events, []*calendar.Event{taggedEvent("note-1", "pay the rent on time")})
+	utils.AssertEqual(t, len(plan.Updates), 1)
+	utils.AssertEqual(t, len(plan.Deletes), 1)
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, plan, options).Err(), nil)
+	events, _ = cal.Events(ctx, calendar.EventsQuery{})
+	utils.AssertEqual(t, len(events), 1)
+	utils.AssertEqual(t, events[0].Summary, calendar.TitlePrefix+"pay the rent on time")
+}
+
+func TestSyncOfOldOverdueNote(t *testing.T) {
+	var icsFile = "temp_test_dir/reminder.ics"
+	// make sure temporary files and dirs are removed at the end of the test
+	defer os.RemoveAll(path.Dir(icsFile))
+	ctx := context.Background()
+	options := &calendar.Options{Provider: calendar.Provider_ICS, ICSFile: icsFile}
+	cal, _ := calendar.New(ctx, options)
+	// the note is overdue by more than the years the events are fetched for
+	overdue := func() *calendar.Event {
+		event := &calendar.Event{Summary: calendar.TitlePrefix + "renew the passport", Start: &calendar.EventTime{DateTime: "2019-01-01T10:00:00Z"}}
+		_ = calendar.TagEvent(event, "passport")
+		return event
+	}
+	events, _ := calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, calendar.ApplySync(ctx, cal, calendar.PlanSync(events, []*calendar.Event{overdue()}), options).Err(), nil)
+	// its event is still matched, rather than being inserted again
+	events, _ = calendar.FetchReminderEvents(ctx, cal, 2, 5)
+	utils.AssertEqual(t, len(events), 1)
+	utils.AssertEqual(t, calendar.PlanSync(events, []*calendar.Event{overdue()}).IsEmpty(), true)
+}
+
+func TestExportICS(t *testing.T) {
+	events := []*calendar.Event{taggedEvent("note-1", "pay the rent")}
+	data, err := calendar.ExportICS(events, calendar.ICSComponent_Todo, "reminder")
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, strings.Contains(data, "BEGIN:VTODO\r\n"), true)
+	utils.AssertEqual(t, strings.Contains(data, "REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n"), true)
+	_, err = calendar.ExportICS(events, "VJOURNAL", "reminder")
+	utils.AssertEqual(t, err.Error(), `Unknown iCalendar component "VJOURNAL"; expected "VEVENT" or "VTODO"`)
+}
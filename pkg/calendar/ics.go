@@ -0,0 +1,132 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// icsCalendar is a local iCalendar (.ics) file, which can be imported into (or subscribed to by)
+// any calendar app.
+type icsCalendar struct {
+	file     string
+	timeZone string
+	// events are the events of the file, read on the first use
+	events []*Event
+	loaded bool
+	// mu guards the events (and the file), as the changes may be made concurrently
+	mu sync.Mutex
+}
+
+// newICSCalendar returns the calendar of the iCalendar file (which is created on the first change).
+func newICSCalendar(options *Options) (Calendar, error) {
+	if options.ICSFile == "" {
+		return nil, errors.New("The iCalendar file (calendar.ics_file) isn't set")
+	}
+	return &icsCalendar{file: utils.TryConvertTildaBasedPath(options.ICSFile), timeZone: options.TimeZone}, nil
+}
+
+func (cal *icsCalendar) Name() string {
+	return fmt.Sprintf("iCalendar file %q", cal.file)
+}
+
+func (cal *icsCalendar) TimeZone(ctx context.Context) (string, error) {
+	return cal.timeZone, nil
+}
+
+func (cal *icsCalendar) Events(ctx context.Context, query EventsQuery) ([]*Event, error) {
+	cal.mu.Lock()
+	defer cal.mu.Unlock()
+	if err := cal.load(); err != nil {
+		return nil, err
+	}
+	var events []*Event
+	for _, event := range cal.events {
+		if query.Matches(event) {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	return events, nil
+}
+
+func (cal *icsCalendar) InsertEvent(ctx context.Context, event *Event) error {
+	cal.mu.Lock()
+	defer cal.mu.Unlock()
+	if err := cal.load(); err != nil {
+		return err
+	}
+	inserted := *event
+	if inserted.Id == "" {
+		inserted.Id = uuid.New().String()
+	}
+	cal.events = append(cal.events, &inserted)
+	return cal.save()
+}
+
+func (cal *icsCalendar) UpdateEvent(ctx context.Context, event *Event) error {
+	cal.mu.Lock()
+	defer cal.mu.Unlock()
+	index, err := cal.indexOf(event.Id)
+	if err != nil {
+		return err
+	}
+	updated := *event
+	cal.events[index] = &updated
+	return cal.save()
+}
+
+func (cal *icsCalendar) DeleteEvent(ctx context.Context, event *Event) error {
+	cal.mu.Lock()
+	defer cal.mu.Unlock()
+	index, err := cal.indexOf(event.Id)
+	if err != nil {
+		return err
+	}
+	cal.events = append(cal.events[:index], cal.events[index+1:]...)
+	return cal.save()
+}
+
+// indexOf returns index of the event with given id.
+func (cal *icsCalendar) indexOf(id string) (int, error) {
+	if err := cal.load(); err != nil {
+		return -1, err
+	}
+	for index, event := range cal.events {
+		if event.Id == id {
+			return index, nil
+		}
+	}
+	return -1, fmt.Errorf("Event %q doesn't exist in %s", id, cal.Name())
+}
+
+// load reads the events of the file, unless they are already read.
+// A missing file has no events.
+func (cal *icsCalendar) load() error {
+	if cal.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(cal.file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if cal.events, err = DecodeICS(string(data)); err != nil {
+		return fmt.Errorf("Unable to read %s: %w", cal.Name(), err)
+	}
+	cal.loaded = true
+	return nil
+}
+
+// save writes the events to the file, replacing it atomically.
+func (cal *icsCalendar) save() error {
+	if err := os.MkdirAll(filepath.Dir(cal.file), 0755); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(cal.file, []byte(EncodeICS(cal.events)), 0600)
+}
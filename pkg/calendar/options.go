@@ -1,15 +1,188 @@
 package calendar
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The calendars which can be synced (see Options.Provider).
+const (
+	Provider_Google = "google"
+	Provider_CalDAV = "caldav"
+	Provider_ICS    = "ics"
+)
+
 type Options struct {
+	// Provider is the calendar to be synced: "google" (default), "caldav" or "ics".
+	Provider       string `json:"provider" yaml:"provider" mapstructure:"provider"`
 	CredentialFile string `json:"credential_file" yaml:"credential_file" mapstructure:"credential_file"`
 	TokenFile      string `json:"token_file" yaml:"token_file" mapstructure:"token_file"`
-	DryMode        bool   `json:"dry_mode" yaml:"dry_mode" mapstructure:"dry_mode"`
+	// CalendarId is the Google Calendar to be synced ("primary" by default).
+	CalendarId string `json:"calendar_id" yaml:"calendar_id" mapstructure:"calendar_id"`
+	// CalendarName is the name of a dedicated Google Calendar to be synced (in place of CalendarId),
+	// which is created if it doesn't exist yet.
+	// Note: Finding (and creating) the calendar needs the access to all the calendars of the user,
+	// and so, the app is to be authorized again once it is set.
+	CalendarName string `json:"calendar_name" yaml:"calendar_name" mapstructure:"calendar_name"`
+	// CalDAV is the calendar collection to be synced with the "caldav" provider.
+	CalDAV *CalDAVOptions `json:"caldav" yaml:"caldav" mapstructure:"caldav"`
+	// ICSFile is the iCalendar file to be written by the "ics" provider.
+	ICSFile string `json:"ics_file" yaml:"ics_file" mapstructure:"ics_file"`
+	// TimeZone is the IANA timezone of the events of the notes without timezone, with the "caldav"
+	// and "ics" providers (the local timezone, if blank); Google Calendar has its own timezone.
+	TimeZone string `json:"timezone" yaml:"timezone" mapstructure:"timezone"`
+	DryMode  bool   `json:"dry_mode" yaml:"dry_mode" mapstructure:"dry_mode"`
+	// Events are the appearance and the reminders of the events of the notes.
+	Events *EventOptions `json:"events" yaml:"events" mapstructure:"events"`
+	// Filter selects the notes to be synced.
+	Filter *SyncFilter `json:"filter" yaml:"filter" mapstructure:"filter"`
+	// Concurrency is the maximum number of concurrent requests to the calendar, while applying
+	// the changes of a sync.
+	Concurrency int `json:"concurrency" yaml:"concurrency" mapstructure:"concurrency"`
+	// Retry is the retrying of the requests to the calendar which fail temporarily.
+	Retry *RetryOptions `json:"retry" yaml:"retry" mapstructure:"retry"`
+}
+
+// RetryOptions are the retries (with exponential backoff) of the requests to the calendar which
+// fail temporarily, such as on hitting the rate limits of the calendar, or on its server errors.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts of a request (1 for no retries).
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts" mapstructure:"max_attempts"`
+	// InitialDelay is the delay (in milliseconds) before the first retry, which is doubled for each
+	// of the next retries.
+	InitialDelay int `json:"initial_delay" yaml:"initial_delay" mapstructure:"initial_delay"`
+	// MaxDelay is the maximum delay (in milliseconds) between the retries.
+	MaxDelay int `json:"max_delay" yaml:"max_delay" mapstructure:"max_delay"`
+}
+
+// SyncFilter selects the notes (with a due date) to be synced, by their status, tags and main flag.
+// The notes marked as not to be synced are never synced, irrespective of the filter.
+type SyncFilter struct {
+	// Statuses are the statuses of the notes to be synced ("pending" by default).
+	Statuses []string `json:"statuses" yaml:"statuses" mapstructure:"statuses"`
+	// IncludeTags and IncludeGroups (if any) limit the sync to the notes with any of the tags (by
+	// their slugs), or with any tag of the groups.
+	IncludeTags   []string `json:"include_tags" yaml:"include_tags" mapstructure:"include_tags"`
+	IncludeGroups []string `json:"include_groups" yaml:"include_groups" mapstructure:"include_groups"`
+	// ExcludeTags and ExcludeGroups leave out the notes with any of the tags, or with any tag of
+	// the groups, over the IncludeTags and IncludeGroups.
+	ExcludeTags   []string `json:"exclude_tags" yaml:"exclude_tags" mapstructure:"exclude_tags"`
+	ExcludeGroups []string `json:"exclude_groups" yaml:"exclude_groups" mapstructure:"exclude_groups"`
+	// MainOnly limits the sync to the main notes.
+	MainOnly bool `json:"main_only" yaml:"main_only" mapstructure:"main_only"`
+	// DueWithinDays (if set) limits the sync to the notes due within the number of days from now
+	// (including the overdue ones).
+	DueWithinDays int `json:"due_within_days" yaml:"due_within_days" mapstructure:"due_within_days"`
+}
+
+// CalDAVOptions locate a CalDAV calendar collection, along with the credentials to access it.
+type CalDAVOptions struct {
+	// URL is the URL of the calendar collection, such as "https://dav.example.com/calendars/me/reminder/".
+	URL      string `json:"url" yaml:"url" mapstructure:"url"`
+	Username string `json:"username" yaml:"username" mapstructure:"username"`
+	// PasswordFile is the file containing the password (or app password) of the user.
+	PasswordFile string `json:"password_file" yaml:"password_file" mapstructure:"password_file"`
 }
 
+// EventOptions are the appearance of the events of the notes, along with their reminders.
+type EventOptions struct {
+	// Color is the color of the events (such as "basil" or "tomato"; see EventColors), or blank
+	// for the default color of the calendar.
+	Color string `json:"color" yaml:"color" mapstructure:"color"`
+	// TagColors are the colors of the events of the notes with the tags (by their slugs), in place
+	// of Color; for a note with many such tags, the first of its tags wins.
+	TagColors map[string]string `json:"tag_colors" yaml:"tag_colors" mapstructure:"tag_colors"`
+	// Duration is the duration (in minutes) of the events.
+	Duration int `json:"duration" yaml:"duration" mapstructure:"duration"`
+	// AllDay makes the events all-day events, on the due dates of their notes.
+	AllDay bool `json:"all_day" yaml:"all_day" mapstructure:"all_day"`
+	// Busy shows the events as busy (rather than as free) in the calendar.
+	Busy bool `json:"busy" yaml:"busy" mapstructure:"busy"`
+	// Reminders are the reminders of the events, in place of the default reminders of the calendar.
+	Reminders []EventReminder `json:"reminders" yaml:"reminders" mapstructure:"reminders"`
+}
+
+// defaultConcurrency is the maximum number of concurrent requests to the calendar, if not set otherwise.
+const defaultConcurrency = 4
+
+// defaultEventDuration is the duration (in minutes) of the events, if not set otherwise.
+const defaultEventDuration = 30
+
 func DefaultOptions() *Options {
 	return &Options{
+		Provider:       Provider_Google,
 		CredentialFile: "~/calendar_credentials.json",
 		TokenFile:      "~/calendar_token.json",
+		CalendarId:     googlePrimaryCalendarId,
+		CalDAV:         &CalDAVOptions{},
+		ICSFile:        "~/reminder/reminder.ics",
 		DryMode:        false,
+		Events:         DefaultEventOptions(),
+		Filter:         DefaultSyncFilter(),
+		Concurrency:    defaultConcurrency,
+		Retry:          DefaultRetryOptions(),
+	}
+}
+
+func DefaultRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:  5,
+		InitialDelay: 500,
+		MaxDelay:     30000,
+	}
+}
+
+func DefaultSyncFilter() *SyncFilter {
+	return &SyncFilter{
+		Statuses:      []string{"pending"},
+		IncludeTags:   []string{},
+		IncludeGroups: []string{},
+		ExcludeTags:   []string{},
+		ExcludeGroups: []string{},
+	}
+}
+
+func DefaultEventOptions() *EventOptions {
+	return &EventOptions{
+		TagColors: map[string]string{},
+		Duration:  defaultEventDuration,
+	}
+}
+
+// Validate tells if the colors and the reminders are valid.
+func (opts *EventOptions) Validate() error {
+	colors := []string{opts.Color}
+	var slugs []string
+	for slug := range opts.TagColors {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	for _, slug := range slugs {
+		colors = append(colors, opts.TagColors[slug])
+	}
+	for _, color := range colors {
+		if color != "" && findEventColor(color) == nil {
+			return fmt.Errorf("Unknown event color %q; expected one of %s", color, strings.Join(EventColors(), ", "))
+		}
+	}
+	for _, reminder := range opts.Reminders {
+		if reminder.Method != ReminderMethod_Popup && reminder.Method != ReminderMethod_Email {
+			return fmt.Errorf("Unknown reminder method %q; expected %q or %q", reminder.Method, ReminderMethod_Popup, ReminderMethod_Email)
+		}
+		if reminder.Minutes < 0 || reminder.Minutes > maxReminderMinutes {
+			return fmt.Errorf("The reminder of %d minutes isn't within 0 and %d minutes", reminder.Minutes, maxReminderMinutes)
+		}
+	}
+	return nil
+}
+
+// ColorFor returns the color of the events of the notes with the tags (by their slugs).
+func (opts *EventOptions) ColorFor(tagSlugs []string) string {
+	for _, slug := range tagSlugs {
+		if color, ok := opts.TagColors[slug]; ok {
+			return color
+		}
 	}
+	return opts.Color
 }
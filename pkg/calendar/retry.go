@@ -0,0 +1,113 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"google.golang.org/api/googleapi"
+)
+
+// statusError is a request to the calendar which failed with an unexpected HTTP status.
+type statusError struct {
+	method     string
+	target     string
+	statusCode int
+	status     string
+	header     http.Header
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %q", err.method, err.target, err.status)
+}
+
+// googleRateLimitReasons are the reasons of the "403 Forbidden" errors of Google Calendar which are
+// due to its rate limits (rather than due to the lack of access).
+var googleRateLimitReasons = []string{"rateLimitExceeded", "userRateLimitExceeded"}
+
+// isTemporary tells if the failed request can be retried, along with the delay asked by the
+// calendar (by "Retry-After" header), if any.
+// The requests failed due to the rate limits (429, or 403 of Google Calendar), or due to the
+// server errors (5xx) are retried.
+func isTemporary(err error) (bool, time.Duration) {
+	var statusCode int
+	var header http.Header
+	var apiErr *googleapi.Error
+	var statusErr *statusError
+	switch {
+	case errors.As(err, &apiErr):
+		statusCode, header = apiErr.Code, apiErr.Header
+		if statusCode == http.StatusForbidden {
+			for _, item := range apiErr.Errors {
+				for _, reason := range googleRateLimitReasons {
+					if item.Reason == reason {
+						return true, retryAfter(header)
+					}
+				}
+			}
+			return false, 0
+		}
+	case errors.As(err, &statusErr):
+		statusCode, header = statusErr.statusCode, statusErr.header
+	default:
+		return false, 0
+	}
+	if statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		return true, retryAfter(header)
+	}
+	return false, 0
+}
+
+// retryAfter returns the delay given (in seconds) by "Retry-After" header, if any.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// retry makes the request, and retries it (as per the options) as long as it fails temporarily,
+// with exponentially growing (and jittered) delays.
+// It gives up once the context is done.
+func retry(ctx context.Context, opts *RetryOptions, request func() error) error {
+	if opts == nil {
+		opts = DefaultRetryOptions()
+	}
+	delay := time.Duration(opts.InitialDelay) * time.Millisecond
+	maxDelay := time.Duration(opts.MaxDelay) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := request()
+		if err == nil || attempt >= opts.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		temporary, wait := isTemporary(err)
+		if !temporary {
+			return err
+		}
+		if wait == 0 {
+			// a random delay between half and full of the backoff, so that the concurrent
+			// requests don't retry all at once
+			wait = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		}
+		if wait > maxDelay {
+			wait = maxDelay
+		}
+		logger.Warn(fmt.Sprintf("The calendar request failed (attempt %d of %d); retrying in %v: %v", attempt, opts.MaxAttempts, wait, err))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (after: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
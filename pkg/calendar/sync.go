@@ -0,0 +1,340 @@
+package calendar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+)
+
+// The private properties by which the events registered by the app are identified.
+const (
+	// AppProperty (with AppPropertyValue) marks the events registered by the app, even if their title is changed.
+	AppProperty      string = "reminder_app"
+	AppPropertyValue string = "true"
+	// NoteIdProperty is the id of the note which the event belongs to.
+	NoteIdProperty string = "reminder_note_id"
+	// HashProperty is the hash of contents of the event, as it was last synced.
+	HashProperty string = "reminder_hash"
+)
+
+// DoneTitlePrefix is the prefix which marks an event (in place of TitlePrefix) as done in the calendar.
+const DoneTitlePrefix string = "[done] "
+
+// TagEvent tags the event with the identity of the note it belongs to, along with the hash
+// of its contents, so that the event can later be matched with the note (see PlanSync).
+func TagEvent(event *Event, noteId string) error {
+	event.Properties = nil
+	contents, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Unable to hash the event %q: %w", EventString(event), err)
+	}
+	hash := sha256.Sum256(contents)
+	event.Properties = map[string]string{
+		AppProperty:    AppPropertyValue,
+		NoteIdProperty: noteId,
+		HashProperty:   hex.EncodeToString(hash[:]),
+	}
+	return nil
+}
+
+// EventNoteId returns id of the note which the event belongs to, or blank string if the event isn't tagged.
+func EventNoteId(event *Event) string {
+	return eventProperty(event, NoteIdProperty)
+}
+
+// EventHash returns hash of contents of the event as it was last synced, or blank string if the event isn't tagged.
+func EventHash(event *Event) string {
+	return eventProperty(event, HashProperty)
+}
+
+// IsCancelled tells if the event is deleted in the calendar.
+func IsCancelled(event *Event) bool {
+	return event.Status == "cancelled"
+}
+
+// IsMarkedDone tells if the event is marked as done in the calendar, by titling it with DoneTitlePrefix.
+func IsMarkedDone(event *Event) bool {
+	return strings.HasPrefix(event.Summary, DoneTitlePrefix)
+}
+
+// EventStart returns start time of the event (in its timezone, or else in the given location), and
+// tells if it is an all-day event.
+// The start of an all-day event is the start of its day.
+func EventStart(event *Event, location *time.Location) (time.Time, bool, error) {
+	if event.Start == nil {
+		return time.Time{}, false, fmt.Errorf("Event %q has no start", event.Id)
+	}
+	if event.Start.TimeZone != "" {
+		if loc, err := time.LoadLocation(event.Start.TimeZone); err == nil {
+			location = loc
+		}
+	}
+	if event.Start.Date != "" {
+		start, err := time.ParseInLocation("2006-01-02", event.Start.Date, location)
+		return start, true, err
+	}
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Unable to parse start of the event %q: %w", event.Id, err)
+	}
+	return start.In(location), false, nil
+}
+
+// drifted tells if the existing event is moved or retitled (such as marked as done) in the calendar,
+// as compared to the desired event.
+func drifted(existing *Event, desired *Event) bool {
+	start1, allDay1, err1 := EventStart(existing, time.UTC)
+	start2, allDay2, err2 := EventStart(desired, time.UTC)
+	sameStart := err1 == nil && err2 == nil && allDay1 == allDay2 && start1.Equal(start2)
+	return !sameStart || existing.Summary != desired.Summary
+}
+
+// eventProperty returns the private property of the event, or blank string if it isn't set.
+func eventProperty(event *Event, name string) string {
+	return event.Properties[name]
+}
+
+/*
+A SyncPlan is the set of changes which brings the events registered by the app in the
+calendar in line with the events of the notes.
+*/
+type SyncPlan struct {
+	// Inserts are the events of the notes which aren't in the calendar yet.
+	Inserts []*Event
+	// Updates are the events of the notes which have changed since they were last synced;
+	// each of them has the Id of the calendar event which it is to be patched into.
+	Updates []*Event
+	// Deletes are the calendar events whose notes are no longer to be synced (such as the
+	// notes marked as done), along with the duplicate and the untagged (legacy) ones.
+	// Note: The events deleted in the calendar are ignored, and so, their notes (if still
+	// to be synced) are inserted again.
+	Deletes []*Event
+	// Unchanged is the number of events which are already in sync.
+	Unchanged int
+}
+
+// PlanSync compares the existing events registered by the app with the desired events (as
+// tagged by TagEvent), and returns the changes to be made to the calendar.
+// The existing events which are neither tagged nor titled with TitlePrefix are left alone.
+// An event which is moved or retitled in the calendar (with its note unchanged) is restored as per its note,
+// and so, any such changes are to be pulled into the notes beforehand (see ReminderData.PullCalendarChanges).
+func PlanSync(existing []*Event, desired []*Event) *SyncPlan {
+	plan := &SyncPlan{}
+	existingByNote := make(map[string]*Event, len(existing))
+	for _, event := range existing {
+		noteId := eventProperty(event, NoteIdProperty)
+		if IsCancelled(event) || event.RecurringEventId != "" {
+			// note: the changed occurrences of a recurring event are left alone as well
+			continue
+		}
+		if noteId == "" && !strings.HasPrefix(event.Summary, TitlePrefix) {
+			// not an event registered by the app
+			continue
+		}
+		if noteId == "" || existingByNote[noteId] != nil {
+			plan.Deletes = append(plan.Deletes, event)
+			continue
+		}
+		existingByNote[noteId] = event
+	}
+	for _, event := range desired {
+		noteId := eventProperty(event, NoteIdProperty)
+		current, ok := existingByNote[noteId]
+		switch {
+		case !ok:
+			plan.Inserts = append(plan.Inserts, event)
+		case EventHash(current) != EventHash(event) || drifted(current, event):
+			update := *event
+			update.Id = current.Id
+			plan.Updates = append(plan.Updates, &update)
+		default:
+			plan.Unchanged++
+		}
+		delete(existingByNote, noteId)
+	}
+	// the remaining events no longer belong to any of the desired events
+	var removed []*Event
+	for _, event := range existingByNote {
+		removed = append(removed, event)
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Id < removed[j].Id })
+	plan.Deletes = append(plan.Deletes, removed...)
+	return plan
+}
+
+// IsEmpty tells if there is nothing to be changed.
+func (plan *SyncPlan) IsEmpty() bool {
+	return len(plan.Inserts) == 0 && len(plan.Updates) == 0 && len(plan.Deletes) == 0
+}
+
+// plannedChanges are the events of the plan with the same change.
+type plannedChanges struct {
+	action string
+	events []*Event
+}
+
+// changes returns the events of the plan by their changes.
+func (plan *SyncPlan) changes() []plannedChanges {
+	return []plannedChanges{{SyncAction_Create, plan.Inserts}, {SyncAction_Update, plan.Updates}, {SyncAction_Delete, plan.Deletes}}
+}
+
+// String provides summary of the plan, along with the events to be changed.
+func (plan *SyncPlan) String() string {
+	lines := []string{fmt.Sprintf("Planned changes: %d to create, %d to update, %d to delete (%d unchanged)",
+		len(plan.Inserts), len(plan.Updates), len(plan.Deletes), plan.Unchanged)}
+	for _, change := range plan.changes() {
+		for _, event := range change.events {
+			lines = append(lines, fmt.Sprintf("  - %s %q", change.action, EventString(event)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The changes made to the calendar by a sync (see SyncResult).
+const (
+	SyncAction_Create = "create"
+	SyncAction_Update = "update"
+	SyncAction_Delete = "delete"
+)
+
+// A SyncResult is the outcome of a change made to the calendar.
+type SyncResult struct {
+	// Action is the change made ("create", "update" or "delete").
+	Action string
+	Event  *Event
+	// NoteId is the id of the note which the event belongs to (blank, for the untagged events).
+	NoteId string
+	// Err is the reason of failure of the change, if it failed.
+	Err error
+}
+
+/*
+A SyncReport is the outcome of applying a SyncPlan to the calendar, with the result of each of
+its changes (in the order of the plan).
+The changes which failed can be applied again (see RetryPlan).
+*/
+type SyncReport struct {
+	Results []*SyncResult
+	// DryMode tells that the changes were just logged (rather than made).
+	DryMode bool
+}
+
+// ApplySync applies the plan to the calendar, with up to options.Concurrency concurrent requests.
+// A failed change doesn't stop the rest of the changes; all of them are reported instead, and the
+// changes yet to be made when the context is done are reported as failed.
+// In dry mode, the changes are just logged.
+func ApplySync(ctx context.Context, cal Calendar, plan *SyncPlan, options *Options) *SyncReport {
+	logger.Info("Start: ApplySync")
+	defer logger.Info("End: ApplySync")
+	report := &SyncReport{DryMode: options.DryMode}
+	for _, change := range plan.changes() {
+		for _, event := range change.events {
+			report.Results = append(report.Results, &SyncResult{Action: change.action, Event: event, NoteId: EventNoteId(event)})
+		}
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, result := range report.Results {
+		if options.DryMode {
+			logger.Warn(fmt.Sprintf("Dry mode is enabled; skipping %s of the event %q.", result.Action, EventString(result.Event)))
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			continue
+		case slots <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(result *SyncResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Err = applyChange(ctx, cal, result.Action, result.Event)
+			if result.Err != nil {
+				logger.Error(fmt.Sprintf("Couldn't %s the Calendar event %q: %v", result.Action, EventString(result.Event), result.Err))
+				return
+			}
+			logger.Info(fmt.Sprintf("Done with %s of the event %q.", result.Action, EventString(result.Event)))
+		}(result)
+	}
+	wg.Wait()
+	return report
+}
+
+// applyChange makes the change to the calendar.
+// Note: The requests which fail temporarily are retried by the calendar itself (see RetryOptions).
+func applyChange(ctx context.Context, cal Calendar, action string, event *Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch action {
+	case SyncAction_Create:
+		return cal.InsertEvent(ctx, event)
+	case SyncAction_Update:
+		return cal.UpdateEvent(ctx, event)
+	case SyncAction_Delete:
+		return cal.DeleteEvent(ctx, event)
+	}
+	return fmt.Errorf("Unknown change %q of the event %q", action, EventString(event))
+}
+
+// Failed returns the results of the changes which failed.
+func (report *SyncReport) Failed() []*SyncResult {
+	var failed []*SyncResult
+	for _, result := range report.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns an error summarizing the failed changes, or nil if all of the changes were made.
+func (report *SyncReport) Err() error {
+	failed := report.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Failed to make %d of the %d changes to the calendar; the first failure: %w", len(failed), len(report.Results), failed[0].Err)
+}
+
+// RetryPlan returns the plan with just the changes which failed, to apply them again.
+func (report *SyncReport) RetryPlan() *SyncPlan {
+	plan := &SyncPlan{}
+	for _, result := range report.Failed() {
+		switch result.Action {
+		case SyncAction_Create:
+			plan.Inserts = append(plan.Inserts, result.Event)
+		case SyncAction_Update:
+			plan.Updates = append(plan.Updates, result.Event)
+		case SyncAction_Delete:
+			plan.Deletes = append(plan.Deletes, result.Event)
+		}
+	}
+	return plan
+}
+
+// String provides summary of the report, along with the failed changes.
+func (report *SyncReport) String() string {
+	failed := report.Failed()
+	if report.DryMode {
+		return fmt.Sprintf("Dry mode is enabled; skipped %d changes.", len(report.Results))
+	}
+	lines := []string{fmt.Sprintf("Sync report: %d changes made, %d failed", len(report.Results)-len(failed), len(failed))}
+	for _, result := range failed {
+		lines = append(lines, fmt.Sprintf("  - failed to %s %q (note %q): %v", result.Action, EventString(result.Event), result.NoteId, result.Err))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,91 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/calendar"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// taggedEvent returns an event of the note, tagged as per calendar.TagEvent.
+func taggedEvent(noteId string, summary string) *calendar.Event {
+	event := &calendar.Event{Summary: calendar.TitlePrefix + summary, Start: &calendar.EventTime{DateTime: "2026-10-16T10:00:00Z"}}
+	_ = calendar.TagEvent(event, noteId)
+	return event
+}
+
+func TestTagEvent(t *testing.T) {
+	event1 := taggedEvent("note-1", "pay the rent")
+	event2 := taggedEvent("note-1", "pay the rent")
+	event3 := taggedEvent("note-1", "pay the rent on time")
+	utils.AssertEqual(t, event1.Properties[calendar.NoteIdProperty], "note-1")
+	// the hash changes only with the contents of the event
+	utils.AssertEqual(t, event1.Properties[calendar.HashProperty], event2.Properties[calendar.HashProperty])
+	utils.AssertEqual(t, event1.Properties[calendar.HashProperty] != event3.Properties[calendar.HashProperty], true)
+	// re-tagging the event doesn't change its hash
+	_ = calendar.TagEvent(event1, "note-1")
+	utils.AssertEqual(t, event1.Properties[calendar.HashProperty], event2.Properties[calendar.HashProperty])
+}
+
+func TestEventStart(t *testing.T) {
+	start, allDay, err := calendar.EventStart(&calendar.Event{Start: &calendar.EventTime{DateTime: "2026-10-16T15:30:00+05:30", TimeZone: "Asia/Kolkata"}}, time.UTC)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, allDay, false)
+	utils.AssertEqual(t, start.Format(time.RFC3339), "2026-10-16T15:30:00+05:30")
+	start, allDay, _ = calendar.EventStart(&calendar.Event{Start: &calendar.EventTime{Date: "2026-10-16", TimeZone: "Asia/Kolkata"}}, time.UTC)
+	utils.AssertEqual(t, allDay, true)
+	utils.AssertEqual(t, start.Format(time.RFC3339), "2026-10-16T00:00:00+05:30")
+	// an event without timezone is taken in the given location
+	newYork, _ := time.LoadLocation("America/New_York")
+	start, _, _ = calendar.EventStart(&calendar.Event{Start: &calendar.EventTime{Date: "2026-10-16"}}, newYork)
+	utils.AssertEqual(t, start.Format(time.RFC3339), "2026-10-16T00:00:00-04:00")
+	_, _, err = calendar.EventStart(&calendar.Event{Id: "e1"}, time.UTC)
+	utils.AssertEqual(t, err.Error(), `Event "e1" has no start`)
+}
+
+func TestPlanSync(t *testing.T) {
+	withId := func(event *calendar.Event, id string) *calendar.Event {
+		event.Id = id
+		return event
+	}
+	existing := []*calendar.Event{
+		withId(taggedEvent("unchanged", "water the plants"), "e1"),
+		withId(taggedEvent("changed", "pay the rent"), "e2"),
+		withId(taggedEvent("removed", "renew the passport"), "e3"),
+		withId(taggedEvent("changed", "pay the rent"), "e4"),
+		withId(&calendar.Event{Summary: calendar.TitlePrefix + "registered by an older version"}, "e5"),
+		withId(&calendar.Event{Summary: "dinner with reminder friends"}, "e6"),
+		withId(taggedEvent("moved", "book the tickets"), "e7"),
+		withId(taggedEvent("deleted", "feed the cat"), "e8"),
+	}
+	// the event moved in the calendar (to the same instant, in another timezone, and then to another day)
+	existing[6].Start = &calendar.EventTime{DateTime: "2026-10-16T15:30:00+05:30", TimeZone: "Asia/Kolkata"}
+	utils.AssertEqual(t, calendar.PlanSync(existing[6:7], []*calendar.Event{taggedEvent("moved", "book the tickets")}).IsEmpty(), true)
+	existing[6].Start = &calendar.EventTime{DateTime: "2026-10-17T10:00:00Z"}
+	existing[7].Status = "cancelled"
+	desired := []*calendar.Event{
+		taggedEvent("unchanged", "water the plants"),
+		taggedEvent("changed", "pay the rent on time"),
+		taggedEvent("new", "call the bank"),
+		taggedEvent("moved", "book the tickets"),
+		taggedEvent("deleted", "feed the cat"),
+	}
+	plan := calendar.PlanSync(existing, desired)
+	// the events deleted in the calendar are inserted again, and the moved ones are moved back
+	utils.AssertEqual(t, plan.Inserts, []*calendar.Event{desired[2], desired[4]})
+	utils.AssertEqual(t, len(plan.Updates), 2)
+	utils.AssertEqual(t, plan.Updates[0].Id, "e2")
+	utils.AssertEqual(t, plan.Updates[1].Id, "e7")
+	utils.AssertEqual(t, plan.Updates[0].Summary, calendar.TitlePrefix+"pay the rent on time")
+	utils.AssertEqual(t, desired[1].Id, "")
+	// the duplicate and untagged events are deleted, but the events of others are left alone
+	var deleted []string
+	for _, event := range plan.Deletes {
+		deleted = append(deleted, event.Id)
+	}
+	utils.AssertEqual(t, deleted, []string{"e4", "e5", "e3"})
+	utils.AssertEqual(t, plan.Unchanged, 1)
+	utils.AssertEqual(t, plan.IsEmpty(), false)
+	utils.AssertEqual(t, calendar.PlanSync(existing[:1], desired[:1]).IsEmpty(), true)
+}
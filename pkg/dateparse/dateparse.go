@@ -0,0 +1,145 @@
+/*
+Package dateparse resolves (natural-language and relative) date expressions to dates.
+
+The supported expressions (case-insensitive) are:
+
+  - "today" and "tomorrow"
+  - "+3d", "+2w", "+1m" and "+1y" (days, weeks, months and years from today)
+  - "in 10 days", "in 2 weeks", "in 1 month" and "in 1 year"
+  - "next week", "next month" and "next year" (a week, month or year from today)
+  - "end of week" (Sunday), "end of month" and "end of year"
+  - weekday names, such as "friday" or "fri" (the coming one, which is today on a Friday), and
+    "next friday" (the coming one after today)
+  - ISO dates, such as "2026-11-03"
+  - "DD-MM-YYYY", and "DD-MM" (in the current year if the date is yet to come, otherwise in the next year)
+
+Adding months or years to a date clamps it to the end of the resulting month (such as
+"+1m" from 31st January is the last day of February).
+*/
+package dateparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidDate is returned for a date expression which isn't understood.
+var ErrorInvalidDate = errors.New("Invalid date")
+
+var (
+	offsetRegex   = regexp.MustCompile(`^\+(\d{1,4}) ?([dwmy])$`)
+	inRegex       = regexp.MustCompile(`^in (\d{1,4}) (day|week|month|year)s?$`)
+	isoRegex      = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})$`)
+	dayMonthRegex = regexp.MustCompile(`^(\d{1,2})-(\d{1,2})(?:-(\d{4}))?$`)
+	weekdayRegex  = regexp.MustCompile(`^(next )?([a-z]+)$`)
+)
+
+// weekdays maps the names (and abbreviations) of weekdays to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Parse resolves the date expression relative to now.
+// The date is returned as the start of the day in the location of now.
+func Parse(text string, now time.Time) (time.Time, error) {
+	expr := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch expr {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "next week":
+		return today.AddDate(0, 0, 7), nil
+	case "next month":
+		return addMonths(today, 1), nil
+	case "next year":
+		return addMonths(today, 12), nil
+	case "end of week":
+		// note: weeks end on Sunday
+		return today.AddDate(0, 0, (7-int(today.Weekday()))%7), nil
+	case "end of month":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), nil
+	case "end of year":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), nil
+	}
+	if match := offsetRegex.FindStringSubmatch(expr); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return addUnits(today, n, match[2]), nil
+	}
+	if match := inRegex.FindStringSubmatch(expr); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return addUnits(today, n, match[2][0:1]), nil
+	}
+	if match := isoRegex.FindStringSubmatch(expr); match != nil {
+		return date(match[1], match[2], match[3], today.Location(), text)
+	}
+	if match := dayMonthRegex.FindStringSubmatch(expr); match != nil {
+		if match[3] != "" {
+			return date(match[3], match[2], match[1], today.Location(), text)
+		}
+		// select the current year if the date is yet to come, otherwise the next year
+		t, err := date(strconv.Itoa(today.Year()), match[2], match[1], today.Location(), text)
+		if err == nil && !t.After(now) {
+			t, err = date(strconv.Itoa(today.Year()+1), match[2], match[1], today.Location(), text)
+		}
+		return t, err
+	}
+	if match := weekdayRegex.FindStringSubmatch(expr); match != nil {
+		if weekday, ok := weekdays[match[2]]; ok {
+			days := (int(weekday) - int(today.Weekday()) + 7) % 7
+			if days == 0 && match[1] != "" {
+				days = 7
+			}
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w %q", ErrorInvalidDate, text)
+}
+
+// addUnits adds n days (d), weeks (w), months (m) or years (y) to the date.
+func addUnits(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "d":
+		return t.AddDate(0, 0, n)
+	case "w":
+		return t.AddDate(0, 0, 7*n)
+	case "m":
+		return addMonths(t, n)
+	default:
+		return addMonths(t, 12*n)
+	}
+}
+
+// addMonths adds months to the date, clamping its day to the end of the resulting month.
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// date returns the date for given year, month and day, making sure that it exists.
+func date(year string, month string, day string, location *time.Location, text string) (time.Time, error) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, location)
+	if t.Year() != y || int(t.Month()) != m || t.Day() != d {
+		return time.Time{}, fmt.Errorf("%w %q: no such date", ErrorInvalidDate, text)
+	}
+	return t, nil
+}
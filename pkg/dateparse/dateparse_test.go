@@ -0,0 +1,60 @@
+package dateparse_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/dateparse"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	// Fri Oct 16 2026 15:04:05 GMT+0000
+	now := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+	var tests = []struct {
+		text string
+		want time.Time
+	}{
+		{"today", date(2026, 10, 16)},
+		{" Tomorrow ", date(2026, 10, 17)},
+		{"+3d", date(2026, 10, 19)},
+		{"+2w", date(2026, 10, 30)},
+		{"+1y", date(2027, 10, 16)},
+		{"in 10 days", date(2026, 10, 26)},
+		{"in 1 month", date(2026, 11, 16)},
+		{"next week", date(2026, 10, 23)},
+		{"friday", date(2026, 10, 16)},
+		{"next friday", date(2026, 10, 23)},
+		{"mon", date(2026, 10, 19)},
+		{"end of week", date(2026, 10, 18)},
+		{"end of month", date(2026, 10, 31)},
+		{"end   of  year", date(2026, 12, 31)},
+		{"2026-11-03", date(2026, 11, 3)},
+		{"3-11-2026", date(2026, 11, 3)},
+		// DD-MM is in the next year, unless the date is yet to come
+		{"03-11", date(2026, 11, 3)},
+		{"16-10", date(2027, 10, 16)},
+	}
+	for _, test := range tests {
+		got, err := dateparse.Parse(test.text, now)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, got, test.want)
+	}
+	// adding months clamps the day to the end of the month
+	got, _ := dateparse.Parse("+1m", date(2026, 1, 31))
+	utils.AssertEqual(t, got, date(2026, 2, 28))
+	// the date is in the location of now
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	got, _ = dateparse.Parse("tomorrow", time.Date(2026, 10, 16, 23, 0, 0, 0, kolkata))
+	utils.AssertEqual(t, got, time.Date(2026, 10, 17, 0, 0, 0, 0, kolkata))
+	// invalid dates
+	for _, text := range []string{"", "someday", "next", "31-02-2026", "2026-13-01", "+3", "in a week", "next fryday"} {
+		_, err := dateparse.Parse(text, now)
+		utils.AssertEqual(t, errors.Is(err, dateparse.ErrorInvalidDate), true)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Package filelock provides an advisory, OS-level lock on a file.
+
+The lock is held on a separate lock file, which also records the details
+(PID, hostname, and start time) of the process holding the lock. As the OS
+releases the lock when its process dies, a lock left behind by a killed
+process is detected as stale and is taken over automatically.
+*/
+package filelock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goyalmunish/reminder/pkg/logger"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+// errWouldBlock is returned by tryLock if the lock is held by another process.
+var errWouldBlock = errors.New("Lock is held by another process")
+
+/*
+An Info represents details of the process holding the lock.
+*/
+type Info struct {
+	PID       int    `json:"pid"`
+	Hostname  string `json:"hostname"`
+	StartedAt int64  `json:"started_at"`
+}
+
+// String provides basic string representation of the lock info.
+func (info Info) String() string {
+	return fmt.Sprintf("PID %d on %q since %s", info.PID, info.Hostname, utils.UnixTimestampToLongTimeStr(info.StartedAt))
+}
+
+// IsStale tells if the process holding the lock no longer exists.
+// A lock held from another host is never considered as stale, as its process can't be looked up.
+func (info Info) IsStale() bool {
+	hostname, _ := os.Hostname()
+	if info.Hostname != hostname {
+		return false
+	}
+	return !processExists(info.PID)
+}
+
+/*
+A LockedError is returned when the lock is held by another (live) process.
+*/
+type LockedError struct {
+	Path string
+	Info *Info
+}
+
+func (e *LockedError) Error() string {
+	if e.Info == nil {
+		return fmt.Sprintf("The lock %q is held by another process", e.Path)
+	}
+	return fmt.Sprintf("The lock %q is held by %v", e.Path, e.Info)
+}
+
+/*
+A Lock represents the acquired lock.
+*/
+type Lock struct {
+	Path string
+	Info Info
+	file *os.File
+}
+
+// Acquire acquires the lock on given lock file path, without blocking.
+// It returns *LockedError if the lock is held by another process.
+func Acquire(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	previousInfo, _ := readInfo(file)
+	if err := tryLock(file, previousInfo); err != nil {
+		file.Close()
+		if errors.Is(err, errWouldBlock) {
+			return nil, &LockedError{Path: path, Info: previousInfo}
+		}
+		return nil, err
+	}
+	// the lock is acquired; any details left in the lock file are of a process which died
+	if previousInfo != nil {
+		logger.Warn(fmt.Sprintf("Taking over the stale lock %q held by %v.", path, previousInfo))
+	}
+	hostname, _ := os.Hostname()
+	lock := &Lock{
+		Path: path,
+		Info: Info{PID: os.Getpid(), Hostname: hostname, StartedAt: utils.CurrentUnixTimestamp()},
+		file: file,
+	}
+	if err := lock.writeInfo(); err != nil {
+		_ = unlock(file)
+		file.Close()
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("Acquired the lock %q.", path))
+	return lock, nil
+}
+
+// Release releases the lock.
+// Note: The lock file is deliberately not removed, as removing it could let
+// two processes hold the lock on two different files at the same time.
+func (lock *Lock) Release() error {
+	if lock == nil || lock.file == nil {
+		return nil
+	}
+	defer func() { lock.file = nil }()
+	// clear the details, so that they are not mistaken as of a stale lock
+	if err := lock.file.Truncate(0); err != nil {
+		utils.LogError(err)
+	}
+	if err := unlock(lock.file); err != nil {
+		lock.file.Close()
+		return err
+	}
+	logger.Info(fmt.Sprintf("Released the lock %q.", lock.Path))
+	return lock.file.Close()
+}
+
+// ReadInfo returns details of the process holding the lock on given lock file path.
+// It returns nil if the lock is not held.
+func ReadInfo(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	return readInfo(file)
+}
+
+// readInfo reads the lock details from the lock file.
+// It returns nil if the lock file is blank (or invalid).
+func readInfo(file *os.File) (*Info, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	byteValue, err := io.ReadAll(file)
+	if err != nil || len(byteValue) == 0 {
+		return nil, err
+	}
+	var info Info
+	if err := json.Unmarshal(byteValue, &info); err != nil {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// writeInfo records the lock details in the lock file.
+func (lock *Lock) writeInfo() error {
+	byteValue, err := json.Marshal(lock.Info)
+	if err != nil {
+		return err
+	}
+	if err := lock.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := lock.file.WriteAt(byteValue, 0); err != nil {
+		return err
+	}
+	return lock.file.Sync()
+}
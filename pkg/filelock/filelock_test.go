@@ -0,0 +1,53 @@
+package filelock_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/filelock"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func TestAcquire(t *testing.T) {
+	var lockFilePath = "temp_test_dir/mydata.json.lock"
+	_ = os.MkdirAll(path.Dir(lockFilePath), 0755)
+	defer os.RemoveAll(path.Dir(lockFilePath))
+	// case 1 (lock is acquired, and its details are recorded)
+	lock, err := filelock.Acquire(lockFilePath)
+	utils.AssertEqual(t, err, nil)
+	info, _ := filelock.ReadInfo(lockFilePath)
+	utils.AssertEqual(t, info.PID, os.Getpid())
+	utils.AssertEqual(t, info.IsStale(), false)
+	// case 2 (lock is already held)
+	_, err = filelock.Acquire(lockFilePath)
+	var lockedErr *filelock.LockedError
+	utils.AssertEqual(t, errors.As(err, &lockedErr), true)
+	utils.AssertEqual(t, lockedErr.Info.PID, os.Getpid())
+	// case 3 (lock is released, and can be acquired again)
+	utils.AssertEqual(t, lock.Release(), nil)
+	info, _ = filelock.ReadInfo(lockFilePath)
+	utils.AssertEqual(t, info == nil, true)
+	lock, err = filelock.Acquire(lockFilePath)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, lock.Release(), nil)
+}
+
+func TestAcquireStaleLock(t *testing.T) {
+	var lockFilePath = "temp_test_dir/mydata.json.lock"
+	_ = os.MkdirAll(path.Dir(lockFilePath), 0755)
+	defer os.RemoveAll(path.Dir(lockFilePath))
+	// lock details left behind by a process which no longer exists
+	hostname, _ := os.Hostname()
+	staleInfo := filelock.Info{PID: 1 << 30, Hostname: hostname, StartedAt: 1609669235}
+	utils.AssertEqual(t, staleInfo.IsStale(), true)
+	_ = os.WriteFile(lockFilePath, []byte(`{"pid": 1073741824, "hostname": "`+hostname+`", "started_at": 1609669235}`), 0600)
+	lock, err := filelock.Acquire(lockFilePath)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, lock.Info.PID, os.Getpid())
+	utils.AssertEqual(t, lock.Release(), nil)
+	// lock held from another host is never considered as stale
+	remoteInfo := filelock.Info{PID: 1 << 30, Hostname: hostname + "-remote"}
+	utils.AssertEqual(t, remoteInfo.IsStale(), false)
+}
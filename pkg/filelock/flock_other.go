@@ -0,0 +1,30 @@
+//go:build !unix
+
+package filelock
+
+import (
+	"os"
+)
+
+// tryLock acquires the lock on platforms without flock support.
+// Here the lock is held as long as the recorded lock details are of a live process.
+func tryLock(_ *os.File, previousInfo *Info) error {
+	if previousInfo != nil && !previousInfo.IsStale() {
+		return errWouldBlock
+	}
+	return nil
+}
+
+// unlock releases the lock; clearing the lock details is sufficient here.
+func unlock(_ *os.File) error {
+	return nil
+}
+
+// processExists tells if the process with given pid is running.
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
@@ -0,0 +1,515 @@
+/*
+Package rrule implements a subset of the recurrence rules (RRULE) of RFC 5545.
+
+The supported rule parts are FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL,
+BYDAY (with an ordinal, such as 2MO or -1FR, for MONTHLY and YEARLY rules),
+BYMONTHDAY (negative values count from the end of the month), BYMONTH, and
+either of COUNT or UNTIL. Weeks start on Monday.
+
+The occurrences of a rule are expanded from its start time (DTSTART), which is
+not a part of the rule itself; each occurrence has the clock time (and location)
+of the start time.
+*/
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidRule is returned for a recurrence rule which is malformed or is not supported.
+var ErrorInvalidRule = errors.New("Invalid recurrence rule")
+
+// A Frequency is the type of the recurrence.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// UntilFormat is the (UTC) format of the UNTIL rule part.
+const UntilFormat = "20060102T150405Z"
+
+// maxEmptyPeriods is the number of consecutive periods without any occurrence after
+// which the expansion is given up (such as for a rule for 30th of February).
+const maxEmptyPeriods = 1000
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// A WeekdayNum is a weekday of the BYDAY rule part, along with its (optional) ordinal.
+// For example, {N: 2, Weekday: time.Monday} is the second Monday of the month, and
+// {N: -1, Weekday: time.Friday} is the last Friday of the month.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (wn WeekdayNum) String() string {
+	if wn.N == 0 {
+		return weekdayCodes[wn.Weekday]
+	}
+	return fmt.Sprintf("%d%s", wn.N, weekdayCodes[wn.Weekday])
+}
+
+// A Rule is a recurrence rule.
+type Rule struct {
+	Freq Frequency
+	// Interval is the number of periods (of Freq) between the recurrences; defaults to 1.
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// Count is the number of occurrences, if non-zero.
+	Count int
+	// Until is the (inclusive) end of the occurrences, if non-zero.
+	Until time.Time
+}
+
+// Parse parses the text of a recurrence rule, such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE".
+// The text may be prefixed with "RRULE:".
+func Parse(text string) (*Rule, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(strings.ToUpper(text), "RRULE:") {
+		text = text[len("RRULE:"):]
+	}
+	if text == "" {
+		return nil, fmt.Errorf("%w: it is empty", ErrorInvalidRule)
+	}
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(text, ";") {
+		name, value, found := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !found || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrorInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: part %s is repeated", ErrorInvalidRule, name)
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(value)
+		case "COUNT":
+			rule.Count, err = parsePositive(value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(value, 1, 12)
+			for _, month := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only MO is supported as start of the week")
+			}
+		default:
+			err = errors.New("unsupported part")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s=%s: %v", ErrorInvalidRule, name, value, err)
+		}
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Validate tells if the rule is a supported one.
+func (r *Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return fmt.Errorf("%w: FREQ is missing", ErrorInvalidRule)
+	default:
+		return fmt.Errorf("%w: unsupported frequency %q", ErrorInvalidRule, r.Freq)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("%w: INTERVAL must be positive", ErrorInvalidRule)
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("%w: COUNT must be positive", ErrorInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: COUNT and UNTIL can't be used together", ErrorInvalidRule)
+	}
+	for _, wn := range r.ByDay {
+		if wn.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return fmt.Errorf("%w: BYDAY with ordinal (%s) is supported only for MONTHLY and YEARLY frequencies", ErrorInvalidRule, wn)
+		}
+		if wn.N < -5 || wn.N > 5 {
+			return fmt.Errorf("%w: ordinal of BYDAY (%s) must be within -5 and 5", ErrorInvalidRule, wn)
+		}
+	}
+	for _, day := range r.ByMonthDay {
+		if day == 0 || day < -31 || day > 31 {
+			return fmt.Errorf("%w: BYMONTHDAY must be within 1 and 31 (or -31 and -1)", ErrorInvalidRule)
+		}
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return fmt.Errorf("%w: BYMONTHDAY can't be used with WEEKLY frequency", ErrorInvalidRule)
+	}
+	// note: within a YEARLY rule, BYDAY and BYMONTHDAY are supported only for the months of BYMONTH
+	if r.Freq == Yearly && len(r.ByMonth) == 0 && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0) {
+		return fmt.Errorf("%w: BYDAY and BYMONTHDAY require BYMONTH for YEARLY frequency", ErrorInvalidRule)
+	}
+	return nil
+}
+
+// String returns the rule in its canonical text form (without "RRULE:" prefix).
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, 0, len(r.ByMonth))
+		for _, month := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(month)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wn := range r.ByDay {
+			days = append(days, wn.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(UntilFormat))
+	}
+	return strings.Join(parts, ";")
+}
+
+// After returns the first occurrence after t (or at t, if inclusive), and whether there is one.
+func (r *Rule) After(dtstart time.Time, t time.Time, inclusive bool) (time.Time, bool) {
+	var found time.Time
+	r.iterate(dtstart, r.startPeriod(dtstart, t, 1), func(occurrence time.Time) bool {
+		if occurrence.After(t) || (inclusive && occurrence.Equal(t)) {
+			found = occurrence
+			return false
+		}
+		return true
+	})
+	return found, !found.IsZero()
+}
+
+// Before returns the last occurrence before t (or at t, if inclusive), and whether there is one.
+func (r *Rule) Before(dtstart time.Time, t time.Time, inclusive bool) (time.Time, bool) {
+	// look back progressively, as the periods just before t may not have any occurrence
+	for lookBack := 1; ; lookBack *= 2 {
+		var found time.Time
+		start := r.startPeriod(dtstart, t, lookBack)
+		r.iterate(dtstart, start, func(occurrence time.Time) bool {
+			if occurrence.Before(t) || (inclusive && occurrence.Equal(t)) {
+				found = occurrence
+				return true
+			}
+			return false
+		})
+		if !found.IsZero() {
+			return found, true
+		}
+		if start == 0 || lookBack > maxEmptyPeriods {
+			return time.Time{}, false
+		}
+	}
+}
+
+// Between returns the occurrences within from and to (both inclusive).
+func (r *Rule) Between(dtstart time.Time, from time.Time, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.iterate(dtstart, r.startPeriod(dtstart, from, 1), func(occurrence time.Time) bool {
+		if occurrence.After(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// iterate calls fn with each occurrence (in order) from the given period onwards, until fn returns false.
+func (r *Rule) iterate(dtstart time.Time, fromPeriod int, fn func(time.Time) bool) {
+	count := 0
+	emptyPeriods := 0
+	for period := fromPeriod; emptyPeriods < maxEmptyPeriods; period++ {
+		candidates := r.candidates(dtstart, period)
+		if len(candidates) == 0 {
+			emptyPeriods++
+			continue
+		}
+		emptyPeriods = 0
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return
+			}
+			count++
+			if !fn(candidate) {
+				return
+			}
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// startPeriod returns the index of the period to start the expansion from, so as to find the
+// occurrences around t; that is lookBack periods before the one of t.
+// The occurrences are counted from the very first period, if the rule has COUNT.
+func (r *Rule) startPeriod(dtstart time.Time, t time.Time, lookBack int) int {
+	if r.Count > 0 || !t.After(dtstart) {
+		return 0
+	}
+	t = t.In(dtstart.Location())
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = daysBetween(dtstart, t)
+	case Weekly:
+		periods = daysBetween(weekStart(dtstart), weekStart(t)) / 7
+	case Monthly:
+		periods = (t.Year()-dtstart.Year())*12 + int(t.Month()) - int(dtstart.Month())
+	case Yearly:
+		periods = t.Year() - dtstart.Year()
+	}
+	start := periods/r.Interval - lookBack
+	if start < 0 {
+		return 0
+	}
+	return start
+}
+
+// candidates returns sorted occurrences (disregarding COUNT, UNTIL and DTSTART) within the given period.
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	year, month, day := dtstart.Date()
+	hour, min, sec := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, dtstart.Location())
+	}
+	var occurrences []time.Time
+	switch r.Freq {
+	case Daily:
+		occurrence := at(year, month, day+period*r.Interval)
+		if r.matchesMonth(occurrence.Month()) && r.matchesDay(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+	case Weekly:
+		monday := weekStart(dtstart)
+		for i := 0; i < 7; i++ {
+			occurrence := at(monday.Year(), monday.Month(), monday.Day()+period*r.Interval*7+i)
+			if !r.matchesMonth(occurrence.Month()) {
+				continue
+			}
+			if (len(r.ByDay) == 0 && occurrence.Weekday() == dtstart.Weekday()) || (len(r.ByDay) > 0 && r.matchesDay(occurrence)) {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+	case Monthly:
+		first := at(year, month+time.Month(period*r.Interval), 1)
+		if r.matchesMonth(first.Month()) {
+			for _, d := range r.monthDays(first.Year(), first.Month(), day) {
+				occurrences = append(occurrences, at(first.Year(), first.Month(), d))
+			}
+		}
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+		months = append([]time.Month{}, months...)
+		sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+		for _, m := range months {
+			for _, d := range r.monthDays(year+period*r.Interval, m, day) {
+				occurrences = append(occurrences, at(year+period*r.Interval, m, d))
+			}
+		}
+	}
+	return occurrences
+}
+
+// monthDays returns sorted days of the given month matching BYMONTHDAY and BYDAY, or just
+// the defaultDay (if the month has it) if none of them is given.
+func (r *Rule) monthDays(year int, month time.Month, defaultDay int) []int {
+	lastDay := daysIn(year, month)
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if defaultDay > lastDay {
+			return nil
+		}
+		return []int{defaultDay}
+	}
+	var days []int
+	for day := 1; day <= lastDay; day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if r.matchesDay(date) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// matchesMonth tells if the month is one of BYMONTH (if given).
+func (r *Rule) matchesMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay tells if the date is one of BYMONTHDAY and one of BYDAY (each of them, if given).
+// Ordinals of BYDAY are relative to the month of the date.
+func (r *Rule) matchesDay(date time.Time) bool {
+	lastDay := daysIn(date.Year(), date.Month())
+	if len(r.ByMonthDay) > 0 {
+		matched := false
+		for _, day := range r.ByMonthDay {
+			if day == date.Day() || (day < 0 && lastDay+1+day == date.Day()) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.ByDay) > 0 {
+		matched := false
+		for _, wn := range r.ByDay {
+			if wn.Weekday != date.Weekday() {
+				continue
+			}
+			nth := (date.Day()-1)/7 + 1
+			nthFromEnd := -((lastDay-date.Day())/7 + 1)
+			if wn.N == 0 || wn.N == nth || wn.N == nthFromEnd {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// daysIn returns the number of days in the month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// daysBetween returns the number of calendar days from a to b.
+func daysBetween(a time.Time, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// weekStart returns the Monday of the week of t.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, errors.New("must be a positive number")
+	}
+	return n, nil
+}
+
+func parseIntList(value string, min int, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("%q must be a non-zero number within %d and %d", item, min, max)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("malformed weekday %q", item)
+		}
+		code := item[len(item)-2:]
+		weekday := -1
+		for i, c := range weekdayCodes {
+			if c == code {
+				weekday = i
+			}
+		}
+		if weekday == -1 {
+			return nil, fmt.Errorf("unknown weekday %q", code)
+		}
+		wn := WeekdayNum{Weekday: time.Weekday(weekday)}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 {
+				return nil, fmt.Errorf("malformed ordinal of weekday %q", item)
+			}
+			wn.N = n
+		}
+		days = append(days, wn)
+	}
+	return days, nil
+}
+
+// parseUntil parses the UNTIL rule part; a date (without time) is taken as the whole day (in UTC).
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	for _, format := range []string{UntilFormat, "20060102T150405"} {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("must be of the form YYYYMMDD or YYYYMMDDThhmmssZ")
+}
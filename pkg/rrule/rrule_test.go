@@ -0,0 +1,112 @@
+package rrule_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goyalmunish/reminder/pkg/rrule"
+	"github.com/goyalmunish/reminder/pkg/utils"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	// valid rules are returned in their canonical form
+	validRules := map[string]string{
+		"FREQ=DAILY": "FREQ=DAILY",
+		"rrule:freq=weekly;interval=2;byday=mo,we":   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3":            "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+		"FREQ=MONTHLY;BYMONTHDAY=-1;INTERVAL=1":      "FREQ=MONTHLY;BYMONTHDAY=-1",
+		"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH":           "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+		"FREQ=YEARLY;UNTIL=20271231":                 "FREQ=YEARLY;UNTIL=20271231T235959Z",
+		"FREQ=WEEKLY;WKST=MO;UNTIL=20270101T100000Z": "FREQ=WEEKLY;UNTIL=20270101T100000Z",
+	}
+	for text, want := range validRules {
+		rule, err := rrule.Parse(text)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, rule.String(), want)
+	}
+	// invalid (or unsupported) rules
+	invalidRules := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20270101",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=YEARLY;BYDAY=1MO",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;FREQ=WEEKLY",
+	}
+	for _, text := range invalidRules {
+		_, err := rrule.Parse(text)
+		utils.AssertEqual(t, errors.Is(err, rrule.ErrorInvalidRule), true)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	var tests = []struct {
+		rule    string
+		dtstart time.Time
+		to      time.Time
+		want    []time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=3", date(2026, 1, 1), date(2026, 1, 10), []time.Time{date(2026, 1, 1), date(2026, 1, 4), date(2026, 1, 7), date(2026, 1, 10)}},
+		// 1st Jan 2026 is a Thursday
+		{"FREQ=WEEKLY;BYDAY=MO,TH", date(2026, 1, 1), date(2026, 1, 11), []time.Time{date(2026, 1, 1), date(2026, 1, 5), date(2026, 1, 8)}},
+		{"FREQ=WEEKLY;INTERVAL=2", date(2026, 1, 1), date(2026, 1, 31), []time.Time{date(2026, 1, 1), date(2026, 1, 15), date(2026, 1, 29)}},
+		{"FREQ=MONTHLY;BYDAY=2MO", date(2026, 1, 1), date(2026, 3, 31), []time.Time{date(2026, 1, 12), date(2026, 2, 9), date(2026, 3, 9)}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", date(2026, 1, 1), date(2026, 3, 31), []time.Time{date(2026, 1, 30), date(2026, 2, 27), date(2026, 3, 27)}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", date(2026, 1, 1), date(2026, 3, 31), []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31)}},
+		// months without 31st day are skipped
+		{"FREQ=MONTHLY", date(2026, 1, 31), date(2026, 3, 31), []time.Time{date(2026, 1, 31), date(2026, 3, 31)}},
+		{"FREQ=MONTHLY;COUNT=2", date(2025, 12, 15), date(2026, 3, 31), []time.Time{date(2026, 1, 15)}},
+		{"FREQ=MONTHLY;UNTIL=20260220", date(2026, 1, 15), date(2026, 3, 31), []time.Time{date(2026, 1, 15), date(2026, 2, 15)}},
+		{"FREQ=YEARLY;BYMONTH=1,3;BYMONTHDAY=10", date(2025, 1, 1), date(2026, 3, 31), []time.Time{date(2026, 1, 10), date(2026, 3, 10)}},
+	}
+	for _, test := range tests {
+		rule, err := rrule.Parse(test.rule)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, rule.Between(test.dtstart, date(2026, 1, 1), test.to), test.want)
+	}
+}
+
+func TestAfterAndBefore(t *testing.T) {
+	// birthday, since long back
+	rule, _ := rrule.Parse("FREQ=YEARLY")
+	dtstart := time.Date(1990, 10, 20, 10, 0, 0, 0, time.UTC)
+	next, ok := rule.After(dtstart, date(2026, 10, 16), false)
+	utils.AssertEqual(t, ok, true)
+	utils.AssertEqual(t, next, time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC))
+	previous, ok := rule.Before(dtstart, date(2026, 10, 16), false)
+	utils.AssertEqual(t, ok, true)
+	utils.AssertEqual(t, previous, time.Date(2025, 10, 20, 10, 0, 0, 0, time.UTC))
+	// there is no occurrence before the start
+	_, ok = rule.Before(dtstart, dtstart, false)
+	utils.AssertEqual(t, ok, false)
+	occurrence, ok := rule.Before(dtstart, dtstart, true)
+	utils.AssertEqual(t, ok, true)
+	utils.AssertEqual(t, occurrence, dtstart)
+	// leap day
+	rule, _ = rrule.Parse("FREQ=YEARLY")
+	next, _ = rule.After(date(2024, 2, 29), date(2024, 3, 1), false)
+	utils.AssertEqual(t, next, date(2028, 2, 29))
+	previous, _ = rule.Before(date(2024, 2, 29), date(2027, 3, 1), false)
+	utils.AssertEqual(t, previous, date(2024, 2, 29))
+	// no occurrence after the end
+	rule, _ = rrule.Parse("FREQ=DAILY;COUNT=5")
+	_, ok = rule.After(date(2026, 1, 1), date(2026, 1, 5), false)
+	utils.AssertEqual(t, ok, false)
+	previous, _ = rule.Before(date(2026, 1, 1), date(2026, 2, 1), false)
+	utils.AssertEqual(t, previous, date(2026, 1, 5))
+	// a rule without any occurrence
+	rule, _ = rrule.Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	_, ok = rule.After(date(2026, 1, 1), date(2026, 1, 1), true)
+	utils.AssertEqual(t, ok, false)
+}
@@ -152,6 +152,68 @@ func TryConvertTildaBasedPath(path string) string {
 	return path
 }
 
+// WriteFileAtomic writes data to the file such that the file either has its
+// old content or the complete new content, even if the process crashes (or
+// the disk gets full) midway.
+// The data is first written (and fsync'ed) to a temporary file in the same
+// directory, which is then renamed over the target file.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) (err error) {
+	// replace the target of a symlink rather than the symlink itself
+	if resolvedPath, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = resolvedPath
+	}
+	dir := filepath.Dir(filePath)
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	// clean up the temporary file in case of any failure
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+	if _, err = tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, filePath); err != nil {
+		return err
+	}
+	// make sure the rename itself is persisted
+	// note: not all platforms support syncing a directory, so the error is ignored
+	if dirFile, err := os.Open(dir); err == nil {
+		_ = dirFile.Sync()
+		dirFile.Close()
+	}
+	return nil
+}
+
+// SymlinkAtomic makes linkPath a symlink pointing to target, replacing any existing file at linkPath.
+// Like `ln -sf`, but the symlink is replaced atomically (by renaming a temporary symlink over it).
+func SymlinkAtomic(target string, linkPath string) error {
+	tmpPath := fmt.Sprintf("%s.tmp-%d", linkPath, time.Now().UnixNano())
+	if err := os.Symlink(target, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, linkPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // AskBoolean asks a boolean question to the user.
 func AskBoolean(msg string) (bool, error) {
 	return askBoolean(msg, os.Stdin)
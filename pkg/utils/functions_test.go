@@ -504,6 +504,38 @@ func TestTryConvertTildaBasedPath(t *testing.T) {
 	}
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filePath := dir + "/data.json"
+	// case 1 (new file)
+	err := utils.WriteFileAtomic(filePath, []byte("first"), 0600)
+	utils.AssertEqual(t, err, nil)
+	got, _ := os.ReadFile(filePath)
+	utils.AssertEqual(t, string(got), "first")
+	stats, _ := os.Stat(filePath)
+	utils.AssertEqual(t, stats.Mode().Perm(), os.FileMode(0600))
+	// case 2 (existing file is replaced)
+	err = utils.WriteFileAtomic(filePath, []byte("second"), 0600)
+	utils.AssertEqual(t, err, nil)
+	got, _ = os.ReadFile(filePath)
+	utils.AssertEqual(t, string(got), "second")
+	// case 3 (symlink is preserved, and its target is updated)
+	linkPath := dir + "/link.json"
+	_ = os.Symlink(filePath, linkPath)
+	err = utils.WriteFileAtomic(linkPath, []byte("third"), 0600)
+	utils.AssertEqual(t, err, nil)
+	got, _ = os.ReadFile(filePath)
+	utils.AssertEqual(t, string(got), "third")
+	linkStats, _ := os.Lstat(linkPath)
+	utils.AssertEqual(t, linkStats.Mode()&os.ModeSymlink != 0, true)
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	utils.AssertEqual(t, len(entries), 2)
+	// case 4 (missing directory)
+	err = utils.WriteFileAtomic(dir+"/no_such_dir/data.json", []byte("x"), 0600)
+	utils.AssertEqual(t, err != nil, true)
+}
+
 func TestAskBoolean(t *testing.T) {
 	var tests = []struct {
 		name      string
@@ -572,3 +604,18 @@ func TestAskBoolean(t *testing.T) {
 		})
 	}
 }
+
+func TestSymlinkAtomic(t *testing.T) {
+	dir := t.TempDir()
+	linkPath := dir + "/latest.json"
+	// case 1 (new symlink)
+	utils.AssertEqual(t, utils.SymlinkAtomic("first.json", linkPath), nil)
+	target, _ := os.Readlink(linkPath)
+	utils.AssertEqual(t, target, "first.json")
+	// case 2 (existing symlink is replaced)
+	utils.AssertEqual(t, utils.SymlinkAtomic("second.json", linkPath), nil)
+	target, _ = os.Readlink(linkPath)
+	utils.AssertEqual(t, target, "second.json")
+	entries, _ := os.ReadDir(dir)
+	utils.AssertEqual(t, len(entries), 1)
+}
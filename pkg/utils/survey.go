@@ -37,6 +37,31 @@ func AskOption(options []string, label string) (int, string, error) {
 	return selectedIndex, options[selectedIndex], nil
 }
 
+// AskOptions function asks the user to choose any number of the options (all of them are chosen by default).
+// It print error, if encountered any (so that they don't have to printed by calling function).
+// It returns the chosen indices.
+func AskOptions(options []string, label string) ([]int, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	var selectedIndices []int
+	prompt := &survey.MultiSelect{
+		Message:  label,
+		Options:  options,
+		Default:  options,
+		PageSize: 25,
+		VimMode:  true,
+	}
+	err := survey.AskOne(prompt, &selectedIndices)
+	if err != nil {
+		// error can happen if user raises an interrupt (such as Ctrl-c, SIGINT)
+		fmt.Printf("%v Prompt failed %v\n", Symbols["warning"], err)
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("You chose %v\n", selectedIndices))
+	return selectedIndices, nil
+}
+
 // GeneratePrompt function generates survey.Input.
 func GeneratePrompt(promptName string, defaultText string) (string, error) {
 	var validator survey.Validator
@@ -102,10 +127,62 @@ func GeneratePrompt(promptName string, defaultText string) (string, error) {
 		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
 	case "note_completed_by":
 		prompt := &survey.Input{
-			Message: "Due Date (format: DD-MM-YYYY or DD-MM), or enter nil to clear existing value: ",
+			Message: "Due Date (format: DD-MM-YYYY or DD-MM, optionally followed by HH:MM and timezone), or enter nil to clear existing value: ",
+			Default: defaultText,
+		}
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(ValidateDateString()))
+	case "note_due_window":
+		prompt := &survey.Input{
+			Message: "Due Window (format: <lead days>[,<grace days>], such as 60 or 1,2), or enter nil to clear existing value: ",
+			Default: defaultText,
+		}
+		validator = survey.MinLength(1)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
+	case "note_snooze":
+		prompt := &survey.Input{
+			Message: "Snooze For (such as 3h, 2 days or 1 week) or Until (such as until 15-11 or until tomorrow at 09:00), or enter nil to wake up: ",
+			Default: defaultText,
+		}
+		validator = survey.MinLength(1)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
+	case "calendar_import_from":
+		prompt := &survey.Input{
+			Message: "Import Events From (such as today or 01-11-2026): ",
+			Default: defaultText,
+		}
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(ValidateDateString()))
+	case "calendar_import_to":
+		prompt := &survey.Input{
+			Message: "Import Events Until (such as +30d or 31-12-2026): ",
 			Default: defaultText,
 		}
 		err = survey.AskOne(prompt, &answer, survey.WithValidator(ValidateDateString()))
+	case "note_completion_comment":
+		prompt := &survey.Input{
+			Message: "Completion Comment (optional): ",
+			Default: defaultText,
+		}
+		validator = survey.MinLength(0)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
+	case "note_recurrence":
+		prompt := &survey.Input{
+			Message: "Recurrence Rule (such as FREQ=WEEKLY;BYDAY=MO,TH or FREQ=MONTHLY;BYMONTHDAY=-1), or enter nil to clear existing value: ",
+			Default: defaultText,
+		}
+		validator = survey.MinLength(1)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
+	case "passphrase":
+		prompt := &survey.Password{
+			Message: "Passphrase of the data file: ",
+		}
+		validator = survey.MinLength(1)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
+	case "passphrase_confirm":
+		prompt := &survey.Password{
+			Message: "Confirm the passphrase: ",
+		}
+		validator = survey.MinLength(1)
+		err = survey.AskOne(prompt, &answer, survey.WithValidator(validator))
 	}
 	return answer, err
 }
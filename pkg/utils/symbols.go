@@ -17,9 +17,11 @@ var Symbols = map[string]string{
 	"home":         "⛺",
 	"noAction":     "❎",
 	"pad":          "📋",
+	"prohibited":   "🚫",
 	"redFlag":      "🚩",
 	"refresh":      "🔄",
 	"search":       "🔎",
+	"snooze":       "😴",
 	"spark":        "⚡",
 	"tag":          "🏷t",
 	"telescope":    "🔭",
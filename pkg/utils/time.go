@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/goyalmunish/reminder/pkg/dateparse"
 )
 
 // Location variable provides location info for `time`.
@@ -32,6 +33,27 @@ func UTCLocation() *time.Location {
 	return location
 }
 
+// LoadLocation returns the location for given IANA timezone name (such as "Asia/Kolkata").
+// For blank name, it returns the local timezone of the system.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown timezone %q: %w", name, err)
+	}
+	return location, nil
+}
+
+// CurrentLocation returns the Location, or the local timezone of the system if it is not set.
+func CurrentLocation() *time.Location {
+	if Location == nil {
+		return time.Local
+	}
+	return Location
+}
+
 // UnixTimestampToTime function converts unix timestamp to time.
 // It serves as central place to switch between UTC and local time.
 // by default use local time, but behavior can be changed via `Location`.
@@ -54,6 +76,14 @@ func UnixTimestampToTimeStr(unixTimestamp int64, timeFormat string) string {
 	return "nil"
 }
 
+// UnixTimestampToTimeStrInLocation function converts unix timestamp to time string in given location.
+func UnixTimestampToTimeStrInLocation(unixTimestamp int64, timeFormat string, location *time.Location) string {
+	if unixTimestamp > 0 {
+		return time.Unix(unixTimestamp, 0).In(location).Format(timeFormat)
+	}
+	return "nil"
+}
+
 // UnixTimestampToLongTimeStr function converts unix timestamp to long time string.
 func UnixTimestampToLongTimeStr(unixTimestamp int64) string {
 	return UnixTimestampToTimeStr(unixTimestamp, time.RFC850)
@@ -109,6 +139,52 @@ func YearForDueDateDDMM(dateMonth string) (int, error) {
 	return year, nil
 }
 
+// ParseDueDate parses the due date string, which is a date expression (such as "DD-MM-YYYY",
+// "DD-MM", "2026-11-03", "tomorrow", "+3d" or "next friday"; see package dateparse) optionally
+// followed by a time of day ("HH:MM", or "at HH:MM") and an IANA timezone (such as "Asia/Kolkata").
+// The date and time are taken in the given timezone, or in the given location if the timezone
+// is missing; and without the time of day, the due date is at the start of the day. A time of day
+// without any date is taken for today.
+func ParseDueDate(text string, location *time.Location) (time.Time, error) {
+	fields := strings.Fields(text)
+	// the timezone (if any) is the last field, followed by the time of day
+	if n := len(fields); n > 1 && (strings.Contains(fields[n-1], "/") || fields[n-1] == "UTC") {
+		zone, err := LoadLocation(fields[n-1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		location, fields = zone, fields[:n-1]
+	}
+	hour, minute := 0, 0
+	if n := len(fields); n > 0 && clockRegex.MatchString(fields[n-1]) {
+		clock, _ := time.Parse("15:04", fields[n-1])
+		hour, minute, fields = clock.Hour(), clock.Minute(), fields[:n-1]
+		if n := len(fields); n > 0 && strings.EqualFold(fields[n-1], "at") {
+			fields = fields[:n-1]
+		}
+		if len(fields) == 0 {
+			fields = []string{"today"}
+		}
+	}
+	date, err := dateparse.Parse(strings.Join(fields, " "), CurrentTime().In(location))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid due date %q: %w", text, err)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, location), nil
+}
+
+// clockRegex matches the time of day in HH:MM format.
+var clockRegex = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):[0-5][0-9]$`)
+
+// DueDateToPreviewStr returns human-readable representation of a due date (such as returned
+// by ParseDueDate), so that it can be previewed before saving it.
+func DueDateToPreviewStr(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format("Mon, 02 Jan 2006")
+	}
+	return t.Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
 // StrToTime converts RFC3339 time sting to time.Time, and sets location to
 // given timezone. If location is blank, then it returns the time as it is.
 func StrToTime(tString string, timezone string) (time.Time, error) {
@@ -151,20 +227,22 @@ func GetZoneFromLocation(loc string) (time.Duration, error) {
 	return dur, nil
 }
 
-// ValidateDateString function validates date string (DD-MM-YYYY) or (DD-MM).
+// ValidateDateString function validates the due date string (see ParseDueDate), such as
+// DD-MM-YYYY, DD-MM, YYYY-MM-DD, tomorrow, +3d, or next friday, optionally followed by time
+// (HH:MM) and timezone (such as "Asia/Kolkata").
 // nil is also valid input
 func ValidateDateString() survey.Validator {
 	// return a validator that checks the length of the string
 	return func(val interface{}) error {
 		if str, ok := val.(string); ok {
-			// if the string is shorter than the given value
 			input := strings.TrimSpace(str)
-			re := regexp.MustCompile(`^((0?[1-9]|[12][0-9]|3[01])-(0?[1-9]|1[012])(-((19|20)\d\d))?|(nil))$`)
-			if re.MatchString(input) {
+			if input == "nil" {
 				return nil
-			} else {
-				return fmt.Errorf("The input must be in the format DD-MM-YYYY or DD-MM.")
 			}
+			if _, err := ParseDueDate(input, CurrentLocation()); err != nil {
+				return fmt.Errorf("%w; the input must be a date (such as DD-MM-YYYY, DD-MM, YYYY-MM-DD, today, tomorrow, +3d, in 2 weeks, next friday, or end of month), optionally followed by HH:MM and timezone", err)
+			}
+			return nil
 		} else {
 			// otherwise we cannot convert the value into a string and cannot enforce length
 			return fmt.Errorf("Invalid type %v", reflect.TypeOf(val).Name())
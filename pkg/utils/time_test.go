@@ -2,6 +2,7 @@ package utils_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -294,14 +295,46 @@ func TestGetZoneFromLocation(t *testing.T) {
 }
 
 func TestValidateDateString(t *testing.T) {
-	errorMsg := "The input must be in the format DD-MM-YYYY or DD-MM."
-	utils.AssertEqual(t, utils.ValidateDateString()("31-12-2020"), nil)
-	utils.AssertEqual(t, utils.ValidateDateString()("nil"), nil)
-	utils.AssertEqual(t, utils.ValidateDateString()("12-31-2020"), errors.New(errorMsg))
-	utils.AssertEqual(t, utils.ValidateDateString()("2020-12-31"), errors.New(errorMsg))
-	utils.AssertEqual(t, utils.ValidateDateString()("2020-31-"), errors.New(errorMsg))
-	utils.AssertEqual(t, utils.ValidateDateString()("2020-31"), errors.New(errorMsg))
-	utils.AssertEqual(t, utils.ValidateDateString()("2020-"), errors.New(errorMsg))
-	utils.AssertEqual(t, utils.ValidateDateString()("2020"), errors.New(errorMsg))
+	errorMsg := "the input must be a date (such as DD-MM-YYYY, DD-MM, YYYY-MM-DD, today, tomorrow, +3d, in 2 weeks, next friday, or end of month), optionally followed by HH:MM and timezone"
+	for _, input := range []string{"31-12-2020", "31-12-2020 18:30", "31-12-2020 9:05 Asia/Kolkata", "31-12 Europe/London", "2020-12-31", "tomorrow at 09:30", "next friday", "+3d", "nil"} {
+		utils.AssertEqual(t, utils.ValidateDateString()(input), nil)
+	}
+	for _, input := range []string{"31-12-2020 24:00", "12-31-2020", "2020-31-", "2020-31", "2020-", "2020", "someday"} {
+		err := utils.ValidateDateString()(input)
+		utils.AssertEqual(t, strings.HasSuffix(err.Error(), errorMsg), true)
+	}
+	utils.AssertEqual(t, strings.HasPrefix(utils.ValidateDateString()("31-12-2020 18:30 Mars/Olympus").Error(), `Unknown timezone "Mars/Olympus"`), true)
 	utils.AssertEqual(t, utils.ValidateDateString()(2020), errors.New("Invalid type int"))
 }
+
+func TestParseDueDate(t *testing.T) {
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	newYork, _ := time.LoadLocation("America/New_York")
+	var tests = []struct {
+		input string
+		want  time.Time
+	}{
+		{"15-12-2021", time.Date(2021, 12, 15, 0, 0, 0, 0, kolkata)},
+		{"15-12-2021 09:30", time.Date(2021, 12, 15, 9, 30, 0, 0, kolkata)},
+		{"15-12-2021 9:30 America/New_York", time.Date(2021, 12, 15, 9, 30, 0, 0, newYork)},
+		{"15-6-2021 America/New_York", time.Date(2021, 6, 15, 0, 0, 0, 0, newYork)},
+		// relative to Fri Oct 16 2026 22:00:00 GMT+0000, which is already Saturday in Kolkata
+		{"tomorrow", time.Date(2026, 10, 18, 0, 0, 0, 0, kolkata)},
+		{"next friday at 18:00", time.Date(2026, 10, 23, 18, 0, 0, 0, kolkata)},
+		{"friday UTC", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"07:15", time.Date(2026, 10, 17, 7, 15, 0, 0, kolkata)},
+	}
+	defer func() { utils.CurrentTime = time.Now }()
+	utils.CurrentTime = func() time.Time { return time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC) }
+	for _, test := range tests {
+		got, err := utils.ParseDueDate(test.input, kolkata)
+		utils.AssertEqual(t, err, nil)
+		utils.AssertEqual(t, got.Unix(), test.want.Unix())
+		utils.AssertEqual(t, got.Location().String(), test.want.Location().String())
+	}
+	// invalid due dates
+	for _, input := range []string{"", "31-02-2021", "15-12-2021 09:30 Asia/Kolkata extra", "15-12-2021 Mars/Olympus"} {
+		_, err := utils.ParseDueDate(input, kolkata)
+		utils.AssertEqual(t, err != nil, true)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Package vault provides passphrase-based authenticated encryption of files.
+
+The key is derived from the passphrase using scrypt, and the content is
+encrypted (and authenticated) using AES-256-GCM. An encrypted file is itself a
+small JSON document (an Envelope), which records the parameters needed for
+decrypting it (except, of course, the passphrase).
+*/
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Format identifies the encrypted files, and the scheme used for encrypting them.
+const Format = "reminder-vault/scrypt+aes-256-gcm"
+
+var (
+	ErrorEmptyPassphrase = errors.New("Passphrase is empty")
+	ErrorWrongPassphrase = errors.New("Wrong passphrase, or the encrypted file is tampered with")
+)
+
+/*
+A KDFParams represents the (scrypt) parameters used for deriving the key from the passphrase.
+*/
+type KDFParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// DefaultKDFParams returns the parameters recommended for interactive use.
+// Deriving a key with them takes roughly 100ms and 32MB of memory.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{N: 1 << 15, R: 8, P: 1}
+}
+
+/*
+An Envelope is the persisted form of the encrypted content.
+Binary fields are (base64) encoded by encoding/json.
+*/
+type Envelope struct {
+	Format     string    `json:"format"`
+	KDF        KDFParams `json:"kdf"`
+	Salt       []byte    `json:"salt"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+/*
+A Vault seals (encrypts) and opens (decrypts) content with a passphrase.
+
+All the content sealed by a vault uses the same salt (with a random nonce each
+time), so that the key is derived just once per vault. Keys derived for opening
+the content sealed with other salts are cached as well.
+*/
+type Vault struct {
+	passphrase []byte
+	params     KDFParams
+	salt       []byte
+	mu         sync.Mutex
+	keys       map[string][]byte
+}
+
+// New returns the vault for the given passphrase, using the default KDF parameters.
+func New(passphrase string) (*Vault, error) {
+	return NewWithParams(passphrase, DefaultKDFParams())
+}
+
+// NewWithParams returns the vault for the given passphrase and KDF parameters.
+func NewWithParams(passphrase string, params KDFParams) (*Vault, error) {
+	if passphrase == "" {
+		return nil, ErrorEmptyPassphrase
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &Vault{
+		passphrase: []byte(passphrase),
+		params:     params,
+		salt:       salt,
+		keys:       make(map[string][]byte),
+	}, nil
+}
+
+// NewForSealed returns the vault for the given passphrase, which adopts the salt and KDF
+// parameters of the sealed content (so that the key isn't derived again for the content
+// sealed later). It returns ErrorWrongPassphrase if the passphrase can't open the content.
+func NewForSealed(passphrase string, sealed []byte) (*Vault, error) {
+	if passphrase == "" {
+		return nil, ErrorEmptyPassphrase
+	}
+	envelope, err := parseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vault{
+		passphrase: []byte(passphrase),
+		params:     envelope.KDF,
+		salt:       envelope.Salt,
+		keys:       make(map[string][]byte),
+	}
+	if _, err := v.Open(sealed); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// key returns the key derived for given salt and parameters.
+func (v *Vault) key(salt []byte, params KDFParams) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%x|%d|%d|%d", salt, params.N, params.R, params.P)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.keys[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key(v.passphrase, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	v.keys[cacheKey] = key
+	return key, nil
+}
+
+// aead returns AES-256-GCM cipher for given salt and parameters.
+func (v *Vault) aead(salt []byte, params KDFParams) (cipher.AEAD, error) {
+	key, err := v.key(salt, params)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts the plaintext, and returns its (JSON encoded) envelope.
+func (v *Vault) Seal(plaintext []byte) ([]byte, error) {
+	gcm, err := v.aead(v.salt, v.params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	envelope := Envelope{
+		Format:     Format,
+		KDF:        v.params,
+		Salt:       v.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(Format)),
+	}
+	return json.MarshalIndent(&envelope, "", "    ")
+}
+
+// Open decrypts the (JSON encoded) envelope, and returns the plaintext.
+// It returns ErrorWrongPassphrase if the content can't be authenticated.
+func (v *Vault) Open(sealed []byte) ([]byte, error) {
+	envelope, err := parseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := v.aead(envelope.Salt, envelope.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("Encrypted file has invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(Format))
+	if err != nil {
+		return nil, ErrorWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// parseEnvelope parses the envelope, and makes sure it is of the supported format.
+func parseEnvelope(sealed []byte) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(sealed, &envelope); err != nil {
+		return nil, fmt.Errorf("Encrypted file is corrupt: %w", err)
+	}
+	if envelope.Format != Format {
+		return nil, fmt.Errorf("Unsupported format %q of the encrypted file", envelope.Format)
+	}
+	return &envelope, nil
+}
+
+// IsSealed tells if the content is an envelope sealed by a vault.
+func IsSealed(content []byte) bool {
+	var header struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return false
+	}
+	return header.Format == Format
+}
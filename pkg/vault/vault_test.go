@@ -0,0 +1,53 @@
+package vault_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/goyalmunish/reminder/pkg/utils"
+	"github.com/goyalmunish/reminder/pkg/vault"
+)
+
+// testParams are cheap KDF parameters, just to keep the tests fast.
+var testParams = vault.KDFParams{N: 1 << 10, R: 8, P: 1}
+
+func TestSealAndOpen(t *testing.T) {
+	plaintext := []byte(`{"notes": [{"text": "1:1 with manager"}]}`)
+	v, err := vault.NewWithParams("secret", testParams)
+	utils.AssertEqual(t, err, nil)
+	// case 1 (sealed content doesn't reveal the plaintext, and opens back)
+	sealed, err := v.Seal(plaintext)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, bytes.Contains(sealed, []byte("manager")), false)
+	utils.AssertEqual(t, vault.IsSealed(sealed), true)
+	utils.AssertEqual(t, vault.IsSealed(plaintext), false)
+	opened, err := v.Open(sealed)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, string(opened), string(plaintext))
+	// case 2 (another vault with the same passphrase opens it too)
+	other, _ := vault.NewWithParams("secret", testParams)
+	opened, err = other.Open(sealed)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, string(opened), string(plaintext))
+	// case 3 (wrong passphrase)
+	wrong, _ := vault.NewWithParams("guess", testParams)
+	_, err = wrong.Open(sealed)
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorWrongPassphrase), true)
+	_, err = vault.NewForSealed("guess", sealed)
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorWrongPassphrase), true)
+	// case 4 (vault adopting the salt of the sealed content)
+	adopted, err := vault.NewForSealed("secret", sealed)
+	utils.AssertEqual(t, err, nil)
+	resealed, _ := adopted.Seal(plaintext)
+	opened, err = v.Open(resealed)
+	utils.AssertEqual(t, err, nil)
+	utils.AssertEqual(t, string(opened), string(plaintext))
+	// case 5 (tampered content)
+	tampered := bytes.Replace(sealed, []byte(`"ciphertext": "`), []byte(`"ciphertext": "AA`), 1)
+	_, err = v.Open(tampered)
+	utils.AssertEqual(t, err != nil, true)
+	// case 6 (empty passphrase)
+	_, err = vault.New("")
+	utils.AssertEqual(t, errors.Is(err, vault.ErrorEmptyPassphrase), true)
+}